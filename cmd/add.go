@@ -3,30 +3,104 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io"
 
+	"github.com/michaelfromorg/tiled/internal/til"
 	"github.com/spf13/cobra"
 )
 
 func newAddCommand() *cobra.Command {
-	return &cobra.Command{
+	var (
+		options til.AddOptions
+		list    bool
+		name    string
+	)
+	command := &cobra.Command{
 		Use:   "add <files...>",
 		Short: "Stage files for the next entry",
-		Args:  cobra.MinimumNArgs(1),
+		Long: "Copy files into .til/staging to attach them to the next entry. Relative paths are kept, so a/main.go " +
+			"and b/main.go do not collide; files outside the current directory are staged under their base name. " +
+			"Directories are added recursively, or as one archive with --bundle, and glob patterns are expanded even " +
+			"when the shell does not. Hidden directories such as .git are skipped unless --hidden is given. Use - with --name to read a file from standard input. A file whose name is " +
+			"already staged with different content is refused unless --force is given. Use --list to show the staged " +
+			"files and 'til reset' to unstage them.",
+		Example: "  til add diagram.png notes.md\n" +
+			"  til add 'src/*.go' docs/\n" +
+			"  til add --bundle zip screenshots/\n" +
+			"  pbpaste | til add - --name snippet.txt\n" +
+			"  til add --force diagram.png\n" +
+			"  til add --list",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if list && len(args) > 0 {
+				return errors.New("--list does not take files")
+			}
+			if !list && len(args) == 0 {
+				return errors.New("requires at least 1 file, or --list")
+			}
+			stdinArgs := 0
+			for _, filePath := range args {
+				if filePath == "-" {
+					stdinArgs++
+				}
+			}
+			if stdinArgs > 0 && name == "" {
+				return errors.New("reading from standard input requires --name")
+			}
+			if stdinArgs == 0 && name != "" {
+				return errors.New("--name is only used with - to read from standard input")
+			}
+			if stdinArgs > 1 {
+				return errors.New("standard input can only be added once")
+			}
 			_, manager, err := loadManager()
 			if err != nil {
 				return err
 			}
+			if list {
+				stagedFiles, err := manager.GetStagedFiles()
+				if err != nil {
+					return err
+				}
+				writeStagedFiles(cmd.OutOrStdout(), stagedFiles)
+				return nil
+			}
 
 			var addErrors []error
 			for _, filePath := range args {
-				if err := manager.AddFile(filePath); err != nil {
+				var staged []string
+				if filePath == "-" {
+					var stagedName string
+					stagedName, err = manager.AddReader(cmd.InOrStdin(), name, options)
+					if err == nil {
+						staged = []string{stagedName}
+					}
+				} else {
+					staged, err = manager.AddPath(filePath, options)
+				}
+				for _, stagedName := range staged {
+					fmt.Fprintf(cmd.OutOrStdout(), "Added file: %s\n", stagedName)
+				}
+				if err != nil {
 					addErrors = append(addErrors, fmt.Errorf("%s: %w", filePath, err))
-					continue
 				}
-				fmt.Fprintf(cmd.OutOrStdout(), "Added file: %s\n", filePath)
 			}
 			return errors.Join(addErrors...)
 		},
 	}
+	command.Flags().BoolVarP(&options.Force, "force", "f", false, "Replace staged files with the same name")
+	command.Flags().BoolVarP(&list, "list", "l", false, "List the staged files instead of adding any")
+	command.Flags().StringVar(&name, "name", "", "Name to stage standard input under when adding -")
+	command.Flags().BoolVar(&options.Hidden, "hidden", false, "Include hidden directories, such as .git, when adding a directory")
+	command.Flags().StringVar(&options.Bundle, "bundle", "", "Stage each directory as one archive: tar (gzip-compressed) or zip")
+	return command
+}
+
+func writeStagedFiles(output io.Writer, stagedFiles []string) {
+	if len(stagedFiles) == 0 {
+		fmt.Fprintln(output, "No files staged for commit.")
+		return
+	}
+	for _, fileName := range stagedFiles {
+		fmt.Fprintf(output, "- %s\n", fileName)
+	}
 }
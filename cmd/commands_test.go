@@ -55,14 +55,26 @@ func TestRootCommandContainsDocumentedCommands(t *testing.T) {
 		"completion",
 		"config",
 		"db",
+		"diff",
+		"doctor",
+		"edit",
 		"export",
+		"feed",
+		"gc",
 		"init",
 		"log",
 		"migrate",
+		"pull",
 		"push",
+		"reset",
 		"restore",
+		"revert",
+		"rm",
+		"show",
+		"site",
 		"slog",
 		"status",
+		"tag",
 		"version",
 	}
 
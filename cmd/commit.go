@@ -27,6 +27,10 @@ func newCommitCommand() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			tags, err := cmd.Flags().GetStringArray("tag")
+			if err != nil {
+				return err
+			}
 
 			messageBody := ""
 			if strings.TrimSpace(message) == "" {
@@ -40,10 +44,7 @@ func newCommitCommand() *cobra.Command {
 					if len(entries) == 0 {
 						return fmt.Errorf("no entries found to amend")
 					}
-					initialContent = entries[0].Message
-					if entries[0].MessageBody != "" {
-						initialContent += "\n\n" + entries[0].MessageBody
-					}
+					initialContent = entryEditorContent(entries[0])
 					stripComments = false
 				}
 
@@ -64,14 +65,14 @@ func newCommitCommand() *cobra.Command {
 			}
 
 			if amend {
-				if err := manager.AmendLastEntryWithBody(message, messageBody); err != nil {
+				if err := manager.AmendLastEntryWithTags(message, messageBody, tags); err != nil {
 					return fmt.Errorf("amend entry: %w", err)
 				}
 				fmt.Fprintln(cmd.OutOrStdout(), "Entry amended successfully")
 				return nil
 			}
 
-			if err := manager.CommitEntryWithBody(message, messageBody); err != nil {
+			if err := manager.CommitEntryWithTags(message, messageBody, tags); err != nil {
 				return fmt.Errorf("commit entry: %w", err)
 			}
 			fmt.Fprintln(cmd.OutOrStdout(), "Entry committed successfully")
@@ -80,6 +81,7 @@ func newCommitCommand() *cobra.Command {
 	}
 	command.Flags().StringP("message", "m", "", "Commit message")
 	command.Flags().Bool("amend", false, "Amend the latest entry")
+	command.Flags().StringArrayP("tag", "t", nil, "Tag the entry (repeatable; with --amend, adds tags)")
 	return command
 }
 
@@ -90,6 +92,14 @@ const commitMessageTemplate = `# Enter your TIL commit message.
 
 `
 
+func entryEditorContent(entry til.Entry) string {
+	content := entry.Message
+	if entry.MessageBody != "" {
+		content += "\n\n" + entry.MessageBody
+	}
+	return content
+}
+
 func removeCommentLines(content string) string {
 	lines := strings.Split(content, "\n")
 	filtered := make([]string, 0, len(lines))
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -16,6 +17,16 @@ import (
 var (
 	isTerminal   = term.IsTerminal
 	readPassword = term.ReadPassword
+
+	// validateNotionProperties checks the NOTION_PROPERTY_* mapping against
+	// the database schema; tests replace it to stay offline.
+	validateNotionProperties = func(ctx context.Context, config til.Config) error {
+		client, err := til.NewNotionClientFromConfig(config, "")
+		if err != nil {
+			return err
+		}
+		return client.ValidateProperties(ctx)
+	}
 )
 
 func newConfigCommand() *cobra.Command {
@@ -32,7 +43,7 @@ func newConfigCommand() *cobra.Command {
 			return writeConfigSummary(cmd.OutOrStdout(), config)
 		},
 	}
-	command.AddCommand(newConfigEditCommand())
+	command.AddCommand(newConfigEditCommand(), newConfigGitCommand(), newConfigReadmeCommand(), newConfigWebhookCommand())
 	return command
 }
 
@@ -56,6 +67,14 @@ func newConfigEditCommand() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if updated.SyncToNotion && !updated.NotionProperties.IsDefault() {
+				if err := validateNotionProperties(cmd.Context(), updated); err != nil {
+					return fmt.Errorf(
+						"validate Notion property mapping (configuration unchanged): %w",
+						err,
+					)
+				}
+			}
 			if err := persistConfigTransition(&config, &updated); err != nil {
 				return err
 			}
@@ -145,6 +164,7 @@ func promptForConfig(
 		updated.NotionAPIKeyAccount = ""
 		updated.NotionAPIKeyInKeyring = false
 		updated.NotionAPIKeyLoadError = nil
+		updated.NotionProperties = til.NotionProperties{}
 	}
 
 	updated.SyncToGit, err = promptYesNoDefault(
@@ -340,6 +360,14 @@ func writeConfigSummary(output io.Writer, config til.Config) error {
 			fmt.Fprintln(&summary, "Notion API key: configured in .til/config (redacted)")
 		}
 		fmt.Fprintf(&summary, "Notion database ID: %s\n", config.NotionDBID)
+		if !config.NotionProperties.IsDefault() {
+			properties := config.NotionProperties
+			for _, field := range properties.Fields() {
+				if *field.Property != "" {
+					fmt.Fprintf(&summary, "Notion %s property: %s\n", field.Label, *field.Property)
+				}
+			}
+		}
 	} else {
 		fmt.Fprintln(&summary, "Notion sync: disabled")
 	}
@@ -347,9 +375,43 @@ func writeConfigSummary(output io.Writer, config til.Config) error {
 	if config.SyncToGit {
 		fmt.Fprintln(&summary, "Git sync: enabled")
 		fmt.Fprintf(&summary, "Git remote: %s\n", til.RedactGitRemoteURL(config.GitRemoteURL))
+		if config.GitAuthor == "" {
+			fmt.Fprintln(&summary, "Git author: from Git's user settings")
+		} else {
+			fmt.Fprintf(&summary, "Git author: %s\n", config.GitAuthor)
+		}
+		if config.GitCommitPerEntry {
+			fmt.Fprintln(&summary, "Git commits: one per entry")
+		} else {
+			fmt.Fprintln(&summary, "Git commits: one per push")
+		}
 	} else {
 		fmt.Fprintln(&summary, "Git sync: disabled")
 	}
+
+	if config.ReadmePages == "" {
+		fmt.Fprintln(&summary, "README pages: none")
+	} else {
+		fmt.Fprintf(&summary, "README pages: by %s\n", config.ReadmePages)
+	}
+
+	if config.SyncToWebhook {
+		fmt.Fprintln(&summary, "Webhook sync: enabled")
+		fmt.Fprintf(&summary, "Webhook URL: %s\n", til.RedactWebhookURL(config.WebhookURL))
+		for _, header := range config.WebhookHeaders {
+			fmt.Fprintf(&summary, "Webhook header: %s (value redacted)\n", header.Name)
+		}
+		switch {
+		case config.WebhookSecretLoadError != nil:
+			fmt.Fprintln(&summary, "Webhook signing secret: unavailable (run 'til config webhook --secret')")
+		case config.WebhookSecretAccount != "":
+			fmt.Fprintln(&summary, "Webhook signing secret: configured (OS keychain)")
+		default:
+			fmt.Fprintln(&summary, "Webhook signing secret: none")
+		}
+	} else {
+		fmt.Fprintln(&summary, "Webhook sync: disabled")
+	}
 	_, err := io.WriteString(output, summary.String())
 	return err
 }
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/michaelfromorg/tiled/internal/til"
+	"github.com/spf13/cobra"
+)
+
+func newConfigGitCommand() *cobra.Command {
+	var (
+		author         string
+		commitPerEntry bool
+	)
+	command := &cobra.Command{
+		Use:   "git",
+		Short: "Configure how Git pushes are committed",
+		Long: "Set the author Git pushes commit as, and whether each changed entry gets its own commit dated when the entry was written. " +
+			"Commit messages always name the entries added, amended, or removed since the last push.",
+		Example: "  til config git --author 'Ada Lovelace <ada@example.com>'\n" +
+			"  til config git --commit-per-entry\n" +
+			"  til config git --author '' --commit-per-entry=false",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, _, err := loadManager()
+			if err != nil {
+				return err
+			}
+			flags := cmd.Flags()
+			if !flags.Changed("author") && !flags.Changed("commit-per-entry") {
+				return writeConfigSummary(cmd.OutOrStdout(), config)
+			}
+			if !config.SyncToGit {
+				return errors.New("Git sync is not configured; run 'til config edit' to enable it")
+			}
+			if flags.Changed("author") {
+				if err := til.ValidateGitAuthor(author); err != nil {
+					return err
+				}
+				config.GitAuthor = author
+			}
+			if flags.Changed("commit-per-entry") {
+				config.GitCommitPerEntry = commitPerEntry
+			}
+
+			if err := til.SaveConfig(config); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Configuration updated successfully")
+			return writeConfigSummary(cmd.OutOrStdout(), config)
+		},
+	}
+	command.Flags().StringVar(&author, "author", "", "Commit as 'Name <email>', or '' to use Git's user settings")
+	command.Flags().BoolVar(&commitPerEntry, "commit-per-entry", false, "Create one commit per changed entry on every push")
+	return command
+}
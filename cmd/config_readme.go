@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/michaelfromorg/tiled/internal/til"
+	"github.com/spf13/cobra"
+)
+
+func newConfigReadmeCommand() *cobra.Command {
+	var pages string
+	command := &cobra.Command{
+		Use:   "readme",
+		Short: "Configure how the generated README is laid out",
+		Long: "Split the generated README into a table of contents and one Markdown page per year or month under til/pages, " +
+			"or keep every entry in README.md. The README can also be customized with a Go template in .til/templates/README.md.tmpl.",
+		Example: "  til config readme --pages month\n" +
+			"  til config readme --pages none",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("pages") {
+				return writeConfigSummary(cmd.OutOrStdout(), config)
+			}
+			if pages == "none" {
+				pages = ""
+			}
+			if err := til.ValidateReadmePages(pages); err != nil {
+				return err
+			}
+
+			config.ReadmePages = pages
+			if err := til.SaveConfig(config); err != nil {
+				return err
+			}
+			manager.Config = config
+			if err := manager.RefreshReadme(); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Configuration updated successfully")
+			return writeConfigSummary(cmd.OutOrStdout(), config)
+		},
+	}
+	command.Flags().StringVar(&pages, "pages", "", "Split the README by year or month, or 'none' to keep one page")
+	return command
+}
@@ -32,6 +32,51 @@ func TestWriteConfigSummaryRedactsCredentials(t *testing.T) {
 	assert.Contains(t, summary, "https://github.com/example/learning.git")
 	assert.NotContains(t, summary, config.NotionAPIKey)
 	assert.NotContains(t, summary, "git-secret")
+	assert.Contains(t, summary, "Git author: from Git's user settings")
+	assert.Contains(t, summary, "Git commits: one per push")
+	assert.Contains(t, summary, "README pages: none")
+}
+
+func TestWriteConfigSummaryRedactsWebhookSettings(t *testing.T) {
+	config := til.Config{
+		DataDir:              "/tmp/learning",
+		SyncToWebhook:        true,
+		WebhookURL:           "https://hooks.example.com/services/T0/B0/token-value",
+		WebhookHeaders:       []til.WebhookHeader{{Name: "Authorization", Value: "Bearer header-secret"}},
+		WebhookSecret:        "signing-secret",
+		WebhookSecretAccount: "webhook-account",
+	}
+	var output bytes.Buffer
+
+	require.NoError(t, writeConfigSummary(&output, config))
+	summary := output.String()
+	assert.Contains(t, summary, "Webhook sync: enabled\n")
+	assert.Contains(t, summary, "Webhook URL: https://hooks.example.com/...\n")
+	assert.Contains(t, summary, "Webhook header: Authorization (value redacted)\n")
+	assert.Contains(t, summary, "Webhook signing secret: configured (OS keychain)\n")
+	for _, secret := range []string{"token-value", "header-secret", "signing-secret"} {
+		assert.NotContains(t, summary, secret)
+	}
+}
+
+func TestWriteConfigSummaryListsNotionPropertyMapping(t *testing.T) {
+	config := til.Config{
+		DataDir:      "/tmp/learning",
+		SyncToNotion: true,
+		NotionAPIKey: "notion-secret-value",
+		NotionDBID:   "database-id",
+		NotionProperties: til.NotionProperties{
+			Date:     "Created",
+			CommitID: "Commit",
+		},
+	}
+	var output bytes.Buffer
+
+	require.NoError(t, writeConfigSummary(&output, config))
+	summary := output.String()
+	assert.Contains(t, summary, "Notion created date property: Created\n")
+	assert.Contains(t, summary, "Notion commit ID property: Commit\n")
+	assert.NotContains(t, summary, "tags property")
 }
 
 func TestWriteConfigSummaryReportsKeyringAndUnavailableCredentials(t *testing.T) {
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+
+	"github.com/michaelfromorg/tiled/internal/til"
+	"github.com/spf13/cobra"
+)
+
+func newConfigWebhookCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "webhook",
+		Short: "Configure the webhook sync destination",
+		Long: "Configure a webhook that 'til push' sends new and changed entries to as JSON. " +
+			"The signing secret is stored in the OS keychain and never written to .til/config.",
+		Example: "  til config webhook --url https://chat.example.com/hooks/til --header 'Authorization: Bearer token'\n" +
+			"  til config webhook --secret\n" +
+			"  til config webhook --disable",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, _, err := loadManager()
+			if err != nil {
+				return err
+			}
+			flags := cmd.Flags()
+			disable, err := flags.GetBool("disable")
+			if err != nil {
+				return err
+			}
+			headerValues, err := flags.GetStringArray("header")
+			if err != nil {
+				return err
+			}
+			clearHeaders, err := flags.GetBool("clear-headers")
+			if err != nil {
+				return err
+			}
+			setSecret, err := flags.GetBool("secret")
+			if err != nil {
+				return err
+			}
+			clearSecret, err := flags.GetBool("clear-secret")
+			if err != nil {
+				return err
+			}
+			if setSecret && clearSecret {
+				return errors.New("--secret and --clear-secret cannot be used together")
+			}
+
+			updated := config
+			if disable {
+				updated.SyncToWebhook = false
+				updated.WebhookURL = ""
+				updated.WebhookHeaders = nil
+				updated.WebhookSecret = ""
+				updated.WebhookSecretAccount = ""
+				updated.WebhookSecretLoadError = nil
+			} else {
+				if flags.Changed("url") {
+					updated.WebhookURL, err = flags.GetString("url")
+					if err != nil {
+						return err
+					}
+				}
+				if updated.WebhookURL == "" {
+					return errors.New("--url is required to enable the webhook")
+				}
+				if err := til.ValidateWebhookURL(updated.WebhookURL); err != nil {
+					return err
+				}
+				updated.SyncToWebhook = true
+
+				if clearHeaders {
+					updated.WebhookHeaders = nil
+				}
+				if len(headerValues) > 0 {
+					updated.WebhookHeaders = nil
+					for _, value := range headerValues {
+						header, err := til.ParseWebhookHeader(value)
+						if err != nil {
+							return err
+						}
+						updated.WebhookHeaders = append(updated.WebhookHeaders, header)
+					}
+				}
+
+				if clearSecret {
+					updated.WebhookSecret = ""
+					updated.WebhookSecretAccount = ""
+					updated.WebhookSecretLoadError = nil
+				}
+				if setSecret {
+					reader := bufio.NewReader(cmd.InOrStdin())
+					updated.WebhookSecret, err = promptSecretString(
+						reader,
+						cmd.InOrStdin(),
+						cmd.OutOrStdout(),
+						"Enter the webhook signing secret: ",
+					)
+					if err != nil {
+						return err
+					}
+					if err := til.StoreWebhookSecret(&updated); err != nil {
+						return err
+					}
+				}
+			}
+
+			if err := til.SaveConfig(updated); err != nil {
+				if setSecret && updated.WebhookSecretAccount != config.WebhookSecretAccount {
+					_ = til.DeleteWebhookSecret(updated)
+				}
+				return err
+			}
+			if config.WebhookSecretAccount != "" && updated.WebhookSecretAccount != config.WebhookSecretAccount {
+				if err := til.DeleteWebhookSecret(config); err != nil {
+					return fmt.Errorf(
+						"configuration saved, but the previous OS keychain entry could not be removed: %w",
+						err,
+					)
+				}
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), "Configuration updated successfully")
+			return writeConfigSummary(cmd.OutOrStdout(), updated)
+		},
+	}
+	command.Flags().String("url", "", "URL that entries are POSTed to")
+	command.Flags().StringArray("header", nil, "Header sent with every delivery as 'Name: value' (repeatable; replaces the saved headers)")
+	command.Flags().Bool("clear-headers", false, "Remove the saved headers")
+	command.Flags().Bool("secret", false, "Prompt for an HMAC signing secret and store it in the OS keychain")
+	command.Flags().Bool("clear-secret", false, "Stop signing deliveries and forget the signing secret")
+	command.Flags().Bool("disable", false, "Disable the webhook and remove its settings")
+	return command
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/michaelfromorg/tiled/internal/til"
+	"github.com/spf13/cobra"
+)
+
+func newDoctorCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the repository, configuration, and destinations for problems",
+		Long: "Check the configuration file, secrets in the OS keychain, database integrity, the staging directory, " +
+			"stored bodies and attachments and their checksums, whether the README is up to date, whether the Git remote " +
+			"is reachable, and whether the Notion database has the properties entries are written to. " +
+			"Each problem is printed with a suggested fix, and the command fails if any are found. " +
+			"Warnings, such as orphaned files, do not make it fail.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			workingDirectory, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+			config, err := til.LoadConfig(workingDirectory)
+			if err != nil {
+				if errors.Is(err, til.ErrConfigNotFound) {
+					return err
+				}
+				return writeDoctorReport(cmd.OutOrStdout(), til.DoctorConfigError(err))
+			}
+			manager, err := openManager(config)
+			if err != nil {
+				return err
+			}
+			report, err := manager.Doctor(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return writeDoctorReport(cmd.OutOrStdout(), report)
+		},
+	}
+}
+
+// writeDoctorReport prints each check and its findings, and returns an
+// error when any of them is a problem.
+func writeDoctorReport(output io.Writer, report til.DoctorReport) error {
+	for _, check := range report.Checks {
+		switch {
+		case check.Skipped != "":
+			fmt.Fprintf(output, "%s: skipped (%s)\n", check.Name, check.Skipped)
+			continue
+		case len(check.Findings) == 0:
+			fmt.Fprintf(output, "%s: ok\n", check.Name)
+			continue
+		case check.Problems() == 0:
+			fmt.Fprintf(output, "%s: %d %s\n", check.Name, len(check.Findings), pluralizeWarning(len(check.Findings)))
+		default:
+			fmt.Fprintf(output, "%s: %d %s\n", check.Name, check.Problems(), pluralizeProblem(check.Problems()))
+		}
+		for _, finding := range check.Findings {
+			label := ""
+			if finding.Warning {
+				label = "warning: "
+			}
+			fmt.Fprintf(output, "  - %s%s\n", label, finding.Problem)
+			fmt.Fprintf(output, "    Fix: %s\n", finding.Fix)
+		}
+	}
+
+	if !report.Healthy() {
+		return fmt.Errorf("til doctor found %d %s", report.Problems(), pluralizeProblem(report.Problems()))
+	}
+	fmt.Fprintln(output, "\nNo problems found.")
+	return nil
+}
+
+func pluralizeProblem(count int) string {
+	if count == 1 {
+		return "problem"
+	}
+	return "problems"
+}
+
+func pluralizeWarning(count int) string {
+	if count == 1 {
+		return "warning"
+	}
+	return "warnings"
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/michaelfromorg/tiled/internal/til"
+	"github.com/spf13/cobra"
+)
+
+func newEditCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "edit <commit-id>",
+		Short: "Edit the title and body of any entry",
+		Long:  "Edit an entry in your editor, pre-filled with its current title and body, or replace them with -m. The commit ID may be abbreviated to any unique prefix of at least four characters.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			message, err := cmd.Flags().GetString("message")
+			if err != nil {
+				return err
+			}
+
+			entry, err := manager.GetEntry(args[0])
+			if err != nil {
+				return err
+			}
+
+			messageBody := ""
+			if strings.TrimSpace(message) == "" {
+				content, err := til.OpenEditor(entryEditorContent(entry))
+				if err != nil {
+					return fmt.Errorf("open editor: %w", err)
+				}
+				if strings.TrimSpace(content) == "" {
+					fmt.Fprintln(cmd.OutOrStdout(), "Aborting edit due to empty message")
+					return nil
+				}
+				message, messageBody = til.SplitCommitMessage(content)
+			} else {
+				message, messageBody = til.SplitCommitMessage(message)
+			}
+
+			if err := manager.EditEntry(entry.CommitID, message, messageBody); err != nil {
+				return fmt.Errorf("edit entry: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Entry %s updated successfully\n", entry.CommitID)
+			return nil
+		},
+	}
+	command.Flags().StringP("message", "m", "", "Replacement commit message")
+	return command
+}
@@ -23,20 +23,12 @@ type entryQueryOptions struct {
 	since   string
 	until   string
 	reverse bool
+	tags    []string
+	literal bool
 	long    bool
 	json    bool
 }
 
-type logEntryJSON struct {
-	CommitID     string   `json:"commit_id"`
-	Date         string   `json:"date"`
-	Message      string   `json:"message"`
-	MessageBody  string   `json:"message_body,omitempty"`
-	Files        []string `json:"files"`
-	IsCommitted  bool     `json:"is_committed"`
-	NotionSynced bool     `json:"notion_synced"`
-}
-
 func addEntryQueryFlags(command *cobra.Command, options *entryQueryOptions) {
 	flags := command.Flags()
 	flags.IntVarP(&options.number, "number", "n", 10, "Number of entries to show")
@@ -44,6 +36,7 @@ func addEntryQueryFlags(command *cobra.Command, options *entryQueryOptions) {
 	flags.StringVar(&options.date, "date", "", "Show entries recorded on a date (YYYY-MM-DD)")
 	flags.StringVar(&options.since, "since", "", "Show entries on or after a date (YYYY-MM-DD)")
 	flags.StringVar(&options.until, "until", "", "Show entries on or before a date (YYYY-MM-DD)")
+	flags.StringArrayVar(&options.tags, "tag", nil, "Show entries with a tag (repeatable; entries must have every tag)")
 	flags.BoolVarP(&options.reverse, "reverse", "r", false, "Show oldest entries first")
 	flags.BoolVarP(&options.long, "long", "l", false, "Show entry bodies and full metadata")
 	flags.BoolVar(&options.json, "json", false, "Print entries as JSON")
@@ -59,10 +52,18 @@ func runEntryQuery(command *cobra.Command, search string, options entryQueryOpti
 	if err != nil {
 		return err
 	}
-	entries, err := manager.QueryEntries(query)
+	matches, err := manager.SearchEntries(query)
 	if err != nil {
 		return err
 	}
+	entries := make([]til.Entry, len(matches))
+	snippets := map[string]string{}
+	for i, match := range matches {
+		entries[i] = match.Entry
+		if match.Snippet != "" {
+			snippets[match.CommitID] = match.Snippet
+		}
+	}
 
 	if options.json {
 		return writeEntriesJSON(command.OutOrStdout(), entries)
@@ -76,9 +77,9 @@ func runEntryQuery(command *cobra.Command, search string, options entryQueryOpti
 		return nil
 	}
 	if options.long {
-		return writeEntriesLong(command.OutOrStdout(), entries)
+		return writeEntriesLong(command.OutOrStdout(), entries, snippets)
 	}
-	return writeEntriesTable(command.OutOrStdout(), entries)
+	return writeEntriesTable(command.OutOrStdout(), entries, snippets)
 }
 
 func buildEntryQuery(
@@ -105,6 +106,8 @@ func buildEntryQuery(
 	query := til.EntryQuery{
 		Limit:       options.number,
 		Search:      strings.TrimSpace(search),
+		Tags:        append([]string(nil), options.tags...),
+		Literal:     options.literal,
 		OldestFirst: options.reverse,
 	}
 	if options.all {
@@ -152,27 +155,37 @@ func parseCalendarDate(flagName, value string) (time.Time, error) {
 	return date, nil
 }
 
-func writeEntriesTable(output io.Writer, entries []til.Entry) error {
+// writeEntriesTable prints one row per entry. When snippets is non-empty, a MATCH
+// column shows each entry's highlighted search snippet.
+func writeEntriesTable(output io.Writer, entries []til.Entry, snippets map[string]string) error {
 	table := tabwriter.NewWriter(output, 0, 4, 2, ' ', 0)
-	if _, err := fmt.Fprintln(table, "COMMIT\tDATE\tMESSAGE\tFILES"); err != nil {
+	header := "COMMIT\tDATE\tMESSAGE\tTAGS\tFILES"
+	if len(snippets) > 0 {
+		header += "\tMATCH"
+	}
+	if _, err := fmt.Fprintln(table, header); err != nil {
 		return err
 	}
 	for _, entry := range entries {
-		if _, err := fmt.Fprintf(
-			table,
-			"%s\t%s\t%s\t%s\n",
+		row := fmt.Sprintf(
+			"%s\t%s\t%s\t%s\t%s",
 			entry.CommitID,
 			entry.Date.Format(calendarDateLayout),
 			singleLine(entry.Message),
+			formatTags(entry.Tags),
 			formatFiles(entry.Files),
-		); err != nil {
+		)
+		if len(snippets) > 0 {
+			row += "\t" + singleLine(snippets[entry.CommitID])
+		}
+		if _, err := fmt.Fprintln(table, row); err != nil {
 			return err
 		}
 	}
 	return table.Flush()
 }
 
-func writeEntriesLong(output io.Writer, entries []til.Entry) error {
+func writeEntriesLong(output io.Writer, entries []til.Entry, snippets map[string]string) error {
 	for index, entry := range entries {
 		if index > 0 {
 			if _, err := fmt.Fprintln(output); err != nil {
@@ -185,9 +198,17 @@ func writeEntriesLong(output io.Writer, entries []til.Entry) error {
 		if _, err := fmt.Fprintf(output, "Date:   %s\n", entry.Date.Format(time.RFC3339)); err != nil {
 			return err
 		}
+		if _, err := fmt.Fprintf(output, "Tags:   %s\n", formatTags(entry.Tags)); err != nil {
+			return err
+		}
 		if _, err := fmt.Fprintf(output, "Files:  %s\n", formatFiles(entry.Files)); err != nil {
 			return err
 		}
+		if snippet := snippets[entry.CommitID]; snippet != "" {
+			if _, err := fmt.Fprintf(output, "Match:  %s\n", singleLine(snippet)); err != nil {
+				return err
+			}
+		}
 		if _, err := fmt.Fprintf(output, "\n    %s\n", entry.Message); err != nil {
 			return err
 		}
@@ -203,20 +224,9 @@ func writeEntriesLong(output io.Writer, entries []til.Entry) error {
 }
 
 func writeEntriesJSON(output io.Writer, entries []til.Entry) error {
-	result := make([]logEntryJSON, len(entries))
+	result := make([]til.EntryJSON, len(entries))
 	for i, entry := range entries {
-		result[i] = logEntryJSON{
-			CommitID:     entry.CommitID,
-			Date:         entry.Date.Format(time.RFC3339Nano),
-			Message:      entry.Message,
-			MessageBody:  entry.MessageBody,
-			Files:        append([]string(nil), entry.Files...),
-			IsCommitted:  entry.IsCommitted,
-			NotionSynced: entry.NotionSynced,
-		}
-		if result[i].Files == nil {
-			result[i].Files = []string{}
-		}
+		result[i] = til.NewEntryJSON(entry)
 	}
 	encoder := json.NewEncoder(output)
 	encoder.SetIndent("", "  ")
@@ -241,3 +251,10 @@ func formatFiles(files []string) string {
 	}
 	return strings.Join(names, ", ")
 }
+
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return "-"
+	}
+	return strings.Join(tags, ", ")
+}
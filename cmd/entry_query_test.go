@@ -24,6 +24,13 @@ func TestBuildEntryQueryDateRange(t *testing.T) {
 	assert.True(t, query.OldestFirst)
 	assert.Equal(t, "2025-01-02", query.SinceDate)
 	assert.Equal(t, "2025-01-05", query.BeforeDate)
+
+	query, err = buildEntryQuery(command, "", entryQueryOptions{
+		number: 5,
+		tags:   []string{"go", "sql"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "sql"}, query.Tags)
 }
 
 func TestBuildEntryQueryValidation(t *testing.T) {
@@ -56,28 +63,41 @@ func TestEntryOutputFormats(t *testing.T) {
 		Message:      "Learned tables",
 		MessageBody:  "Body line one\nBody line two",
 		Files:        []string{"example.sql"},
+		Tags:         []string{"sql"},
 		IsCommitted:  true,
 		NotionSynced: true,
 		CommitID:     "abc12345",
 	}
 
 	var table bytes.Buffer
-	require.NoError(t, writeEntriesTable(&table, []til.Entry{entry}))
+	require.NoError(t, writeEntriesTable(&table, []til.Entry{entry}, nil))
 	assert.Contains(t, table.String(), "COMMIT")
 	assert.Contains(t, table.String(), "abc12345")
 	assert.Contains(t, table.String(), "example.sql")
+	assert.Contains(t, table.String(), "TAGS")
 
 	var long bytes.Buffer
-	require.NoError(t, writeEntriesLong(&long, []til.Entry{entry}))
+	require.NoError(t, writeEntriesLong(&long, []til.Entry{entry}, nil))
 	assert.Contains(t, long.String(), "commit abc12345")
 	assert.Contains(t, long.String(), "Body line two")
 
+	snippets := map[string]string{"abc12345": "Learned **tables**"}
+	table.Reset()
+	require.NoError(t, writeEntriesTable(&table, []til.Entry{entry}, snippets))
+	assert.Contains(t, table.String(), "MATCH")
+	assert.Contains(t, table.String(), "Learned **tables**")
+
+	long.Reset()
+	require.NoError(t, writeEntriesLong(&long, []til.Entry{entry}, snippets))
+	assert.Contains(t, long.String(), "Match:  Learned **tables**")
+
 	var encoded bytes.Buffer
 	require.NoError(t, writeEntriesJSON(&encoded, []til.Entry{entry}))
-	var decoded []logEntryJSON
+	var decoded []til.EntryJSON
 	require.NoError(t, json.Unmarshal(encoded.Bytes(), &decoded))
 	require.Len(t, decoded, 1)
 	assert.Equal(t, "abc12345", decoded[0].CommitID)
 	assert.Equal(t, []string{"example.sql"}, decoded[0].Files)
+	assert.Equal(t, []string{"sql"}, decoded[0].Tags)
 	assert.True(t, decoded[0].IsCommitted)
 }
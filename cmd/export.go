@@ -20,11 +20,12 @@ func newExportCommand() *cobra.Command {
 		exportFormat string
 		outputPath   string
 		force        bool
+		tags         []string
 	)
 	command := &cobra.Command{
 		Use:   "export",
 		Short: "Export all TIL entries",
-		Long:  "Export every entry, oldest first, as Markdown or JSON. Attachments are referenced by name but are not copied.",
+		Long:  "Export every entry, oldest first, as Markdown or JSON. Use --tag to export a subset. Attachments are referenced by name but are not copied.",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			format, err := normalizeExportFormat(exportFormat)
@@ -39,7 +40,10 @@ func newExportCommand() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			entries, err := manager.QueryEntries(til.EntryQuery{OldestFirst: true})
+			entries, err := manager.QueryEntries(til.EntryQuery{
+				Tags:        tags,
+				OldestFirst: true,
+			})
 			if err != nil {
 				return err
 			}
@@ -80,6 +84,7 @@ func newExportCommand() *cobra.Command {
 	)
 	command.Flags().StringVarP(&outputPath, "output", "o", "", "Write to a file instead of stdout")
 	command.Flags().BoolVar(&force, "force", false, "Replace an existing regular output file")
+	command.Flags().StringArrayVar(&tags, "tag", nil, "Export only entries with a tag (repeatable)")
 	return command
 }
 
@@ -142,6 +147,15 @@ func writeEntriesMarkdown(output io.Writer, entries []til.Entry) error {
 		if _, err := fmt.Fprintf(output, "- Notion: %s\n", notionStatus); err != nil {
 			return err
 		}
+		if len(entry.Tags) > 0 {
+			tags := make([]string, len(entry.Tags))
+			for i, tag := range entry.Tags {
+				tags[i] = "`#" + tag + "`"
+			}
+			if _, err := fmt.Fprintf(output, "- Tags: %s\n", strings.Join(tags, ", ")); err != nil {
+				return err
+			}
+		}
 		if len(entry.Files) == 0 {
 			if _, err := fmt.Fprintln(output, "- Attachments: none"); err != nil {
 				return err
@@ -20,6 +20,7 @@ func TestRenderMarkdownExport(t *testing.T) {
 			Message:      "Slices & maps",
 			MessageBody:  "A **Markdown** body.",
 			Files:        []string{"example_[one].go"},
+			Tags:         []string{"go"},
 			IsCommitted:  true,
 			NotionSynced: true,
 			CommitID:     "abc12345",
@@ -33,6 +34,7 @@ func TestRenderMarkdownExport(t *testing.T) {
 	assert.Contains(t, export, "## 2025-01-02 — Slices & maps")
 	assert.Contains(t, export, "- Commit: `abc12345`")
 	assert.Contains(t, export, "- Notion: synced")
+	assert.Contains(t, export, "- Tags: `#go`")
 	assert.Contains(t, export, "example\\_\\[one\\].go")
 	assert.Contains(t, export, "A **Markdown** body.")
 }
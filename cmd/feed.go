@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/michaelfromorg/tiled/internal/til"
+	"github.com/spf13/cobra"
+)
+
+func newFeedCommand() *cobra.Command {
+	var options til.FeedOptions
+	command := &cobra.Command{
+		Use:   "feed",
+		Short: "Print an Atom feed of the latest entries",
+		Long: "Print an Atom feed of the latest entries, newest first. Entry IDs are derived from commit IDs, " +
+			"so they stay the same when an entry is edited. Attachments are linked as enclosures when the Git remote is on GitHub. " +
+			"Use 'til push --git --feed' to publish the feed as feed.xml next to README.md.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if options.Limit <= 0 {
+				return errors.New("--number must be greater than zero")
+			}
+			_, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			feed, err := manager.RenderFeed(options)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(feed)
+			return err
+		},
+	}
+	command.Flags().IntVarP(&options.Limit, "number", "n", til.DefaultFeedEntries, "Number of entries to include")
+	command.Flags().StringVar(&options.Author, "author", "", "Feed author (default: Git user.name)")
+	command.Flags().StringVar(&options.SelfURL, "url", "", "URL the feed is published at (default: raw feed.xml on GitHub)")
+	return command
+}
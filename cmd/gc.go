@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/michaelfromorg/tiled/internal/til"
+	"github.com/spf13/cobra"
+)
+
+func newGCCommand() *cobra.Command {
+	var options til.GCOptions
+	command := &cobra.Command{
+		Use:   "gc",
+		Short: "Find missing and orphaned body and attachment files",
+		Long: "Cross-reference the bodies and attachments recorded in the database with the files under til/files. " +
+			"Report files that are referenced but missing, and orphaned files that nothing refers to, including copies " +
+			"left in .til/staging by interrupted commands. Orphans are only deleted with --prune; add --quarantine to move them aside instead.",
+		Example: "  til gc\n" +
+			"  til gc --prune\n" +
+			"  til gc --prune --quarantine ~/til-quarantine",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if options.QuarantineDir != "" && !options.Prune {
+				return errors.New("--quarantine requires --prune")
+			}
+			_, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			report, err := manager.CollectGarbage(options)
+			if err != nil {
+				return err
+			}
+			writeGCReport(cmd.OutOrStdout(), report)
+			return nil
+		},
+	}
+	command.Flags().BoolVar(&options.Prune, "prune", false, "Delete orphaned files")
+	command.Flags().StringVar(&options.QuarantineDir, "quarantine", "", "Move orphaned files to this directory instead of deleting them")
+	return command
+}
+
+func writeGCReport(output io.Writer, report til.GCReport) {
+	fmt.Fprintf(output, "Checked %d referenced %s and %d staged %s.\n",
+		report.Referenced, pluralizeFile(report.Referenced),
+		report.Staged, pluralizeFile(report.Staged))
+	if len(report.Missing) == 0 && len(report.Orphans) == 0 {
+		fmt.Fprintln(output, "No missing or orphaned files.")
+		return
+	}
+
+	if len(report.Missing) > 0 {
+		fmt.Fprintf(output, "\nMissing %d %s:\n", len(report.Missing), pluralizeFile(len(report.Missing)))
+		for _, missing := range report.Missing {
+			owner := missing.CommitID
+			if missing.Revision > 0 {
+				owner = fmt.Sprintf("%s@%d", missing.CommitID, missing.Revision)
+			}
+			what := "body"
+			if missing.Name != "" {
+				what = "attachment " + missing.Name
+			}
+			fmt.Fprintf(output, "  %s %s: %s\n", owner, what, missing.Path)
+		}
+	}
+
+	if len(report.Orphans) == 0 {
+		return
+	}
+	fmt.Fprintf(output, "\n%d orphaned %s (%d bytes):\n",
+		len(report.Orphans), pluralizeFile(len(report.Orphans)), report.OrphanedBytes())
+	for _, orphan := range report.Orphans {
+		fmt.Fprintf(output, "  %s (%d bytes)\n", orphan.Path, orphan.Size)
+	}
+	switch {
+	case !report.Pruned:
+		fmt.Fprintln(output, "\nRun 'til gc --prune' to delete them, or add --quarantine <dir> to move them aside.")
+	case report.QuarantineDir != "":
+		fmt.Fprintf(output, "\nMoved %d orphaned %s to %s.\n",
+			len(report.Orphans), pluralizeFile(len(report.Orphans)), report.QuarantineDir)
+	default:
+		fmt.Fprintf(output, "\nDeleted %d orphaned %s.\n", len(report.Orphans), pluralizeFile(len(report.Orphans)))
+	}
+}
+
+func pluralizeFile(count int) string {
+	if count == 1 {
+		return "file"
+	}
+	return "files"
+}
@@ -1,17 +1,31 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
 
 func newLogCommand() *cobra.Command {
-	var options entryQueryOptions
+	var (
+		options   entryQueryOptions
+		revisions string
+	)
 	command := &cobra.Command{
 		Use:   "log",
 		Short: "Show TIL entries",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			if cmd.Flags().Changed("revisions") {
+				if options.json {
+					return errors.New("--revisions cannot be combined with --json")
+				}
+				return runRevisionLog(cmd, revisions, options.long)
+			}
 			return runEntryQuery(cmd, "", options)
 		},
 	}
 	addEntryQueryFlags(command, &options)
+	command.Flags().StringVar(&revisions, "revisions", "", "List the earlier revisions of an entry")
 	return command
 }
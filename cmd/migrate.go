@@ -12,7 +12,7 @@ import (
 func newMigrateCommand() *cobra.Command {
 	command := &cobra.Command{
 		Use:   "migrate",
-		Short: "Migrate legacy YAML or Markdown storage to SQLite",
+		Short: "Migrate legacy storage to SQLite or upgrade the database schema",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			workingDirectory, err := os.Getwd()
@@ -24,6 +24,20 @@ func newMigrateCommand() *cobra.Command {
 				return err
 			}
 
+			manager := til.NewManager(config)
+			if manager.IsInitialized() {
+				report, err := manager.UpgradeSchema()
+				if err != nil {
+					return fmt.Errorf("upgrade database schema: %w", err)
+				}
+				if !report.Upgraded() {
+					fmt.Fprintf(cmd.OutOrStdout(), "Database schema is up to date (version %d)\n", report.ToVersion)
+					return nil
+				}
+				writeSchemaUpgradeReport(cmd.OutOrStdout(), report)
+				return nil
+			}
+
 			confirmed, err := cmd.Flags().GetBool("yes")
 			if err != nil {
 				return err
@@ -43,7 +57,6 @@ func newMigrateCommand() *cobra.Command {
 				return nil
 			}
 
-			manager := til.NewManager(config)
 			if err := manager.MigrateToSQL(); err != nil {
 				return fmt.Errorf("migrate entries: %w", err)
 			}
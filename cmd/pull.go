@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/michaelfromorg/tiled/internal/til"
+	"github.com/spf13/cobra"
+)
+
+func newPullCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "pull",
+		Short: "Import entries from sync destinations",
+		Long: "Import entries from every configured destination, or only the ones selected with --git and --notion.\n\n" +
+			"--git fetches the Git remote and merges entries pushed from other devices by commit ID, copies their files, regenerates the README, and commits the merge. Entries changed on both devices keep the local version; the other version is saved as a revision.\n\n" +
+			"--notion imports pages added to the Notion database since the last pull and applies title and body changes made in Notion. Entries changed both locally and in Notion are reported as conflicts and left unchanged unless --theirs is given.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			theirs, err := cmd.Flags().GetBool("theirs")
+			if err != nil {
+				return err
+			}
+			pullGit, err := cmd.Flags().GetBool("git")
+			if err != nil {
+				return err
+			}
+			pullNotion, err := cmd.Flags().GetBool("notion")
+			if err != nil {
+				return err
+			}
+			if pullGit && !config.SyncToGit {
+				return errors.New("Git sync is not configured")
+			}
+			if pullNotion && !config.SyncToNotion {
+				return errors.New("Notion sync is not configured")
+			}
+			if !pullGit && !pullNotion {
+				pullGit, pullNotion = config.SyncToGit, config.SyncToNotion
+				if !pullGit && !pullNotion {
+					return errors.New("no sync destinations are configured")
+				}
+			}
+
+			output := cmd.OutOrStdout()
+			if pullGit {
+				report, err := manager.PullGit()
+				if err != nil {
+					return err
+				}
+				til.WriteGitPullReport(output, report)
+			}
+			if !pullNotion {
+				return nil
+			}
+			client, err := til.NewNotionClientFromConfig(config, "")
+			if err != nil {
+				return err
+			}
+			report, err := manager.PullNotion(context.Background(), client, theirs)
+			writeNotionPullReport(output, report)
+			if err != nil {
+				return err
+			}
+			if len(report.Conflicts) > 0 {
+				return fmt.Errorf(
+					"%d conflicting %s left unchanged; run 'til push --notion' to keep the local version or 'til pull --notion --theirs' to take Notion's",
+					len(report.Conflicts),
+					pluralizeEntry(len(report.Conflicts)),
+				)
+			}
+			return nil
+		},
+	}
+	command.Flags().Bool("git", false, "Merge entries pushed to the Git remote from other devices")
+	command.Flags().Bool("notion", false, "Pull from Notion")
+	command.Flags().Bool("theirs", false, "Resolve conflicts by taking the Notion version")
+	return command
+}
+
+func writeNotionPullReport(output io.Writer, report til.NotionPullReport) {
+	sections := []struct {
+		label   string
+		entries []til.Entry
+	}{
+		{"Imported", report.Imported},
+		{"Updated", report.Updated},
+		{"Linked", report.Linked},
+	}
+	changed := false
+	for _, section := range sections {
+		if len(section.entries) == 0 {
+			continue
+		}
+		changed = true
+		fmt.Fprintf(
+			output,
+			"%s %d %s from Notion.\n",
+			section.label,
+			len(section.entries),
+			pluralizeEntry(len(section.entries)),
+		)
+		for _, entry := range section.entries {
+			fmt.Fprintf(output, "  %s %s\n", entry.CommitID, entry.Message)
+		}
+	}
+	for _, conflict := range report.Conflicts {
+		changed = true
+		fmt.Fprintf(
+			output,
+			"Conflict: %s %q changed both locally and in Notion since the last sync.\n",
+			conflict.Entry.CommitID,
+			conflict.Entry.Message,
+		)
+	}
+	if !changed {
+		fmt.Fprintln(output, "Local entries are up to date with Notion.")
+	}
+}
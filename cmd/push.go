@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"path/filepath"
-	"strings"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
 
 	"github.com/michaelfromorg/tiled/internal/til"
 	"github.com/spf13/cobra"
@@ -15,13 +17,19 @@ func newPushCommand() *cobra.Command {
 	command := &cobra.Command{
 		Use:   "push",
 		Short: "Sync committed entries",
-		Args:  cobra.NoArgs,
+		Long: "Push committed entries to every configured destination, or only to the destinations named with --to.\n\n" +
+			"Built-in destinations: git, notion, webhook.",
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			config, manager, err := loadManager()
 			if err != nil {
 				return err
 			}
 
+			names, err := cmd.Flags().GetStringSlice("to")
+			if err != nil {
+				return err
+			}
 			notionOnly, err := cmd.Flags().GetBool("notion")
 			if err != nil {
 				return err
@@ -34,143 +42,160 @@ func newPushCommand() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			if notionOnly && gitOnly {
-				return errors.New("--notion and --git cannot be used together")
+			feed, err := cmd.Flags().GetBool("feed")
+			if err != nil {
+				return err
+			}
+			commitPerEntry, err := cmd.Flags().GetBool("commit-per-entry")
+			if err != nil {
+				return err
 			}
-			if notionOnly && !config.SyncToNotion {
-				return errors.New("Notion sync is not configured")
+			timeout, err := cmd.Flags().GetDuration("timeout")
+			if err != nil {
+				return err
 			}
-			if gitOnly && !config.SyncToGit {
-				return errors.New("Git sync is not configured")
+			if timeout < 0 {
+				return errors.New("--timeout cannot be negative")
 			}
-			if force && gitOnly {
-				return errors.New("--force only applies to Notion sync")
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				return err
+			}
+			jobs, err := cmd.Flags().GetInt("jobs")
+			if err != nil {
+				return err
+			}
+			if jobs < 1 {
+				return errors.New("--jobs must be at least 1")
+			}
+			if gitOnly {
+				names = append(names, "git")
+			}
+			if notionOnly {
+				names = append(names, "notion")
 			}
 
-			pushToGit := config.SyncToGit && !notionOnly
-			pushToNotion := config.SyncToNotion && !gitOnly
-			if !pushToGit && !pushToNotion {
+			destinations, err := til.ConfiguredDestinations(config, names...)
+			if err != nil {
+				return err
+			}
+			pushesGit := slices.ContainsFunc(destinations, func(destination til.Destination) bool {
+				return destination.Name() == "git"
+			})
+			if feed && !pushesGit {
+				return errors.New("--feed requires Git synchronization")
+			}
+			if commitPerEntry && !pushesGit {
+				return errors.New("--commit-per-entry requires Git synchronization")
+			}
+			if len(destinations) == 0 {
 				fmt.Fprintln(cmd.OutOrStdout(), "No sync destinations are configured.")
 				return nil
 			}
 
-			branch := "main"
-			if pushToGit {
-				branch, err = pushGit(manager, config, cmd)
-				if err != nil {
-					return err
-				}
-			} else if config.SyncToGit {
-				gitManager := til.NewGitManager(filepath.Join(config.DataDir, "til"))
-				if gitManager.IsInitialized() {
-					if currentBranch, branchErr := gitManager.CurrentBranch(); branchErr == nil {
-						branch = currentBranch
-					}
-				}
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			options := til.PushOptions{
+				Force:          force,
+				Feed:           feed,
+				CommitPerEntry: commitPerEntry,
+				Jobs:           jobs,
+				Output:         cmd.OutOrStdout(),
 			}
+			if dryRun {
+				return previewPush(ctx, cmd.OutOrStdout(), manager, destinations, options)
+			}
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+			defer stop()
 
-			if pushToNotion {
-				if err := pushNotion(manager, config, branch, force, cmd); err != nil {
+			for _, destination := range destinations {
+				if err := pushDestination(ctx, manager, destination, options); err != nil {
 					return err
 				}
 			}
 			return nil
 		},
 	}
-	command.Flags().Bool("notion", false, "Push only to Notion")
-	command.Flags().Bool("git", false, "Push only to Git")
-	command.Flags().Bool("force", false, "Push entries to Notion even when marked as synced")
+	command.Flags().StringSlice("to", nil, "Push only to these destinations, e.g. --to git,notion")
+	command.Flags().Bool("notion", false, "Push only to Notion (same as --to notion)")
+	command.Flags().Bool("git", false, "Push only to Git (same as --to git)")
+	command.Flags().Bool("force", false, "Push entries even when unchanged since the last push")
+	command.Flags().Bool("feed", false, "Write an Atom feed to feed.xml next to README.md before the Git commit")
+	command.Flags().Bool("commit-per-entry", false, "Create one Git commit per changed entry, dated when it was written")
+	command.Flags().Bool("dry-run", false, "Show what would be committed and published without changing anything")
+	command.Flags().Int("jobs", til.DefaultNotionPushJobs, "Number of entries to push at the same time")
+	command.Flags().Duration("timeout", 0, "Stop pushing after this long, e.g. 5m (default no limit)")
 	return command
 }
 
-func pushGit(manager *til.Manager, config til.Config, cmd *cobra.Command) (string, error) {
-	gitManager := til.NewGitManager(filepath.Join(config.DataDir, "til"))
-	if !gitManager.IsInitialized() {
-		return "", errors.New("Git repository is not initialized; run 'til config edit' to configure it")
-	}
-	if err := manager.RefreshReadme(); err != nil {
-		return "", err
-	}
-	if err := gitManager.AddAll(); err != nil {
-		return "", fmt.Errorf("stage Git changes: %w", err)
-	}
-
-	hasChanges, err := gitManager.HasStagedChanges()
-	if err != nil {
-		return "", err
-	}
-	if hasChanges {
-		if err := gitManager.Commit("Update TIL entries"); err != nil {
-			return "", fmt.Errorf("commit Git changes: %w", err)
-		}
-		fmt.Fprintln(cmd.OutOrStdout(), "Committed TIL changes to Git.")
-	} else {
-		fmt.Fprintln(cmd.OutOrStdout(), "No new Git changes to commit.")
-	}
-
-	if err := gitManager.Push(); err != nil {
-		return "", err
-	}
-	branch, err := gitManager.CurrentBranch()
-	if err != nil {
-		return "", err
-	}
-	fmt.Fprintln(cmd.OutOrStdout(), "Successfully pushed changes to Git.")
-	return branch, nil
-}
-
-func pushNotion(
+func pushDestination(
+	ctx context.Context,
 	manager *til.Manager,
-	config til.Config,
-	branch string,
-	force bool,
-	cmd *cobra.Command,
+	destination til.Destination,
+	options til.PushOptions,
 ) error {
-	if config.NotionAPIKeyLoadError != nil {
-		return fmt.Errorf(
-			"Notion API key is unavailable: %w; run 'til config edit'",
-			config.NotionAPIKeyLoadError,
+	progress := newPushProgress(options.Output, destination.Name())
+	options.Progress = progress.update
+	report, err := destination.Push(ctx, manager, options)
+	progress.finish()
+
+	if ctx.Err() != nil && report.Remaining+report.Failed > 0 {
+		fmt.Fprintf(
+			options.Output,
+			"Push stopped with %d %s left; run 'til push' again to resume.\n",
+			report.Remaining+report.Failed,
+			pluralizeEntry(report.Remaining+report.Failed),
 		)
 	}
-	if strings.TrimSpace(config.NotionAPIKey) == "" {
-		return errors.New("Notion API key is empty; run 'til config edit'")
-	}
-
-	entries, err := manager.GetLatestEntries(0)
 	if err != nil {
-		return err
+		return fmt.Errorf("push to %s: %w", destination.Name(), err)
 	}
+	return nil
+}
 
-	options := []til.NotionClientOption{}
-	if config.SyncToGit {
-		options = append(options, til.WithGitAttachments(config.GitRemoteURL, branch))
-	}
-	client := til.NewNotionClient(config.NotionAPIKey, config.NotionDBID, options...)
-	ctx := context.Background()
-	pushed := 0
-	var pushErrors []error
+// pushProgress reports Notion push results. Terminals get a single line that
+// is rewritten in place; other outputs get one line per entry.
+type pushProgress struct {
+	output      io.Writer
+	destination string
+	terminal    bool
+	written     bool
+}
 
-	for _, entry := range entries {
-		if entry.NotionSynced && !force {
-			continue
-		}
+func newPushProgress(output io.Writer, destination string) *pushProgress {
+	file, ok := output.(*os.File)
+	return &pushProgress{
+		output:      output,
+		destination: destination,
+		terminal:    ok && isTerminal(int(file.Fd())),
+	}
+}
 
-		if err := client.PushEntry(ctx, entry, config.DataDir); err != nil {
-			pushErrors = append(pushErrors, fmt.Errorf("%q: %w", entry.Message, err))
-			continue
+func (progress *pushProgress) update(result til.PushProgress) {
+	if progress.terminal {
+		if result.Err != nil {
+			fmt.Fprintf(progress.output, "\r\033[KFailed %q: %v\n", result.Entry.Message, result.Err)
 		}
-		entry.NotionSynced = true
-		if err := manager.UpdateEntryNotionSyncStatus(entry); err != nil {
-			pushErrors = append(pushErrors, fmt.Errorf("%q: %w", entry.Message, err))
-			continue
-		}
-		pushed++
+		fmt.Fprintf(progress.output, "\r\033[KPushing to %s: %d/%d", progress.destination, result.Done, result.Total)
+		progress.written = true
+		return
+	}
+	if result.Err != nil {
+		fmt.Fprintf(progress.output, "[%d/%d] Failed %q: %v\n", result.Done, result.Total, result.Entry.Message, result.Err)
+		return
 	}
+	fmt.Fprintf(progress.output, "[%d/%d] Pushed %q\n", result.Done, result.Total, result.Entry.Message)
+}
 
-	entryLabel := "entries"
-	if pushed == 1 {
-		entryLabel = "entry"
+func (progress *pushProgress) finish() {
+	if progress.written {
+		fmt.Fprintln(progress.output)
 	}
-	fmt.Fprintf(cmd.OutOrStdout(), "Successfully pushed %d %s to Notion.\n", pushed, entryLabel)
-	return errors.Join(pushErrors...)
 }
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/michaelfromorg/tiled/internal/til"
+)
+
+// previewPush reports what push would do for each destination without
+// writing files, creating commits, or contacting remote services.
+func previewPush(
+	ctx context.Context,
+	output io.Writer,
+	manager *til.Manager,
+	destinations []til.Destination,
+	options til.PushOptions,
+) error {
+	fmt.Fprintln(output, "Dry run: nothing will be written, committed, or published.")
+	options.Output = output
+	for _, destination := range destinations {
+		previewer, ok := destination.(til.PushPreviewer)
+		if !ok {
+			fmt.Fprintf(output, "\n%s: no preview available.\n", destination.Name())
+			continue
+		}
+		if err := previewer.Preview(ctx, manager, options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/michaelfromorg/tiled/internal/til"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushProgressWritesOneLinePerEntryWithoutTerminal(t *testing.T) {
+	var output bytes.Buffer
+	progress := newPushProgress(&output, "notion")
+
+	progress.update(til.PushProgress{Entry: til.Entry{Message: "First"}, Done: 1, Total: 2})
+	progress.update(til.PushProgress{
+		Entry: til.Entry{Message: "Second"},
+		Err:   errors.New("rate limited"),
+		Done:  2,
+		Total: 2,
+	})
+	progress.finish()
+
+	assert.Equal(t, "[1/2] Pushed \"First\"\n[2/2] Failed \"Second\": rate limited\n", output.String())
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newResetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reset [files...]",
+		Short: "Unstage files",
+		Long: "Remove files from .til/staging so they are not attached to the next entry. With no arguments, every " +
+			"staged file is unstaged. Files may be given by their staged name or by the path they were added from.",
+		Example: "  til reset diagram.png\n" +
+			"  til reset",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			removed, err := manager.UnstageFiles(args)
+			if err != nil {
+				return err
+			}
+			if len(removed) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No files staged for commit.")
+				return nil
+			}
+			for _, fileName := range removed {
+				fmt.Fprintf(cmd.OutOrStdout(), "Unstaged file: %s\n", fileName)
+			}
+			return nil
+		},
+	}
+}
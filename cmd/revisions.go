@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/michaelfromorg/tiled/internal/til"
+	"github.com/spf13/cobra"
+)
+
+func runRevisionLog(command *cobra.Command, commitID string, long bool) error {
+	_, manager, err := loadManager()
+	if err != nil {
+		return err
+	}
+	entry, err := manager.GetEntry(commitID)
+	if err != nil {
+		return err
+	}
+	revisions, err := manager.EntryRevisions(entry.CommitID)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		fmt.Fprintf(command.OutOrStdout(), "Entry %s has no earlier revisions\n", entry.CommitID)
+		return nil
+	}
+	if long {
+		return writeRevisionsLong(command.OutOrStdout(), entry, revisions)
+	}
+	return writeRevisionsTable(command.OutOrStdout(), entry, revisions)
+}
+
+func writeRevisionsTable(output io.Writer, entry til.Entry, revisions []til.EntryRevision) error {
+	table := tabwriter.NewWriter(output, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(table, "REV\tREPLACED\tMESSAGE\tTAGS\tFILES"); err != nil {
+		return err
+	}
+	for _, revision := range revisions {
+		if _, err := fmt.Fprintf(
+			table,
+			"%d\t%s\t%s\t%s\t%s\n",
+			revision.Revision,
+			revision.RecordedAt.Local().Format("2006-01-02 15:04"),
+			singleLine(revision.Message),
+			formatTags(revision.Tags),
+			formatFiles(revision.Files),
+		); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(
+		table,
+		"current\t-\t%s\t%s\t%s\n",
+		singleLine(entry.Message),
+		formatTags(entry.Tags),
+		formatFiles(entry.Files),
+	); err != nil {
+		return err
+	}
+	return table.Flush()
+}
+
+func writeRevisionsLong(output io.Writer, entry til.Entry, revisions []til.EntryRevision) error {
+	for _, revision := range revisions {
+		if _, err := fmt.Fprintf(output, "revision %d of %s\n", revision.Revision, entry.CommitID); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(output, "Replaced: %s\n", revision.RecordedAt.Format(time.RFC3339)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(output, "Tags:     %s\n", formatTags(revision.Tags)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(output, "Files:    %s\n", formatFiles(revision.Files)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(output, "\n    %s\n", revision.Message); err != nil {
+			return err
+		}
+		if revision.MessageBody != "" {
+			for _, line := range strings.Split(revision.MessageBody, "\n") {
+				if _, err := fmt.Fprintf(output, "    %s\n", line); err != nil {
+					return err
+				}
+			}
+		}
+		if _, err := fmt.Fprintln(output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newDiffCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <commit-id> [revision]",
+		Short: "Show changes between an entry revision and its current state",
+		Long:  "Show a unified diff of the title, tags, body, and attachments between a revision and the current entry. Without a revision, compare against the most recent one.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			revision := 0
+			if len(args) == 2 {
+				var err error
+				revision, err = parseRevision(args[1])
+				if err != nil {
+					return err
+				}
+			}
+			_, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			diff, err := manager.DiffEntry(args[0], revision)
+			if err != nil {
+				return err
+			}
+			if diff == "" {
+				fmt.Fprintln(cmd.OutOrStdout(), "No differences")
+				return nil
+			}
+			_, err = io.WriteString(cmd.OutOrStdout(), diff)
+			return err
+		},
+	}
+}
+
+func newRevertCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revert <commit-id> <revision>",
+		Short: "Restore an entry to an earlier revision",
+		Long:  "Restore the title, body, attachments, and tags of an earlier revision. The replaced state is kept as a new revision, so a revert can itself be reverted.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			revision, err := parseRevision(args[1])
+			if err != nil {
+				return err
+			}
+			_, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			entry, err := manager.RevertEntry(args[0], revision)
+			if err != nil {
+				return fmt.Errorf("revert entry: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Entry %s reverted to revision %d\n", entry.CommitID, revision)
+			return nil
+		},
+	}
+}
+
+func parseRevision(value string) (int, error) {
+	revision, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(value), "@"))
+	if err != nil || revision <= 0 {
+		return 0, fmt.Errorf("revision must be a positive number (received %q)", value)
+	}
+	return revision, nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newRemoveCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "rm <commit-id>",
+		Short: "Delete an entry and its stored files",
+		Long:  "Delete an entry, its attachment records, and its body and attachment files under til/files. The commit ID may be abbreviated to any unique prefix of at least four characters.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			entry, err := manager.GetEntry(args[0])
+			if err != nil {
+				return err
+			}
+
+			confirmed, err := cmd.Flags().GetBool("yes")
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				confirmed, err = promptYesNo(
+					bufio.NewReader(cmd.InOrStdin()),
+					cmd.OutOrStdout(),
+					fmt.Sprintf("Delete entry %s %q? (y/n): ", entry.CommitID, entry.Message),
+				)
+				if err != nil {
+					return err
+				}
+			}
+			if !confirmed {
+				fmt.Fprintln(cmd.OutOrStdout(), "Removal aborted")
+				return nil
+			}
+
+			if _, err := manager.RemoveEntry(entry.CommitID); err != nil {
+				return fmt.Errorf("remove entry: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Entry %s removed\n", entry.CommitID)
+			return nil
+		},
+	}
+	command.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+	return command
+}
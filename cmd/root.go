@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"runtime/debug"
 
@@ -35,13 +36,25 @@ func NewRootCommand() *cobra.Command {
 		newCompletionCommand(),
 		newConfigCommand(),
 		newDatabaseCommand(),
+		newDiffCommand(),
+		newDoctorCommand(),
+		newEditCommand(),
 		newExportCommand(),
+		newFeedCommand(),
+		newGCCommand(),
 		newStatusCommand(),
 		newPushCommand(),
+		newPullCommand(),
 		newLogCommand(),
 		newSlogCommand(),
+		newResetCommand(),
 		newRestoreCommand(),
+		newRevertCommand(),
+		newRemoveCommand(),
+		newShowCommand(),
+		newSiteCommand(),
 		newMigrateCommand(),
+		newTagCommand(),
 		newVersionCommand(),
 	)
 	return root
@@ -78,9 +91,39 @@ func loadManager() (til.Config, *til.Manager, error) {
 	if err != nil {
 		return config, nil, err
 	}
+	manager, err := openManager(config)
+	return config, manager, err
+}
+
+// openManager opens the repository a loaded configuration points at,
+// upgrading its database schema if needed.
+func openManager(config til.Config) (*til.Manager, error) {
 	manager := til.NewManager(config)
 	if err := manager.EnsureInitialized(); err != nil {
-		return config, nil, err
+		return nil, err
+	}
+	report, err := manager.UpgradeSchema()
+	if err != nil {
+		return nil, fmt.Errorf("upgrade database schema: %w", err)
+	}
+	writeSchemaUpgradeReport(os.Stderr, report)
+	return manager, nil
+}
+
+func writeSchemaUpgradeReport(output io.Writer, report til.SchemaUpgradeReport) {
+	if !report.Upgraded() {
+		return
+	}
+	fmt.Fprintf(
+		output,
+		"Upgraded TIL database schema from version %d to %d\n",
+		report.FromVersion,
+		report.ToVersion,
+	)
+	for _, migration := range report.Applied {
+		fmt.Fprintf(output, "  %d: %s\n", migration.Version, migration.Description)
+	}
+	if report.BackupPath != "" {
+		fmt.Fprintf(output, "Previous database backed up to %s\n", report.BackupPath)
 	}
-	return config, manager, nil
 }
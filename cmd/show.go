@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/michaelfromorg/tiled/internal/til"
+	"github.com/spf13/cobra"
+)
+
+func newShowCommand() *cobra.Command {
+	var asJSON bool
+	command := &cobra.Command{
+		Use:   "show <commit-id>",
+		Short: "Show one entry with its body and attachments",
+		Long:  "Show one entry. The commit ID may be abbreviated to any unique prefix of at least four characters.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			entry, err := manager.GetEntry(args[0])
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				return writeEntriesJSON(cmd.OutOrStdout(), []til.Entry{entry})
+			}
+			return writeEntriesLong(cmd.OutOrStdout(), []til.Entry{entry}, nil)
+		},
+	}
+	command.Flags().BoolVar(&asJSON, "json", false, "Print the entry as JSON")
+	return command
+}
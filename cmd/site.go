@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/michaelfromorg/tiled/internal/til"
+	"github.com/spf13/cobra"
+)
+
+func newSiteCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "site",
+		Short: "Publish TIL entries as a static website",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+	command.AddCommand(newSiteBuildCommand())
+	return command
+}
+
+func newSiteBuildCommand() *cobra.Command {
+	var outputDir string
+	command := &cobra.Command{
+		Use:   "build",
+		Short: "Render entries as a static HTML site",
+		Long: "Render an index, a page per entry, monthly and tag archives, and a client-side search index. " +
+			"The site is written to the site directory next to til/ unless --output is given, replacing the previous build. " +
+			"Files in .til/templates replace the built-in templates with the same name: " +
+			strings.Join(til.SiteTemplateNames, ", ") + ".",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			_, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			report, err := manager.BuildSite(outputDir)
+			if err != nil {
+				return err
+			}
+
+			output := cmd.OutOrStdout()
+			for _, name := range report.Overrides {
+				fmt.Fprintf(output, "Using template %s from .til/templates.\n", name)
+			}
+			for _, name := range report.MissingFiles {
+				fmt.Fprintf(output, "Warning: attachment %s is missing from files/; its link will be broken.\n", name)
+			}
+			fmt.Fprintf(
+				output,
+				"Built %d pages for %d %s in %s\n",
+				report.Pages,
+				report.Entries,
+				pluralizeEntry(report.Entries),
+				report.OutputDir,
+			)
+			return nil
+		},
+	}
+	command.Flags().StringVarP(&outputDir, "output", "o", "", "Directory to write the site to (default: site next to til/)")
+	return command
+}
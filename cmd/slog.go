@@ -11,12 +11,18 @@ func newSlogCommand() *cobra.Command {
 	command := &cobra.Command{
 		Use:   "slog <query>",
 		Short: "Search TIL entries",
-		Long:  "Search entry IDs, titles, bodies, and attachment names using a case-insensitive literal query.",
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Search entry IDs, titles, bodies, and attachment names with a ranked full-text query.
+
+Words match whole terms in any order, "quoted phrases" match consecutive
+terms, and a trailing * matches a prefix (inter*). Combine terms with AND, OR
+and NOT, and group them with parentheses. Results are ordered by relevance
+unless --reverse is given. Use --literal for a case-insensitive substring search.`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runEntryQuery(cmd, strings.Join(args, " "), options)
 		},
 	}
 	addEntryQueryFlags(command, &options)
+	command.Flags().BoolVar(&options.literal, "literal", false, "Match the query as a case-insensitive substring instead of full-text syntax")
 	return command
 }
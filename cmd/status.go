@@ -45,6 +45,9 @@ func newStatusCommand() *cobra.Command {
 				} else {
 					fmt.Fprintf(output, "Body:    %s\n", preview(entry.MessageBody, 50))
 				}
+				if len(entry.Tags) > 0 {
+					fmt.Fprintf(output, "Tags:    %s\n", strings.Join(entry.Tags, ", "))
+				}
 				if len(entry.Files) == 0 {
 					fmt.Fprintln(output, "Files:   None")
 				} else {
@@ -64,13 +67,7 @@ func newStatusCommand() *cobra.Command {
 				return err
 			}
 			fmt.Fprintln(output, "\nStaged Files:")
-			if len(stagedFiles) == 0 {
-				fmt.Fprintln(output, "No files staged for commit.")
-			} else {
-				for _, fileName := range stagedFiles {
-					fmt.Fprintf(output, "- %s\n", fileName)
-				}
-			}
+			writeStagedFiles(output, stagedFiles)
 
 			if config.SyncToGit {
 				gitManager := til.NewGitManager(filepath.Join(config.DataDir, "til"))
@@ -92,16 +89,6 @@ func newStatusCommand() *cobra.Command {
 			}
 
 			if config.SyncToNotion {
-				allEntries, err := manager.GetLatestEntries(0)
-				if err != nil {
-					return err
-				}
-				synced := 0
-				for _, entry := range allEntries {
-					if entry.NotionSynced {
-						synced++
-					}
-				}
 				fmt.Fprintln(output, "\nNotion Sync:")
 				if config.NotionAPIKeyLoadError != nil {
 					fmt.Fprintln(output, "API Key: unavailable (run 'til config edit')")
@@ -111,7 +98,21 @@ func newStatusCommand() *cobra.Command {
 					fmt.Fprintf(output, "API Key: %s\n", maskString(config.NotionAPIKey))
 				}
 				fmt.Fprintf(output, "DB ID:   %s\n", maskString(config.NotionDBID))
-				fmt.Fprintf(output, "Synced:  %d/%d entries\n", synced, len(allEntries))
+			}
+
+			destinations, err := til.ConfiguredDestinations(config)
+			if err != nil {
+				return err
+			}
+			if len(destinations) > 0 {
+				fmt.Fprintln(output, "\nDestinations:")
+				for _, destination := range destinations {
+					status, err := destination.Status(manager)
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(output, "%-8s %d/%d entries synced\n", destination.Name()+":", status.Synced, status.Total)
+				}
 			}
 			return nil
 		},
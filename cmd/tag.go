@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func newTagCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "tag",
+		Short: "Manage entry tags",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+	command.AddCommand(
+		newTagAddCommand(),
+		newTagRemoveCommand(),
+		newTagListCommand(),
+	)
+	return command
+}
+
+func newTagAddCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <commit-id> <tag>...",
+		Short: "Add tags to an entry",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			tags, err := manager.AddEntryTags(args[0], args[1:])
+			if err != nil {
+				return fmt.Errorf("add tags: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Tags for %s: %s\n", args[0], formatTags(tags))
+			return nil
+		},
+	}
+}
+
+func newTagRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm <commit-id> <tag>...",
+		Aliases: []string{"remove"},
+		Short:   "Remove tags from an entry",
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			tags, err := manager.RemoveEntryTags(args[0], args[1:])
+			if err != nil {
+				return fmt.Errorf("remove tags: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Tags for %s: %s\n", args[0], formatTags(tags))
+			return nil
+		},
+	}
+}
+
+func newTagListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List tags and how many entries use them",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			_, manager, err := loadManager()
+			if err != nil {
+				return err
+			}
+			tags, err := manager.ListTags()
+			if err != nil {
+				return err
+			}
+			if len(tags) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No tags found")
+				return nil
+			}
+			table := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(table, "TAG\tENTRIES")
+			for _, tag := range tags {
+				fmt.Fprintf(table, "%s\t%d\n", tag.Name, tag.Entries)
+			}
+			return table.Flush()
+		},
+	}
+}
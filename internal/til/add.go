@@ -0,0 +1,281 @@
+package til
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// bundleExtensions maps the AddOptions.Bundle formats to the extension of
+// the archive they produce.
+var bundleExtensions = map[string]string{
+	"tar": ".tar.gz",
+	"zip": ".zip",
+}
+
+// AddPath stages a file, every regular file below a directory, or every
+// match of a glob pattern, and returns the names they were staged under.
+// Patterns are only expanded when no file has the literal name, so they
+// work the same in shells that do not expand them, such as cmd.exe. On an
+// error, the names staged before it are still returned.
+func (m *Manager) AddPath(filePath string, options AddOptions) ([]string, error) {
+	if !m.IsInitialized() {
+		return nil, ErrRepositoryNotInitialized
+	}
+	if _, ok := bundleExtensions[options.Bundle]; options.Bundle != "" && !ok {
+		return nil, fmt.Errorf("unknown bundle format %q; use tar or zip", options.Bundle)
+	}
+
+	paths := []string{filePath}
+	if _, err := os.Lstat(filePath); errors.Is(err, fs.ErrNotExist) && strings.ContainsAny(filePath, "*?[") {
+		matches, err := filepath.Glob(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("expand %s: %w", filePath, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", filePath)
+		}
+		paths = matches
+	}
+
+	staged := []string{}
+	for _, match := range paths {
+		info, err := os.Stat(match)
+		if err != nil {
+			return staged, fmt.Errorf("file not found: %w", err)
+		}
+		switch {
+		case info.IsDir() && options.Bundle != "":
+			name, err := m.stageBundle(match, options)
+			if err != nil {
+				return staged, err
+			}
+			staged = append(staged, name)
+		case info.IsDir():
+			names, err := m.stageDirectory(match, options)
+			staged = append(staged, names...)
+			if err != nil {
+				return staged, err
+			}
+		default:
+			name := stagedFileName(match)
+			if err := m.stageFile(match, name, options); err != nil {
+				return staged, err
+			}
+			staged = append(staged, name)
+		}
+	}
+	return staged, nil
+}
+
+// AddReader stages the content of reader, such as standard input, under
+// name, which may include directories.
+func (m *Manager) AddReader(reader io.Reader, name string, options AddOptions) (string, error) {
+	if !m.IsInitialized() {
+		return "", ErrRepositoryNotInitialized
+	}
+	name = filepath.ToSlash(strings.TrimSpace(name))
+	if err := validateStagedName(name); err != nil {
+		return "", err
+	}
+
+	temporary, err := os.CreateTemp("", "til-add-*")
+	if err != nil {
+		return "", fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(temporary.Name())
+	size, err := io.Copy(temporary, io.LimitReader(reader, MaxFileSize+1))
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", name, err)
+	}
+	if size > MaxFileSize {
+		return "", fmt.Errorf("file too large: %s (more than %d bytes)", name, MaxFileSize)
+	}
+	if err := os.Chmod(temporary.Name(), 0644); err != nil {
+		return "", fmt.Errorf("read %s: %w", name, err)
+	}
+	if err := m.stageFile(temporary.Name(), name, options); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// stageDirectory stages every regular file below directory under its
+// directory's staged name, keeping the paths inside it.
+func (m *Manager) stageDirectory(directory string, options AddOptions) ([]string, error) {
+	files, err := directoryFiles(directory, options.Hidden)
+	if err != nil {
+		return nil, err
+	}
+	prefix := directoryStagedName(directory)
+	staged := make([]string, 0, len(files))
+	for _, relative := range files {
+		name := path.Join(prefix, relative)
+		if err := m.stageFile(filepath.Join(directory, filepath.FromSlash(relative)), name, options); err != nil {
+			return staged, err
+		}
+		staged = append(staged, name)
+	}
+	return staged, nil
+}
+
+// stageBundle stages directory as one archive named after it.
+func (m *Manager) stageBundle(directory string, options AddOptions) (string, error) {
+	files, err := directoryFiles(directory, options.Hidden)
+	if err != nil {
+		return "", err
+	}
+	name := directoryStagedName(directory) + bundleExtensions[options.Bundle]
+
+	temporary, err := os.CreateTemp("", "til-bundle-*")
+	if err != nil {
+		return "", fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(temporary.Name())
+	if options.Bundle == "zip" {
+		err = writeZipBundle(temporary, directory, files)
+	} else {
+		err = writeTarBundle(temporary, directory, files)
+	}
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("bundle %s: %w", directory, err)
+	}
+	if err := os.Chmod(temporary.Name(), 0644); err != nil {
+		return "", fmt.Errorf("bundle %s: %w", directory, err)
+	}
+	if info, err := os.Stat(temporary.Name()); err == nil && info.Size() > MaxFileSize {
+		return "", fmt.Errorf(
+			"file too large: %s (%d bytes, maximum is %d bytes)",
+			name,
+			info.Size(),
+			MaxFileSize,
+		)
+	}
+	if err := m.stageFile(temporary.Name(), name, options); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// directoryStagedName is the name a directory's files are staged below,
+// following stagedFileName.
+func directoryStagedName(directory string) string {
+	name := stagedFileName(directory)
+	if name == "." {
+		if absolute, err := filepath.Abs(directory); err == nil {
+			name = filepath.Base(absolute)
+		}
+	}
+	return name
+}
+
+// directoryFiles lists the regular files below directory, relative to it
+// with forward slashes. Symbolic links and other special files are skipped,
+// and so are hidden directories such as .git and .til unless hidden is set.
+func directoryFiles(directory string, hidden bool) ([]string, error) {
+	files := []string{}
+	err := filepath.WalkDir(directory, func(filePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.IsDir() && !hidden && filePath != directory && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		relative, err := filepath.Rel(directory, filePath)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(relative))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read directory %s: %w", directory, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files to add in %s", directory)
+	}
+	return files, nil
+}
+
+func writeTarBundle(output io.Writer, directory string, files []string) error {
+	compressed := gzip.NewWriter(output)
+	archive := tar.NewWriter(compressed)
+	root := path.Base(directoryStagedName(directory))
+	for _, relative := range files {
+		err := addBundleFile(directory, relative, func(info fs.FileInfo) (io.Writer, error) {
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return nil, err
+			}
+			header.Name = path.Join(root, relative)
+			header.Uname, header.Gname = "", ""
+			return archive, archive.WriteHeader(header)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
+
+func writeZipBundle(output io.Writer, directory string, files []string) error {
+	archive := zip.NewWriter(output)
+	root := path.Base(directoryStagedName(directory))
+	for _, relative := range files {
+		err := addBundleFile(directory, relative, func(info fs.FileInfo) (io.Writer, error) {
+			header, err := zip.FileInfoHeader(info)
+			if err != nil {
+				return nil, err
+			}
+			header.Name = path.Join(root, relative)
+			header.Method = zip.Deflate
+			return archive.CreateHeader(header)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// addBundleFile copies one file into an archive, through the writer that
+// create returns after writing the file's header.
+func addBundleFile(directory, relative string, create func(fs.FileInfo) (io.Writer, error)) error {
+	file, err := os.Open(filepath.Join(directory, filepath.FromSlash(relative)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	writer, err := create(info)
+	if err != nil {
+		return fmt.Errorf("add %s: %w", relative, err)
+	}
+	if _, err := io.Copy(writer, file); err != nil {
+		return fmt.Errorf("add %s: %w", relative, err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package til
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddPathKeepsRelativePathsAndExpandsDirectoriesAndGlobs(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	for name, content := range map[string]string{
+		"a/main.go":          "package a",
+		"b/main.go":          "package b",
+		"b/util.go":          "package b // util",
+		"docs/guide.md":      "# Guide",
+		"docs/img/arrow.png": "png",
+		"notes.txt":          "notes",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	workingDirectory, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(root))
+	t.Cleanup(func() { _ = os.Chdir(workingDirectory) })
+
+	staged, err := manager.AddPath(filepath.Join("a", "main.go"), AddOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/main.go"}, staged)
+	staged, err = manager.AddPath(filepath.Join("b", "*.go"), AddOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b/main.go", "b/util.go"}, staged)
+	staged, err = manager.AddPath("docs", AddOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs/guide.md", "docs/img/arrow.png"}, staged)
+	staged, err = manager.AddPath(filepath.Join(root, "notes.txt"), AddOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes.txt"}, staged, "paths outside the working directory use their base name")
+	_, err = manager.AddPath("*.missing", AddOptions{})
+	assert.ErrorContains(t, err, "no files match *.missing")
+
+	name, err := manager.AddReader(strings.NewReader("from stdin"), "snippets/stdin.txt", AddOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "snippets/stdin.txt", name)
+	_, err = manager.AddReader(strings.NewReader("escape"), "../escape.txt", AddOptions{})
+	assert.ErrorContains(t, err, "invalid file name")
+	_, err = manager.AddReader(strings.NewReader(strings.Repeat("x", int(MaxFileSize)+1)), "large.bin", AddOptions{})
+	assert.ErrorContains(t, err, "file too large")
+
+	files, err := manager.GetStagedFiles()
+	require.NoError(t, err)
+	expected := []string{
+		"a/main.go",
+		"b/main.go",
+		"b/util.go",
+		"docs/guide.md",
+		"docs/img/arrow.png",
+		"notes.txt",
+		"snippets/stdin.txt",
+	}
+	assert.Equal(t, expected, files)
+
+	removed, err := manager.UnstageFiles([]string{"docs"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs/guide.md", "docs/img/arrow.png"}, removed)
+	assert.NoDirExists(t, filepath.Join(manager.stagingDir(), "docs"))
+
+	require.NoError(t, manager.CommitEntry("Two packages"))
+	entries, err := manager.GetLatestEntries(1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/main.go", "b/main.go", "b/util.go", "notes.txt", "snippets/stdin.txt"}, entries[0].Files)
+	for name, content := range map[string]string{"a/main.go": "package a", "b/main.go": "package b", "snippets/stdin.txt": "from stdin"} {
+		stored, err := os.ReadFile(storedAttachmentPath(manager, entries[0], name))
+		require.NoError(t, err)
+		assert.Equal(t, content, string(stored), name)
+	}
+	files, err = manager.GetStagedFiles()
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestAddPathSkipsHiddenDirectories(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	project := filepath.Join(root, "project")
+	for _, name := range []string{"main.go", ".env", ".git/HEAD", ".til/config", "docs/.cache/index", "docs/guide.md"} {
+		path := filepath.Join(project, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(name), 0644))
+	}
+
+	staged, err := manager.AddPath(project, AddOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"project/.env", "project/docs/guide.md", "project/main.go"}, staged)
+	staged, err = manager.AddPath(filepath.Join(project, ".git"), AddOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".git/HEAD"}, staged, "a hidden directory added by name is not skipped")
+
+	staged, err = manager.AddPath(filepath.Join(project, "docs"), AddOptions{Hidden: true, Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs/.cache/index", "docs/guide.md"}, staged)
+}
+
+func TestAddPathBundlesDirectories(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	directory := filepath.Join(root, "screenshots")
+	require.NoError(t, os.MkdirAll(filepath.Join(directory, "old"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "new.png"), []byte("new"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "old", "first.png"), []byte("first"), 0644))
+
+	_, err := manager.AddPath(directory, AddOptions{Bundle: "rar"})
+	assert.ErrorContains(t, err, `unknown bundle format "rar"`)
+
+	staged, err := manager.AddPath(directory, AddOptions{Bundle: "tar"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"screenshots.tar.gz"}, staged)
+	archive, err := os.Open(filepath.Join(manager.stagingDir(), "screenshots.tar.gz"))
+	require.NoError(t, err)
+	defer archive.Close()
+	compressed, err := gzip.NewReader(archive)
+	require.NoError(t, err)
+	reader := tar.NewReader(compressed)
+	contents := map[string]string{}
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		contents[header.Name] = string(content)
+	}
+	assert.Equal(t, map[string]string{"screenshots/new.png": "new", "screenshots/old/first.png": "first"}, contents)
+
+	staged, err = manager.AddPath(directory, AddOptions{Bundle: "zip"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"screenshots.zip"}, staged)
+	zipped, err := zip.OpenReader(filepath.Join(manager.stagingDir(), "screenshots.zip"))
+	require.NoError(t, err)
+	defer zipped.Close()
+	names := []string{}
+	for _, file := range zipped.File {
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, []string{"screenshots/new.png", "screenshots/old/first.png"}, names)
+}
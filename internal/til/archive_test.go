@@ -26,7 +26,7 @@ func TestPortableArchiveRoundTrip(t *testing.T) {
 	attachmentSource := filepath.Join(root, "example.txt")
 	require.NoError(t, os.WriteFile(attachmentSource, []byte("attachment contents"), 0640))
 	require.NoError(t, manager.AddFile(attachmentSource))
-	require.NoError(t, manager.CommitEntryWithBody("Portable entry", "Markdown body"))
+	require.NoError(t, manager.CommitEntryWithTags("Portable entry", "Markdown body", []string{"archive"}))
 
 	archivePath := filepath.Join(t.TempDir(), "portable archive.tar.gz")
 	createdPath, err := manager.CreateArchive(archivePath)
@@ -61,14 +61,9 @@ func TestPortableArchiveRoundTrip(t *testing.T) {
 	entry := entries[0]
 	assert.Equal(t, "Portable entry", entry.Message)
 	assert.Equal(t, "Markdown body", entry.MessageBody)
+	assert.Equal(t, []string{"archive"}, entry.Tags)
 	require.Len(t, entry.Files, 1)
-	attachmentPath := filepath.Join(
-		restoreRoot,
-		"til",
-		"files",
-		entry.CommitID+"_example.txt",
-	)
-	attachment, err := os.ReadFile(attachmentPath)
+	attachment, err := os.ReadFile(storedAttachmentPath(restoreManager, entry, "example.txt"))
 	require.NoError(t, err)
 	assert.Equal(t, "attachment contents", string(attachment))
 	assert.FileExists(t, filepath.Join(restoreRoot, "til", "README.md"))
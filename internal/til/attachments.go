@@ -0,0 +1,427 @@
+package til
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// attachmentObjectsDirectoryName is the directory under files/ holding
+// attachment content, stored once per SHA-256 digest.
+const attachmentObjectsDirectoryName = "objects"
+
+// Attachment describes the stored content of a file attached to an entry.
+// SHA256 is empty for files stored under their per-entry name before
+// attachments were content-addressed.
+type Attachment struct {
+	Name      string `json:"name"`
+	SHA256    string `json:"sha256"`
+	Size      int64  `json:"size"`
+	MediaType string `json:"media_type"`
+}
+
+// attachment returns the recorded content of one of the entry's files.
+func (entry Entry) attachment(fileName string) (Attachment, bool) {
+	for _, attachment := range entry.Attachments {
+		if attachment.Name == fileName {
+			return attachment, true
+		}
+	}
+	return Attachment{}, false
+}
+
+// withAttachment returns a copy of attachments with the one of the same
+// name replaced, or the attachment added.
+func withAttachment(attachments []Attachment, attachment Attachment) []Attachment {
+	updated := make([]Attachment, 0, len(attachments)+1)
+	replaced := false
+	for _, existing := range attachments {
+		if existing.Name == attachment.Name {
+			existing = attachment
+			replaced = true
+		}
+		updated = append(updated, existing)
+	}
+	if !replaced {
+		updated = append(updated, attachment)
+	}
+	return updated
+}
+
+func attachmentNames(attachments []Attachment) []string {
+	names := make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		names = append(names, attachment.Name)
+	}
+	return names
+}
+
+// attachmentDigests lists the content digest of each of an entry's files,
+// empty where none is recorded.
+func attachmentDigests(entry Entry) []string {
+	digests := make([]string, 0, len(entry.Files))
+	for _, fileName := range entry.Files {
+		attachment, _ := entry.attachment(fileName)
+		digests = append(digests, attachment.SHA256)
+	}
+	return digests
+}
+
+// attachmentObjectName is where content with a digest is stored, relative
+// to the files directory. The file's extension is kept so that links from
+// the README and site open with the right type.
+func attachmentObjectName(digest, fileName string) string {
+	return path.Join(attachmentObjectsDirectoryName, digest[:2], digest+attachmentObjectExtension(fileName))
+}
+
+func attachmentObjectExtension(fileName string) string {
+	extension := strings.ToLower(filepath.Ext(filepath.Base(fileName)))
+	if len(extension) < 2 || len(extension) > 16 {
+		return ""
+	}
+	for _, character := range extension[1:] {
+		if (character < 'a' || character > 'z') && (character < '0' || character > '9') {
+			return ""
+		}
+	}
+	return extension
+}
+
+// storeAttachmentObject copies a file into the object store and describes
+// it. The returned path is set only when the object did not exist yet, so
+// callers undoing a failed commit never remove content shared with other
+// entries.
+func (m *Manager) storeAttachmentObject(sourcePath, fileName string) (Attachment, string, error) {
+	input, err := os.Open(sourcePath)
+	if err != nil {
+		return Attachment{}, "", err
+	}
+	defer input.Close()
+
+	objectsDir := filepath.Join(m.filesDir(), attachmentObjectsDirectoryName)
+	if err := os.MkdirAll(objectsDir, 0755); err != nil {
+		return Attachment{}, "", fmt.Errorf("create attachment objects directory: %w", err)
+	}
+	temporary, err := os.CreateTemp(objectsDir, ".incoming-*")
+	if err != nil {
+		return Attachment{}, "", fmt.Errorf("create attachment object: %w", err)
+	}
+	temporaryPath := temporary.Name()
+	defer os.Remove(temporaryPath)
+
+	head := make([]byte, 512)
+	headSize, err := io.ReadFull(input, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		temporary.Close()
+		return Attachment{}, "", err
+	}
+	hash := sha256.New()
+	output := io.MultiWriter(temporary, hash)
+	if _, err := output.Write(head[:headSize]); err != nil {
+		temporary.Close()
+		return Attachment{}, "", fmt.Errorf("write attachment object: %w", err)
+	}
+	rest, err := io.Copy(output, input)
+	if err != nil {
+		temporary.Close()
+		return Attachment{}, "", fmt.Errorf("write attachment object: %w", err)
+	}
+	if err := temporary.Close(); err != nil {
+		return Attachment{}, "", fmt.Errorf("write attachment object: %w", err)
+	}
+
+	attachment := Attachment{
+		Name:      filepath.Base(fileName),
+		SHA256:    hex.EncodeToString(hash.Sum(nil)),
+		Size:      int64(headSize) + rest,
+		MediaType: mime.TypeByExtension(filepath.Ext(fileName)),
+	}
+	if attachment.MediaType == "" {
+		attachment.MediaType = http.DetectContentType(head[:headSize])
+	}
+
+	targetPath := filepath.Join(m.filesDir(), attachmentObjectName(attachment.SHA256, fileName))
+	if _, err := os.Stat(targetPath); err == nil {
+		return attachment, "", nil
+	}
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return Attachment{}, "", fmt.Errorf("create attachment objects directory: %w", err)
+	}
+	if err := os.Chmod(temporaryPath, 0644); err != nil {
+		return Attachment{}, "", fmt.Errorf("set attachment object permissions: %w", err)
+	}
+	if err := os.Rename(temporaryPath, targetPath); err != nil {
+		return Attachment{}, "", fmt.Errorf("store attachment object: %w", err)
+	}
+	return attachment, targetPath, nil
+}
+
+// unreferencedAttachmentObjects returns the stored names of objects among
+// attachments that no entry or revision records any more.
+func (m *Manager) unreferencedAttachmentObjects(attachments []Attachment) ([]string, error) {
+	db, err := m.openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	names := []string{}
+	seen := map[string]bool{}
+	for _, attachment := range attachments {
+		name := attachmentObjectName(attachment.SHA256, attachment.Name)
+		if attachment.SHA256 == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		referenced, err := attachmentObjectReferenced(db, attachment.SHA256, name)
+		if err != nil {
+			return nil, err
+		}
+		if !referenced {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// attachmentObjectReferenced reports whether an entry or revision records
+// the object stored under name. The same content attached with another
+// extension is stored under a different name, so references are compared
+// by name rather than by digest.
+func attachmentObjectReferenced(db *sql.DB, digest, name string) (bool, error) {
+	rows, err := db.Query("SELECT file_name FROM attachments WHERE sha256 = ?", digest)
+	if err != nil {
+		return false, fmt.Errorf("check attachment object references: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fileName string
+		if err := rows.Scan(&fileName); err != nil {
+			return false, fmt.Errorf("scan attachment object reference: %w", err)
+		}
+		if attachmentObjectName(digest, fileName) == name {
+			return true, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("check attachment object references: %w", err)
+	}
+
+	revisions, err := db.Query(
+		"SELECT attachment_objects FROM entry_revisions WHERE attachment_objects LIKE ?",
+		`%"`+digest+`"%`,
+	)
+	if err != nil {
+		return false, fmt.Errorf("check revision attachment object references: %w", err)
+	}
+	defer revisions.Close()
+	for revisions.Next() {
+		var encoded string
+		if err := revisions.Scan(&encoded); err != nil {
+			return false, fmt.Errorf("scan revision attachment objects: %w", err)
+		}
+		var attachments []Attachment
+		if err := json.Unmarshal([]byte(encoded), &attachments); err != nil {
+			return false, fmt.Errorf("decode revision attachment objects: %w", err)
+		}
+		for _, attachment := range attachments {
+			if attachment.SHA256 == digest && attachmentObjectName(digest, attachment.Name) == name {
+				return true, nil
+			}
+		}
+	}
+	if err := revisions.Err(); err != nil {
+		return false, fmt.Errorf("check revision attachment object references: %w", err)
+	}
+	return false, nil
+}
+
+// migrateAttachmentObjects moves files stored under their per-entry names
+// into the object store and records their digests for entries and
+// revisions. Files that are missing keep their per-entry names, and
+// running it again only picks up what is left.
+func (m *Manager) migrateAttachmentObjects(db *sql.DB) error {
+	owners := map[int64]Entry{}
+	rows, err := db.Query("SELECT id, commit_id, created_at FROM entries")
+	if err != nil {
+		return fmt.Errorf("query entries for attachment migration: %w", err)
+	}
+	for rows.Next() {
+		var (
+			id        int64
+			entry     Entry
+			createdAt string
+		)
+		if err := rows.Scan(&id, &entry.CommitID, &createdAt); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan entry for attachment migration: %w", err)
+		}
+		entry.Date, _ = time.Parse(time.RFC3339Nano, createdAt)
+		owners[id] = entry
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("close entry rows: %w", err)
+	}
+
+	stored := map[string]Attachment{}
+	migrated := []string{}
+	storeLegacyFile := func(entryID int64, fileName string) (Attachment, bool, error) {
+		legacyPath := filepath.Join(m.filesDir(), storedAttachmentName(owners[entryID], fileName))
+		attachment, ok := stored[legacyPath]
+		if !ok {
+			var err error
+			attachment, _, err = m.storeAttachmentObject(legacyPath, fileName)
+			if errors.Is(err, os.ErrNotExist) {
+				return Attachment{}, false, nil
+			}
+			if err != nil {
+				return Attachment{}, false, fmt.Errorf("store attachment %s: %w", fileName, err)
+			}
+			stored[legacyPath] = attachment
+			migrated = append(migrated, legacyPath)
+		}
+		attachment.Name = fileName
+		return attachment, true, nil
+	}
+
+	transaction, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin attachment migration transaction: %w", err)
+	}
+	defer transaction.Rollback()
+
+	type pendingAttachment struct {
+		entryID  int64
+		fileName string
+	}
+	pending := []pendingAttachment{}
+	rows, err = transaction.Query("SELECT entry_id, file_name FROM attachments WHERE sha256 = ''")
+	if err != nil {
+		return fmt.Errorf("query attachments for migration: %w", err)
+	}
+	for rows.Next() {
+		var item pendingAttachment
+		if err := rows.Scan(&item.entryID, &item.fileName); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan attachment for migration: %w", err)
+		}
+		pending = append(pending, item)
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("close attachment rows: %w", err)
+	}
+	for _, item := range pending {
+		attachment, found, err := storeLegacyFile(item.entryID, item.fileName)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		if _, err := transaction.Exec(
+			"UPDATE attachments SET sha256 = ?, size = ?, media_type = ? WHERE entry_id = ? AND file_name = ?",
+			attachment.SHA256,
+			attachment.Size,
+			attachment.MediaType,
+			item.entryID,
+			item.fileName,
+		); err != nil {
+			return fmt.Errorf("record attachment %s: %w", item.fileName, err)
+		}
+	}
+
+	type pendingRevision struct {
+		id          int64
+		entryID     int64
+		files       []string
+		attachments []Attachment
+	}
+	revisions := []pendingRevision{}
+	rows, err = transaction.Query("SELECT id, entry_id, attachments, attachment_objects FROM entry_revisions")
+	if err != nil {
+		return fmt.Errorf("query revisions for attachment migration: %w", err)
+	}
+	for rows.Next() {
+		var (
+			revision    pendingRevision
+			files       string
+			attachments string
+		)
+		if err := rows.Scan(&revision.id, &revision.entryID, &files, &attachments); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan revision for attachment migration: %w", err)
+		}
+		if err := json.Unmarshal([]byte(files), &revision.files); err != nil {
+			rows.Close()
+			return fmt.Errorf("decode revision attachments: %w", err)
+		}
+		if err := json.Unmarshal([]byte(attachments), &revision.attachments); err != nil {
+			rows.Close()
+			return fmt.Errorf("decode revision attachment objects: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("close revision rows: %w", err)
+	}
+	for _, revision := range revisions {
+		entry := Entry{Attachments: revision.attachments}
+		changed := false
+		for _, fileName := range revision.files {
+			if attachment, ok := entry.attachment(fileName); ok && attachment.SHA256 != "" {
+				continue
+			}
+			attachment, found, err := storeLegacyFile(revision.entryID, fileName)
+			if err != nil {
+				return err
+			}
+			if found {
+				entry.Attachments = withAttachment(entry.Attachments, attachment)
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		encoded, err := json.Marshal(entry.Attachments)
+		if err != nil {
+			return fmt.Errorf("encode revision attachment objects: %w", err)
+		}
+		if _, err := transaction.Exec(
+			"UPDATE entry_revisions SET attachment_objects = ? WHERE id = ?",
+			string(encoded),
+			revision.id,
+		); err != nil {
+			return fmt.Errorf("record revision attachments: %w", err)
+		}
+	}
+
+	// Digests recorded now change the content hash of their entries.
+	if err := upgradeContentHashes(transaction); err != nil {
+		return err
+	}
+	if err := transaction.Commit(); err != nil {
+		return fmt.Errorf("commit attachment migration: %w", err)
+	}
+	var removeErrors []error
+	for _, legacyPath := range migrated {
+		if err := os.Remove(legacyPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			removeErrors = append(removeErrors, err)
+		}
+	}
+	if len(removeErrors) > 0 {
+		return fmt.Errorf("attachments migrated, but old copies remain: %w", errors.Join(removeErrors...))
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package til
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentsAreStoredOnceByContent(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	source := filepath.Join(root, "diagram.txt")
+	require.NoError(t, os.WriteFile(source, []byte("boxes and arrows"), 0644))
+	for _, message := range []string{"First use", "Second use"} {
+		require.NoError(t, manager.AddFile(source))
+		require.NoError(t, manager.CommitEntry(message))
+	}
+
+	entries, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	digest := sha256.Sum256([]byte("boxes and arrows"))
+	expected := Attachment{
+		Name:      "diagram.txt",
+		SHA256:    hex.EncodeToString(digest[:]),
+		Size:      int64(len("boxes and arrows")),
+		MediaType: "text/plain; charset=utf-8",
+	}
+	assert.Equal(t, []Attachment{expected}, entries[0].Attachments)
+	assert.Equal(t, []Attachment{expected}, entries[1].Attachments)
+	objectPath := storedAttachmentPath(manager, entries[0], "diagram.txt")
+	assert.Equal(t, filepath.Join(manager.filesDir(), "objects", expected.SHA256[:2], expected.SHA256+".txt"), objectPath)
+	objects, err := os.ReadDir(filepath.Dir(objectPath))
+	require.NoError(t, err)
+	assert.Len(t, objects, 1, "the same content is stored once")
+
+	_, err = manager.RemoveEntry(entries[0].CommitID)
+	require.NoError(t, err)
+	assert.FileExists(t, objectPath, "content still attached to another entry is kept")
+	_, err = manager.RemoveEntry(entries[1].CommitID)
+	require.NoError(t, err)
+	assert.NoFileExists(t, objectPath)
+}
+
+func TestRemovingEntryDeletesObjectStoredUnderOtherExtension(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	for _, name := range []string{"notes.txt", "notes.md"} {
+		source := filepath.Join(root, name)
+		require.NoError(t, os.WriteFile(source, []byte("same content"), 0644))
+		require.NoError(t, manager.AddFile(source))
+		require.NoError(t, manager.CommitEntry("Attach "+name))
+	}
+
+	entries, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	markdown := storedAttachmentPath(manager, entries[0], "notes.md")
+	text := storedAttachmentPath(manager, entries[1], "notes.txt")
+	require.NotEqual(t, markdown, text)
+	require.FileExists(t, markdown)
+	require.FileExists(t, text)
+
+	_, err = manager.RemoveEntry(entries[1].CommitID)
+	require.NoError(t, err)
+	assert.NoFileExists(t, text, "the .txt object is unreferenced even though the .md object has the same digest")
+	assert.FileExists(t, markdown)
+}
+
+func TestSchemaUpgradeMovesAttachmentsIntoObjectStore(t *testing.T) {
+	manager, _ := newTestManager(t, Config{SyncToGit: true})
+	require.NoError(t, manager.insertEntry(Entry{
+		Date:        time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC),
+		Message:     "Legacy attachments",
+		Files:       []string{"notes.txt", "missing.png"},
+		IsCommitted: true,
+		CommitID:    "legacy01",
+	}))
+	legacyPath := filepath.Join(manager.filesDir(), "legacy01_notes.txt")
+	require.NoError(t, os.WriteFile(legacyPath, []byte("old notes"), 0644))
+	require.NoError(t, manager.EditEntry("legacy01", "Legacy attachments, edited", ""))
+	legacy, err := manager.GetEntry("legacy01")
+	require.NoError(t, err)
+	require.NoError(t, manager.RecordDestinationSync(notionDestinationName, map[string]DestinationSync{
+		"legacy01": {Synced: true, RemoteID: "page-1", ContentHash: EntryContentHash(legacy)},
+	}))
+
+	database, err := sql.Open("sqlite", manager.DatabasePath())
+	require.NoError(t, err)
+	_, err = database.Exec(`
+DROP INDEX attachments_sha256_idx;
+ALTER TABLE attachments DROP COLUMN media_type;
+ALTER TABLE attachments DROP COLUMN size;
+ALTER TABLE attachments DROP COLUMN sha256;
+ALTER TABLE entry_revisions DROP COLUMN attachment_objects;
+DROP TABLE repository_metadata;
+PRAGMA user_version = 7;
+`)
+	require.NoError(t, err)
+	require.NoError(t, database.Close())
+
+	report, err := manager.UpgradeSchema()
+	require.NoError(t, err)
+	require.Len(t, report.Applied, 3)
+	assert.Equal(t, 8, report.Applied[0].Version)
+
+	entry, err := manager.GetEntry("legacy01")
+	require.NoError(t, err)
+	assert.NotEqual(t, EntryContentHash(legacy), EntryContentHash(entry), "the hash covers attachment content")
+	assert.Equal(t, EntryContentHash(entry), entry.NotionContentHash, "recorded hashes are upgraded")
+	assert.False(t, entry.NeedsNotionPush())
+	digest := sha256.Sum256([]byte("old notes"))
+	require.Len(t, entry.Attachments, 2)
+	assert.Equal(t, hex.EncodeToString(digest[:]), entry.Attachments[0].SHA256)
+	assert.Equal(t, int64(len("old notes")), entry.Attachments[0].Size)
+	assert.Empty(t, entry.Attachments[1].SHA256, "missing files keep their old name")
+	content, err := os.ReadFile(storedAttachmentPath(manager, entry, "notes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "old notes", string(content))
+	assert.NoFileExists(t, legacyPath)
+
+	revisions, err := manager.EntryRevisions("legacy01")
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, entry.Attachments[:1], revisions[0].Attachments)
+
+	require.NoError(t, manager.RefreshReadme())
+	readme, err := os.ReadFile(filepath.Join(manager.repositoryDir(), readmeFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(readme), "[notes.txt](files/objects/"+entry.Attachments[0].SHA256[:2]+"/"+entry.Attachments[0].SHA256+".txt)")
+}
+
+func TestSchemaUpgradeAddsAttachmentContentToRecordedHashes(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	source := filepath.Join(root, "notes.txt")
+	require.NoError(t, os.WriteFile(source, []byte("notes"), 0644))
+	require.NoError(t, manager.AddFile(source))
+	require.NoError(t, manager.CommitEntry("Hashed entry"))
+	entries, err := manager.GetLatestEntries(1)
+	require.NoError(t, err)
+	require.NoError(t, manager.RecordDestinationSync(notionDestinationName, map[string]DestinationSync{
+		entries[0].CommitID: {Synced: true, RemoteID: "page-1", ContentHash: legacyEntryContentHash(entries[0])},
+	}))
+
+	database, err := sql.Open("sqlite", manager.DatabasePath())
+	require.NoError(t, err)
+	_, err = database.Exec("DROP TABLE repository_metadata; PRAGMA user_version = 8")
+	require.NoError(t, err)
+	require.NoError(t, database.Close())
+
+	_, err = manager.UpgradeSchema()
+	require.NoError(t, err)
+	entry, err := manager.GetEntry(entries[0].CommitID)
+	require.NoError(t, err)
+	assert.Equal(t, EntryContentHash(entry), entry.NotionContentHash)
+	assert.False(t, entry.NeedsNotionPush(), "entries pushed before the upgrade are not pushed again")
+}
+
+func TestInterruptedAttachmentMoveIsFinishedOnNextOpen(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	require.NoError(t, manager.insertEntry(Entry{
+		Date:        time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC),
+		Message:     "Legacy attachment",
+		Files:       []string{"notes.txt"},
+		IsCommitted: true,
+		CommitID:    "legacy02",
+	}))
+	legacyPath := filepath.Join(manager.filesDir(), "legacy02_notes.txt")
+	require.NoError(t, os.WriteFile(legacyPath, []byte("old notes"), 0644))
+	database, err := sql.Open("sqlite", manager.DatabasePath())
+	require.NoError(t, err)
+	_, err = database.Exec("UPDATE attachments SET sha256 = '', size = 0, media_type = ''")
+	require.NoError(t, err)
+	require.NoError(t, database.Close())
+
+	objectsPath := filepath.Join(manager.filesDir(), "objects")
+	require.NoError(t, os.RemoveAll(objectsPath))
+	require.NoError(t, os.WriteFile(objectsPath, []byte("in the way"), 0644))
+	_, err = manager.UpgradeSchema()
+	assert.ErrorContains(t, err, "move attachments into the object store")
+	assert.FileExists(t, legacyPath)
+
+	require.NoError(t, os.Remove(objectsPath))
+	report, err := manager.UpgradeSchema()
+	require.NoError(t, err)
+	assert.False(t, report.Upgraded(), "the schema is already current")
+	entry, err := manager.GetEntry("legacy02")
+	require.NoError(t, err)
+	require.Len(t, entry.Attachments, 1)
+	assert.NotEmpty(t, entry.Attachments[0].SHA256)
+	assert.FileExists(t, storedAttachmentPath(manager, entry, "notes.txt"))
+	assert.NoFileExists(t, legacyPath)
+}
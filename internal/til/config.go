@@ -49,6 +49,28 @@ func LoadConfig(dir string) (Config, error) {
 			config.SyncToGit = strings.TrimSpace(value) == "true"
 		case "GIT_REMOTE_URL":
 			config.GitRemoteURL = strings.TrimSpace(value)
+		case "GIT_AUTHOR":
+			config.GitAuthor = strings.TrimSpace(value)
+		case "GIT_COMMIT_PER_ENTRY":
+			config.GitCommitPerEntry = strings.TrimSpace(value) == "true"
+		case "README_PAGES":
+			config.ReadmePages = strings.TrimSpace(value)
+		case "SYNC_TO_WEBHOOK":
+			config.SyncToWebhook = strings.TrimSpace(value) == "true"
+		case "WEBHOOK_URL":
+			config.WebhookURL = strings.TrimSpace(value)
+		case "WEBHOOK_HEADER":
+			header, err := ParseWebhookHeader(value)
+			if err != nil {
+				return config, fmt.Errorf("read configuration: %w", err)
+			}
+			config.WebhookHeaders = append(config.WebhookHeaders, header)
+		case "WEBHOOK_SECRET_ACCOUNT":
+			config.WebhookSecretAccount = strings.TrimSpace(value)
+		default:
+			if field, ok := notionPropertyConfigKey(strings.TrimSpace(key)); ok {
+				*field.name(&config.NotionProperties) = strings.TrimSpace(value)
+			}
 		}
 	}
 	if err := scanner.Err(); err != nil {
@@ -67,6 +89,13 @@ func LoadConfig(dir string) (Config, error) {
 			notionAPIKeySource,
 		)
 	}
+	if err := ValidateReadmePages(config.ReadmePages); err != nil {
+		return config, fmt.Errorf("read configuration: %w", err)
+	}
+	if err := ValidateGitAuthor(config.GitAuthor); err != nil {
+		return config, fmt.Errorf("read configuration: %w", err)
+	}
+	loadWebhookSecret(&config)
 	return config, nil
 }
 
@@ -76,11 +105,32 @@ func SaveConfig(config Config) error {
 		"NOTION_API_KEY_ACCOUNT": config.NotionAPIKeyAccount,
 		"NOTION_DB_ID":           config.NotionDBID,
 		"GIT_REMOTE_URL":         config.GitRemoteURL,
+		"WEBHOOK_URL":            config.WebhookURL,
+		"WEBHOOK_SECRET_ACCOUNT": config.WebhookSecretAccount,
 	} {
 		if strings.ContainsAny(value, "\r\n") {
 			return fmt.Errorf("%s cannot contain a newline", name)
 		}
 	}
+	for _, field := range notionPropertyFields {
+		name := notionPropertyConfigPrefix + field.key
+		value := *field.name(&config.NotionProperties)
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("%s cannot contain a newline", name)
+		}
+	}
+
+	for _, header := range config.WebhookHeaders {
+		if err := header.validate(); err != nil {
+			return err
+		}
+	}
+	if err := ValidateReadmePages(config.ReadmePages); err != nil {
+		return err
+	}
+	if err := ValidateGitAuthor(config.GitAuthor); err != nil {
+		return err
+	}
 
 	var content bytes.Buffer
 	fmt.Fprintf(&content, "SYNC_TO_NOTION=%t\n", config.SyncToNotion)
@@ -99,10 +149,36 @@ func SaveConfig(config Config) error {
 			fmt.Fprintf(&content, "NOTION_API_KEY=%s\n", config.NotionAPIKey)
 		}
 		fmt.Fprintf(&content, "NOTION_DB_ID=%s\n", config.NotionDBID)
+		for _, field := range notionPropertyFields {
+			if value := strings.TrimSpace(*field.name(&config.NotionProperties)); value != "" {
+				fmt.Fprintf(&content, "%s%s=%s\n", notionPropertyConfigPrefix, field.key, value)
+			}
+		}
 	}
 	fmt.Fprintf(&content, "SYNC_TO_GIT=%t\n", config.SyncToGit)
 	if config.SyncToGit {
 		fmt.Fprintf(&content, "GIT_REMOTE_URL=%s\n", config.GitRemoteURL)
+		if config.GitAuthor != "" {
+			fmt.Fprintf(&content, "GIT_AUTHOR=%s\n", config.GitAuthor)
+		}
+		if config.GitCommitPerEntry {
+			fmt.Fprintln(&content, "GIT_COMMIT_PER_ENTRY=true")
+		}
+	}
+	if config.ReadmePages != "" {
+		fmt.Fprintf(&content, "README_PAGES=%s\n", config.ReadmePages)
+	}
+	// Older configurations have no webhook section, so it is only written
+	// once a webhook has been enabled.
+	if config.SyncToWebhook {
+		fmt.Fprintln(&content, "SYNC_TO_WEBHOOK=true")
+		fmt.Fprintf(&content, "WEBHOOK_URL=%s\n", config.WebhookURL)
+		for _, header := range config.WebhookHeaders {
+			fmt.Fprintf(&content, "WEBHOOK_HEADER=%s: %s\n", header.Name, header.Value)
+		}
+		if config.WebhookSecretAccount != "" {
+			fmt.Fprintf(&content, "WEBHOOK_SECRET_ACCOUNT=%s\n", config.WebhookSecretAccount)
+		}
 	}
 
 	configFile := filepath.Join(config.DataDir, metadataDirectoryName, "config")
@@ -112,6 +188,19 @@ func SaveConfig(config Config) error {
 	return nil
 }
 
+// notionPropertyConfigPrefix starts the configuration keys of the Notion
+// property mapping, such as NOTION_PROPERTY_DATE.
+const notionPropertyConfigPrefix = "NOTION_PROPERTY_"
+
+func notionPropertyConfigKey(key string) (notionPropertyField, bool) {
+	for _, field := range notionPropertyFields {
+		if key == notionPropertyConfigPrefix+field.key {
+			return field, true
+		}
+	}
+	return notionPropertyField{}, false
+}
+
 func findConfigRoot(start string) (string, error) {
 	current, err := filepath.Abs(start)
 	if err != nil {
@@ -10,7 +10,7 @@ import (
 	"github.com/zalando/go-keyring"
 )
 
-const notionCredentialService = "TILed"
+const credentialService = "TILed"
 
 type credentialStore interface {
 	Set(service, account, secret string) error
@@ -32,7 +32,8 @@ func (systemCredentialStore) Delete(service, account string) error {
 	return keyring.Delete(service, account)
 }
 
-var notionCredentials credentialStore = systemCredentialStore{}
+// systemCredentials holds the Notion API key and the webhook signing secret.
+var systemCredentials credentialStore = systemCredentialStore{}
 
 func StoreNotionAPIKey(config *Config) error {
 	if config == nil {
@@ -59,8 +60,8 @@ func StoreNotionAPIKey(config *Config) error {
 	if strings.ContainsAny(config.NotionAPIKeyAccount, "\r\n") {
 		return errors.New("Notion keychain account cannot contain a newline")
 	}
-	if err := notionCredentials.Set(
-		notionCredentialService,
+	if err := systemCredentials.Set(
+		credentialService,
 		config.NotionAPIKeyAccount,
 		config.NotionAPIKey,
 	); err != nil {
@@ -77,8 +78,8 @@ func DeleteNotionAPIKey(config Config) error {
 	if !config.NotionAPIKeyInKeyring || config.NotionAPIKeyAccount == "" {
 		return nil
 	}
-	if err := notionCredentials.Delete(
-		notionCredentialService,
+	if err := systemCredentials.Delete(
+		credentialService,
 		config.NotionAPIKeyAccount,
 	); err != nil && !errors.Is(err, keyring.ErrNotFound) {
 		return fmt.Errorf("delete Notion API key from OS keychain: %w", err)
@@ -97,8 +98,8 @@ func loadNotionAPIKey(config *Config) {
 		return
 	}
 
-	secret, err := notionCredentials.Get(
-		notionCredentialService,
+	secret, err := systemCredentials.Get(
+		credentialService,
 		config.NotionAPIKeyAccount,
 	)
 	if err != nil {
@@ -119,9 +120,74 @@ func loadNotionAPIKey(config *Config) {
 }
 
 func newNotionCredentialAccount() (string, error) {
+	return newCredentialAccount("notion")
+}
+
+func newCredentialAccount(prefix string) (string, error) {
 	random := make([]byte, 16)
 	if _, err := rand.Read(random); err != nil {
-		return "", fmt.Errorf("generate Notion keychain account: %w", err)
+		return "", fmt.Errorf("generate %s keychain account: %w", prefix, err)
+	}
+	return prefix + "-" + hex.EncodeToString(random), nil
+}
+
+// StoreWebhookSecret saves the webhook signing secret in the OS keychain,
+// creating a keychain account for it when the configuration has none.
+func StoreWebhookSecret(config *Config) error {
+	if config == nil {
+		return errors.New("configuration is required")
+	}
+	if strings.TrimSpace(config.WebhookSecret) == "" {
+		return errors.New("webhook signing secret cannot be empty")
+	}
+	generatedAccount := false
+	if config.WebhookSecretAccount == "" {
+		account, err := newCredentialAccount("webhook")
+		if err != nil {
+			return err
+		}
+		config.WebhookSecretAccount = account
+		generatedAccount = true
+	}
+	if err := systemCredentials.Set(
+		credentialService,
+		config.WebhookSecretAccount,
+		config.WebhookSecret,
+	); err != nil {
+		if generatedAccount {
+			config.WebhookSecretAccount = ""
+		}
+		return fmt.Errorf("store webhook signing secret in OS keychain: %w", err)
+	}
+	config.WebhookSecretLoadError = nil
+	return nil
+}
+
+func DeleteWebhookSecret(config Config) error {
+	if config.WebhookSecretAccount == "" {
+		return nil
+	}
+	if err := systemCredentials.Delete(
+		credentialService,
+		config.WebhookSecretAccount,
+	); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("delete webhook signing secret from OS keychain: %w", err)
+	}
+	return nil
+}
+
+func loadWebhookSecret(config *Config) {
+	if !config.SyncToWebhook || config.WebhookSecretAccount == "" {
+		return
+	}
+	secret, err := systemCredentials.Get(credentialService, config.WebhookSecretAccount)
+	if err != nil {
+		config.WebhookSecretLoadError = fmt.Errorf(
+			"load webhook signing secret from OS keychain: %w",
+			err,
+		)
+		return
 	}
-	return "notion-" + hex.EncodeToString(random), nil
+	config.WebhookSecret = secret
+	config.WebhookSecretLoadError = nil
 }
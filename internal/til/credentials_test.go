@@ -112,11 +112,52 @@ func TestSaveKeyringConfigRequiresAccount(t *testing.T) {
 
 func useMemoryCredentialStore(t *testing.T) *memoryCredentialStore {
 	t.Helper()
-	previous := notionCredentials
+	previous := systemCredentials
 	store := &memoryCredentialStore{secrets: map[string]string{}}
-	notionCredentials = store
+	systemCredentials = store
 	t.Cleanup(func() {
-		notionCredentials = previous
+		systemCredentials = previous
 	})
 	return store
 }
+
+func TestWebhookConfigRoundTripKeepsSecretInKeychain(t *testing.T) {
+	store := useMemoryCredentialStore(t)
+	root := t.TempDir()
+	config := Config{
+		DataDir:       root,
+		SyncToWebhook: true,
+		WebhookURL:    "https://hooks.example.com/til",
+		WebhookHeaders: []WebhookHeader{
+			{Name: "Authorization", Value: "Bearer abc"},
+			{Name: "X-Team", Value: "learning"},
+		},
+		WebhookSecret: "signing-secret",
+	}
+	require.NoError(t, StoreWebhookSecret(&config))
+	require.NotEmpty(t, config.WebhookSecretAccount)
+	require.NoError(t, SaveConfig(config))
+
+	content, err := os.ReadFile(filepath.Join(root, ".til", "config"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "signing-secret")
+	assert.Contains(t, string(content), "WEBHOOK_HEADER=Authorization: Bearer abc\n")
+
+	loaded, err := LoadConfig(root)
+	require.NoError(t, err)
+	assert.True(t, loaded.SyncToWebhook)
+	assert.Equal(t, config.WebhookURL, loaded.WebhookURL)
+	assert.Equal(t, config.WebhookHeaders, loaded.WebhookHeaders)
+	assert.Equal(t, "signing-secret", loaded.WebhookSecret)
+	assert.NoError(t, loaded.WebhookSecretLoadError)
+
+	require.NoError(t, DeleteWebhookSecret(loaded))
+	assert.Empty(t, store.secrets)
+	missing, err := LoadConfig(root)
+	require.NoError(t, err)
+	assert.Empty(t, missing.WebhookSecret)
+	assert.Error(t, missing.WebhookSecretLoadError)
+
+	config.WebhookHeaders = []WebhookHeader{{Name: "X-Team", Value: "a\nb"}}
+	assert.ErrorContains(t, SaveConfig(config), `invalid value for webhook header "X-Team"`)
+}
@@ -101,7 +101,7 @@ func (m *Manager) BackupDatabase(destination string) (string, error) {
 		}
 	}
 
-	database, err := m.openDatabase()
+	database, _, err := m.openDatabaseConnection()
 	if err != nil {
 		return "", err
 	}
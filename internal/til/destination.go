@@ -0,0 +1,225 @@
+package til
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	gitDestinationName    = "git"
+	notionDestinationName = "notion"
+)
+
+// Destination publishes committed entries outside the repository. Git,
+// Notion, and webhooks are built in; other destinations are added with RegisterDestination.
+type Destination interface {
+	// Name identifies the destination in 'til push --to' and in the sync
+	// state stored for each entry.
+	Name() string
+	// Configure reads the destination's settings and reports whether it is
+	// enabled. It is called once before Push, Preview, or Status.
+	Configure(config Config) (bool, error)
+	// Push publishes entries and reports how many were pushed.
+	Push(ctx context.Context, m *Manager, options PushOptions) (PushReport, error)
+	// Status reports how many entries the destination has published.
+	Status(m *Manager) (DestinationStatus, error)
+}
+
+// PushPreviewer is implemented by destinations that can describe a push
+// without changing anything, for 'til push --dry-run'.
+type PushPreviewer interface {
+	Preview(ctx context.Context, m *Manager, options PushOptions) error
+}
+
+type PushOptions struct {
+	// Force pushes entries that are unchanged since their last push.
+	Force bool
+	// Feed makes Git pushes write feed.xml next to README.md.
+	Feed bool
+	// CommitPerEntry makes Git pushes create one commit per changed entry,
+	// as Config.GitCommitPerEntry does.
+	CommitPerEntry bool
+	// Jobs bounds the number of entries pushed concurrently.
+	Jobs int
+	// Progress is called on the calling goroutine after each entry is
+	// pushed or fails, once its result has been saved.
+	Progress func(PushProgress)
+	// Output receives messages about the push. It may be nil.
+	Output io.Writer
+}
+
+func (options PushOptions) output() io.Writer {
+	if options.Output == nil {
+		return io.Discard
+	}
+	return options.Output
+}
+
+type PushProgress struct {
+	Entry Entry
+	Err   error
+	Done  int
+	Total int
+}
+
+type PushReport struct {
+	Pushed  int
+	Failed  int
+	Removed int
+	// Remaining counts entries that were not attempted because ctx ended.
+	Remaining int
+}
+
+type DestinationStatus struct {
+	// Synced counts entries whose current content has been pushed.
+	Synced int
+	// Total counts the entries the destination publishes.
+	Total int
+}
+
+// DestinationSync is what a destination recorded about an entry when it was
+// last pushed. RemoteID is the destination's own identifier for it, if any.
+type DestinationSync struct {
+	Synced      bool
+	RemoteID    string
+	ContentHash string
+	SyncedAt    time.Time
+}
+
+// Current reports whether the entry is unchanged since it was pushed.
+// Entries synced without a content hash are trusted to be current.
+func (state DestinationSync) Current(entry Entry) bool {
+	return state.Synced && (state.ContentHash == "" || state.ContentHash == EntryContentHash(entry))
+}
+
+type registeredDestination struct {
+	name    string
+	factory func() Destination
+}
+
+// destinations are pushed in registration order. Git comes first so Notion
+// can link attachments to files that were just pushed.
+var destinations = []registeredDestination{
+	{gitDestinationName, func() Destination { return &GitDestination{} }},
+	{notionDestinationName, func() Destination { return &NotionDestination{} }},
+	{webhookDestinationName, func() Destination { return &WebhookDestination{} }},
+}
+
+// RegisterDestination makes a destination available to 'til push --to name'.
+// It is meant to be called from init functions and panics if the name is
+// empty or already registered.
+func RegisterDestination(name string, factory func() Destination) {
+	if name == "" || factory == nil {
+		panic("til: RegisterDestination needs a name and a factory")
+	}
+	if slices.ContainsFunc(destinations, func(registered registeredDestination) bool {
+		return registered.name == name
+	}) {
+		panic(fmt.Sprintf("til: destination %q is already registered", name))
+	}
+	destinations = append(destinations, registeredDestination{name, factory})
+}
+
+// DestinationNames lists the registered destinations in push order.
+func DestinationNames() []string {
+	names := make([]string, 0, len(destinations))
+	for _, registered := range destinations {
+		names = append(names, registered.name)
+	}
+	return names
+}
+
+// ConfiguredDestinations returns the enabled destinations in push order. When
+// names are given only those destinations are returned, and each of them must
+// be registered and enabled.
+func ConfiguredDestinations(config Config, names ...string) ([]Destination, error) {
+	for _, name := range names {
+		if !slices.Contains(DestinationNames(), name) {
+			return nil, fmt.Errorf(
+				"unknown destination %q; available destinations: %s",
+				name,
+				strings.Join(DestinationNames(), ", "),
+			)
+		}
+	}
+
+	configured := []Destination{}
+	for _, registered := range destinations {
+		selected := len(names) == 0 || slices.Contains(names, registered.name)
+		if !selected {
+			continue
+		}
+		destination := registered.factory()
+		enabled, err := destination.Configure(config)
+		if err != nil {
+			return nil, fmt.Errorf("configure %s destination: %w", registered.name, err)
+		}
+		if enabled {
+			configured = append(configured, destination)
+		} else if len(names) > 0 {
+			return nil, fmt.Errorf("%s sync is not configured; run 'til config edit'", registered.name)
+		}
+	}
+	return configured, nil
+}
+
+// DestinationSyncStates returns a destination's recorded state for each entry
+// it has pushed, keyed by commit ID.
+func (m *Manager) DestinationSyncStates(destination string) (map[string]DestinationSync, error) {
+	if !m.IsInitialized() {
+		return nil, ErrRepositoryNotInitialized
+	}
+	return m.destinationSyncStates(destination)
+}
+
+// RecordDestinationSync stores a destination's state for entries, keyed by
+// commit ID. An empty RemoteID or zero SyncedAt keeps the recorded value.
+func (m *Manager) RecordDestinationSync(destination string, states map[string]DestinationSync) error {
+	if !m.IsInitialized() {
+		return ErrRepositoryNotInitialized
+	}
+	return m.recordDestinationSync(destination, states)
+}
+
+// PendingEntries returns the entries a destination has not pushed since they
+// last changed, oldest first. With force every entry is returned.
+func (m *Manager) PendingEntries(destination string, force bool) ([]Entry, error) {
+	states, err := m.DestinationSyncStates(destination)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := m.QueryEntries(EntryQuery{OldestFirst: true})
+	if err != nil {
+		return nil, err
+	}
+	pending := []Entry{}
+	for _, entry := range entries {
+		if force || !states[entry.CommitID].Current(entry) {
+			pending = append(pending, entry)
+		}
+	}
+	return pending, nil
+}
+
+// destinationStatus counts the entries a destination has pushed.
+func (m *Manager) destinationStatus(destination string) (DestinationStatus, error) {
+	states, err := m.DestinationSyncStates(destination)
+	if err != nil {
+		return DestinationStatus{}, err
+	}
+	entries, err := m.GetLatestEntries(0)
+	if err != nil {
+		return DestinationStatus{}, err
+	}
+	status := DestinationStatus{Total: len(entries)}
+	for _, entry := range entries {
+		if states[entry.CommitID].Current(entry) {
+			status.Synced++
+		}
+	}
+	return status, nil
+}
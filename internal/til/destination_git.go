@@ -0,0 +1,218 @@
+package til
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+const gitCommitMessage = "Update TIL entries"
+
+// GitDestination commits the repository and pushes it to its remote.
+type GitDestination struct {
+	config Config
+}
+
+func (d *GitDestination) Name() string {
+	return gitDestinationName
+}
+
+func (d *GitDestination) Configure(config Config) (bool, error) {
+	d.config = config
+	return config.SyncToGit, nil
+}
+
+// Push commits every change in the repository, merges entries pushed from
+// other devices, and pushes the branch. The database is part of the commit,
+// so sync state is recorded first and reverted only if the commit fails.
+// Once committed, entries count as pushed: a failed merge or push leaves
+// the commit in place, and the next push sends it.
+func (d *GitDestination) Push(ctx context.Context, m *Manager, options PushOptions) (PushReport, error) {
+	output := options.output()
+	gitManager, err := d.gitManager(m)
+	if err != nil {
+		return PushReport{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return PushReport{}, err
+	}
+
+	pending, err := m.PendingEntries(gitDestinationName, false)
+	if err != nil {
+		return PushReport{}, err
+	}
+	pushedAt := time.Now()
+	synced := make(map[string]DestinationSync, len(pending))
+	unsynced := make(map[string]DestinationSync, len(pending))
+	for _, entry := range pending {
+		synced[entry.CommitID] = DestinationSync{
+			Synced:      true,
+			ContentHash: EntryContentHash(entry),
+			SyncedAt:    pushedAt,
+		}
+		unsynced[entry.CommitID] = DestinationSync{}
+	}
+	if err := m.RecordDestinationSync(gitDestinationName, synced); err != nil {
+		return PushReport{}, err
+	}
+	commits, err := d.commit(m, gitManager, options)
+	if err != nil {
+		return PushReport{}, errors.Join(err, m.RecordDestinationSync(gitDestinationName, unsynced))
+	}
+	switch commits {
+	case 0:
+		fmt.Fprintln(output, "No new Git changes to commit.")
+	case 1:
+		fmt.Fprintln(output, "Committed TIL changes to Git.")
+	default:
+		fmt.Fprintf(output, "Committed TIL changes to Git in %d commits.\n", commits)
+	}
+
+	// Entries pushed from other devices are merged so the push below
+	// fast-forwards the remote branch.
+	pullReport, err := m.PullGit()
+	if err != nil {
+		return PushReport{}, fmt.Errorf("merge entries from Git: %w", err)
+	}
+	if !pullReport.UpToDate {
+		WriteGitPullReport(output, pullReport)
+	}
+
+	if err := gitManager.Push(); err != nil {
+		return PushReport{}, err
+	}
+	fmt.Fprintln(output, "Successfully pushed changes to Git.")
+	return PushReport{Pushed: len(pending)}, nil
+}
+
+// commit regenerates the README and feed and commits every change.
+func (d *GitDestination) commit(m *Manager, gitManager *GitManager, options PushOptions) (int, error) {
+	if err := m.RefreshReadme(); err != nil {
+		return 0, err
+	}
+	if options.Feed {
+		if err := m.RefreshFeed(FeedOptions{}); err != nil {
+			return 0, err
+		}
+	}
+	return m.commitGitChanges(gitManager, options.CommitPerEntry || d.config.GitCommitPerEntry)
+}
+
+// Preview prints the README and feed changes, the files that would be
+// staged, and the commit and push that would follow. It does not fetch, so
+// entries from other devices are not shown.
+func (d *GitDestination) Preview(_ context.Context, m *Manager, options PushOptions) error {
+	output := options.output()
+	gitManager, err := d.gitManager(m)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(output, "\nGit:")
+
+	readmeDiff, err := m.ReadmeDiff()
+	if err != nil {
+		return err
+	}
+	if readmeDiff == "" {
+		fmt.Fprintln(output, "README.md is up to date.")
+	} else {
+		fmt.Fprintln(output, "README.md would be updated:")
+		fmt.Fprint(output, readmeDiff)
+	}
+
+	feedChanged := false
+	if options.Feed {
+		feed, err := m.RenderFeed(FeedOptions{})
+		if err != nil {
+			return err
+		}
+		current, err := os.ReadFile(m.feedPath())
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("read feed: %w", err)
+		}
+		feedChanged = !bytes.Equal(current, feed)
+		if feedChanged {
+			fmt.Fprintln(output, "feed.xml would be updated.")
+		} else {
+			fmt.Fprintln(output, "feed.xml is up to date.")
+		}
+	}
+
+	changes, err := gitManager.AddAllDryRun()
+	if err != nil {
+		return fmt.Errorf("preview Git changes: %w", err)
+	}
+	if feedChanged && !slices.Contains(changes, "add 'feed.xml'") {
+		changes = append([]string{"add 'feed.xml'"}, changes...)
+	}
+	if readmeDiff != "" && !slices.Contains(changes, "add 'README.md'") {
+		changes = append([]string{"add 'README.md'"}, changes...)
+	}
+	hasStaged, err := gitManager.HasStagedChanges()
+	if err != nil {
+		return err
+	}
+	entryChanges, err := m.gitEntryChanges(gitManager)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 && !hasStaged && len(entryChanges) == 0 {
+		fmt.Fprintln(output, "No new Git changes to commit.")
+	} else {
+		if len(changes) > 0 {
+			fmt.Fprintln(output, "Files that would be staged:")
+			for _, change := range changes {
+				fmt.Fprintf(output, "  %s\n", change)
+			}
+		}
+		if (options.CommitPerEntry || d.config.GitCommitPerEntry) && len(entryChanges) > 0 {
+			commits, err := m.gitEntryCommits(gitManager, entryChanges)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(output, "Would create %d commits, one per entry:\n", len(commits))
+			for _, commit := range commits {
+				fmt.Fprintf(output, "  %s\n", gitCommitSubject(gitCommitMessageFor(commit.Changes)))
+			}
+		} else {
+			fmt.Fprintf(output, "Would commit %q.\n", gitCommitSubject(gitCommitMessageFor(entryChanges)))
+		}
+	}
+	branch, err := gitManager.CurrentBranch()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(output, "Would merge entries pushed to origin from other devices.")
+	fmt.Fprintf(output, "Would push branch %s to origin.\n", branch)
+	return nil
+}
+
+func (d *GitDestination) Status(m *Manager) (DestinationStatus, error) {
+	return m.destinationStatus(gitDestinationName)
+}
+
+func (d *GitDestination) gitManager(m *Manager) (*GitManager, error) {
+	gitManager := NewGitManager(m.repositoryDir())
+	if !gitManager.IsInitialized() {
+		return nil, errors.New("Git repository is not initialized; run 'til config edit' to configure it")
+	}
+	return gitManager, nil
+}
+
+// gitBranch is the branch that links to pushed files point at: the current
+// branch when Git sync is set up, and main otherwise.
+func gitBranch(config Config, repositoryDir string) string {
+	if config.SyncToGit {
+		gitManager := NewGitManager(repositoryDir)
+		if gitManager.IsInitialized() {
+			if branch, err := gitManager.CurrentBranch(); err == nil {
+				return branch
+			}
+		}
+	}
+	return "main"
+}
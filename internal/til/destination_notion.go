@@ -0,0 +1,95 @@
+package til
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// NotionDestination publishes entries as pages in a Notion database.
+type NotionDestination struct {
+	config Config
+}
+
+func (d *NotionDestination) Name() string {
+	return notionDestinationName
+}
+
+func (d *NotionDestination) Configure(config Config) (bool, error) {
+	d.config = config
+	return config.SyncToNotion, nil
+}
+
+func (d *NotionDestination) Push(ctx context.Context, m *Manager, options PushOptions) (PushReport, error) {
+	client, err := NewNotionClientFromConfig(d.config, d.branch(m))
+	if err != nil {
+		return PushReport{}, err
+	}
+
+	output := options.output()
+	report, err := m.PushNotion(ctx, client, options)
+	fmt.Fprintf(output, "Successfully pushed %d %s to Notion.\n", report.Pushed, pluralizeEntry(report.Pushed))
+	if report.Removed > 0 {
+		fmt.Fprintf(output, "Archived %d removed %s in Notion.\n", report.Removed, pluralizeEntry(report.Removed))
+	}
+	return report, err
+}
+
+// Preview lists the pages that would be created, updated, or archived along
+// with the attachment URLs they would link to.
+func (d *NotionDestination) Preview(_ context.Context, m *Manager, options PushOptions) error {
+	output := options.output()
+	client, err := NewNotionClientFromConfig(d.config, d.branch(m))
+	if err != nil {
+		return err
+	}
+	plan, err := m.PlanNotionPush(options.Force)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(output, "\nNotion:")
+
+	for _, entry := range plan.Entries {
+		if entry.NotionPageID == "" {
+			fmt.Fprintf(output, "  create %s %q\n", entry.CommitID, entry.Message)
+		} else {
+			fmt.Fprintf(output, "  update %s %q (page %s)\n", entry.CommitID, entry.Message, entry.NotionPageID)
+		}
+		if len(entry.Files) == 0 {
+			continue
+		}
+		attachments, err := client.AttachmentURLs(entry, d.config.DataDir)
+		if err != nil {
+			fmt.Fprintf(output, "    attachments: %s\n", strings.ReplaceAll(err.Error(), "\n", " "))
+			continue
+		}
+		for _, attachment := range attachments {
+			fmt.Fprintf(output, "    %s: %s\n", attachment.Name, attachment.URL)
+		}
+	}
+	for _, tombstone := range plan.Archive {
+		fmt.Fprintf(output, "  archive %q (page %s)\n", tombstone.Message, tombstone.PageID)
+	}
+
+	fmt.Fprintf(output, "Would push %d %s to Notion.\n", len(plan.Entries), pluralizeEntry(len(plan.Entries)))
+	if len(plan.Archive) > 0 {
+		fmt.Fprintf(output, "Would archive %d removed %s in Notion.\n", len(plan.Archive), pluralizeEntry(len(plan.Archive)))
+	}
+	return nil
+}
+
+func (d *NotionDestination) Status(m *Manager) (DestinationStatus, error) {
+	return m.destinationStatus(notionDestinationName)
+}
+
+// branch is the Git branch attachment links point at.
+func (d *NotionDestination) branch(m *Manager) string {
+	return gitBranch(d.config, m.repositoryDir())
+}
+
+func pluralizeEntry(count int) string {
+	if count == 1 {
+		return "entry"
+	}
+	return "entries"
+}
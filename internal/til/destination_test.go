@@ -0,0 +1,171 @@
+package til
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDestination pushes entries by recording their commit IDs.
+type recordingDestination struct {
+	pushed []string
+}
+
+func (d *recordingDestination) Name() string { return "recording" }
+
+func (d *recordingDestination) Configure(Config) (bool, error) { return true, nil }
+
+func (d *recordingDestination) Push(_ context.Context, m *Manager, options PushOptions) (PushReport, error) {
+	pending, err := m.PendingEntries(d.Name(), options.Force)
+	if err != nil {
+		return PushReport{}, err
+	}
+	states := map[string]DestinationSync{}
+	for _, entry := range pending {
+		d.pushed = append(d.pushed, entry.CommitID)
+		states[entry.CommitID] = DestinationSync{Synced: true, RemoteID: "remote-" + entry.CommitID, ContentHash: EntryContentHash(entry)}
+	}
+	return PushReport{Pushed: len(pending)}, m.RecordDestinationSync(d.Name(), states)
+}
+
+func (d *recordingDestination) Status(m *Manager) (DestinationStatus, error) {
+	return m.destinationStatus(d.Name())
+}
+
+func TestConfiguredDestinationsSelectsEnabledDestinationsInOrder(t *testing.T) {
+	config := Config{SyncToGit: true, SyncToNotion: true}
+	destinations, err := ConfiguredDestinations(config)
+	require.NoError(t, err)
+	require.Len(t, destinations, 2)
+	assert.Equal(t, "git", destinations[0].Name())
+	assert.Equal(t, "notion", destinations[1].Name())
+
+	destinations, err = ConfiguredDestinations(config, "notion")
+	require.NoError(t, err)
+	require.Len(t, destinations, 1)
+	assert.Equal(t, "notion", destinations[0].Name())
+
+	destinations, err = ConfiguredDestinations(Config{SyncToNotion: true})
+	require.NoError(t, err)
+	require.Len(t, destinations, 1)
+
+	_, err = ConfiguredDestinations(Config{SyncToNotion: true}, "git")
+	assert.ErrorContains(t, err, "git sync is not configured")
+	_, err = ConfiguredDestinations(config, "ftp")
+	assert.ErrorContains(t, err, `unknown destination "ftp"; available destinations: git, notion, webhook`)
+}
+
+func TestRegisteredDestinationTracksItsOwnSyncState(t *testing.T) {
+	original := destinations
+	t.Cleanup(func() { destinations = original })
+	destinations = append([]registeredDestination(nil), original...)
+	recorder := &recordingDestination{}
+	RegisterDestination("recording", func() Destination { return recorder })
+	assert.Panics(t, func() { RegisterDestination("recording", func() Destination { return recorder }) })
+
+	manager, _ := newTestManager(t, Config{})
+	commitTestEntries(t, manager, 2)
+	entries, err := manager.QueryEntries(EntryQuery{OldestFirst: true})
+	require.NoError(t, err)
+
+	selected, err := ConfiguredDestinations(manager.Config, "recording")
+	require.NoError(t, err)
+	require.Len(t, selected, 1)
+	report, err := selected[0].Push(context.Background(), manager, PushOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Pushed)
+	assert.Equal(t, []string{entries[0].CommitID, entries[1].CommitID}, recorder.pushed)
+
+	states, err := manager.DestinationSyncStates("recording")
+	require.NoError(t, err)
+	assert.Equal(t, "remote-"+entries[0].CommitID, states[entries[0].CommitID].RemoteID)
+	status, err := selected[0].Status(manager)
+	require.NoError(t, err)
+	assert.Equal(t, DestinationStatus{Synced: 2, Total: 2}, status)
+
+	require.NoError(t, manager.EditEntry(entries[1].CommitID, "Entry 2 (edited)", ""))
+	pending, err := manager.PendingEntries("recording", false)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, entries[1].CommitID, pending[0].CommitID)
+	status, err = selected[0].Status(manager)
+	require.NoError(t, err)
+	assert.Equal(t, DestinationStatus{Synced: 1, Total: 2}, status)
+
+	after, err := manager.QueryEntries(EntryQuery{OldestFirst: true})
+	require.NoError(t, err)
+	assert.False(t, after[0].NotionSynced, "other destinations do not mark entries as synced to Notion")
+}
+
+func TestDestinationSyncStateIsRemovedWithEntry(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntry("Published"))
+	entries, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+	entry := entries[0]
+	entry.NotionSynced = true
+	entry.NotionPageID = "page-1"
+	require.NoError(t, manager.UpdateEntryNotionSyncStatus(entry))
+	require.NoError(t, manager.RecordDestinationSync("git", map[string]DestinationSync{
+		entry.CommitID: {Synced: true},
+	}))
+
+	_, err = manager.RemoveEntry(entry.CommitID)
+	require.NoError(t, err)
+	for _, destination := range []string{"git", "notion"} {
+		states, err := manager.DestinationSyncStates(destination)
+		require.NoError(t, err)
+		assert.Empty(t, states, destination)
+	}
+	tombstones, err := manager.NotionPageTombstones()
+	require.NoError(t, err)
+	require.Len(t, tombstones, 1)
+	assert.Equal(t, "page-1", tombstones[0].PageID)
+}
+
+func TestNotionPreviewListsCreatesUpdatesAndAttachmentURLs(t *testing.T) {
+	root := t.TempDir()
+	config := Config{
+		DataDir:      root,
+		SyncToNotion: true,
+		NotionAPIKey: "secret",
+		NotionDBID:   "database-id",
+		SyncToGit:    true,
+		GitRemoteURL: "git@github.com:example/learning.git",
+	}
+	manager := NewManager(config)
+	require.NoError(t, manager.Init())
+	source := filepath.Join(root, "slides.pdf")
+	require.NoError(t, os.WriteFile(source, []byte("pdf"), 0644))
+	require.NoError(t, manager.AddFile(source))
+	require.NoError(t, manager.CommitEntry("New entry"))
+	require.NoError(t, manager.CommitEntry("Published entry"))
+	entries, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+	published := entries[0]
+	published.NotionSynced = true
+	published.NotionPageID = "page-1"
+	require.NoError(t, manager.UpdateEntryNotionSyncStatus(published))
+	require.NoError(t, manager.EditEntry(published.CommitID, "Published entry (edited)", ""))
+
+	destination := &NotionDestination{}
+	enabled, err := destination.Configure(config)
+	require.NoError(t, err)
+	require.True(t, enabled)
+	var output bytes.Buffer
+	require.NoError(t, destination.Preview(context.Background(), manager, PushOptions{Output: &output}))
+	preview := output.String()
+	assert.Contains(t, preview, `create `+entries[1].CommitID+` "New entry"`)
+	assert.Contains(t, preview, "    slides.pdf: https://raw.githubusercontent.com/example/learning/main/files/"+storedAttachmentName(entries[1], "slides.pdf")+"\n")
+	assert.Contains(t, preview, `update `+published.CommitID+` "Published entry (edited)" (page page-1)`)
+	assert.Contains(t, preview, "Would push 2 entries to Notion.\n")
+
+	after, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+	assert.True(t, after[0].NeedsNotionPush(), "a preview does not mark entries as synced")
+}
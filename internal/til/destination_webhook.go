@@ -0,0 +1,239 @@
+package til
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	webhookDestinationName = "webhook"
+	webhookTimeout         = 30 * time.Second
+
+	// WebhookSignatureHeader carries the hex HMAC-SHA256 of the request body,
+	// prefixed with "sha256=", when a signing secret is configured.
+	WebhookSignatureHeader = "X-TIL-Signature"
+	// WebhookEventHeader is "entry.created" for an entry's first delivery
+	// and "entry.updated" when a changed entry is delivered again.
+	WebhookEventHeader = "X-TIL-Event"
+)
+
+// ParseWebhookHeader reads a header in "Name: value" form.
+func ParseWebhookHeader(value string) (WebhookHeader, error) {
+	name, headerValue, ok := strings.Cut(value, ":")
+	header := WebhookHeader{Name: strings.TrimSpace(name), Value: strings.TrimSpace(headerValue)}
+	if !ok {
+		return header, fmt.Errorf("webhook header %q must use the form 'Name: value'", value)
+	}
+	return header, header.validate()
+}
+
+func (header WebhookHeader) validate() error {
+	validName := header.Name != "" && !strings.ContainsFunc(header.Name, func(r rune) bool {
+		// Header names are RFC 9110 tokens.
+		return r > '~' || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r)
+	})
+	if !validName {
+		return fmt.Errorf("invalid webhook header name %q", header.Name)
+	}
+	if strings.ContainsAny(header.Value, "\r\n\x00") {
+		return fmt.Errorf("invalid value for webhook header %q", header.Name)
+	}
+	return nil
+}
+
+// ValidateWebhookURL checks that deliveries can be sent to rawURL.
+func ValidateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("webhook URL %q must be an absolute http or https URL", RedactWebhookURL(rawURL))
+	}
+	return nil
+}
+
+// RedactWebhookURL hides credentials and query parameters, which chat
+// services commonly use to carry tokens.
+func RedactWebhookURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return "(redacted)"
+	}
+	redacted := parsed.Scheme + "://" + parsed.Host + "/"
+	if parsed.Path != "" && parsed.Path != "/" {
+		redacted += "..."
+	}
+	return redacted
+}
+
+// WebhookDestination POSTs each new or changed entry as JSON, in the same
+// form as 'til log --json', one request per entry.
+type WebhookDestination struct {
+	config Config
+	client *http.Client
+}
+
+func (d *WebhookDestination) Name() string {
+	return webhookDestinationName
+}
+
+func (d *WebhookDestination) Configure(config Config) (bool, error) {
+	d.config = config
+	if !config.SyncToWebhook {
+		return false, nil
+	}
+	if err := ValidateWebhookURL(config.WebhookURL); err != nil {
+		return false, err
+	}
+	if d.client == nil {
+		d.client = &http.Client{Timeout: webhookTimeout}
+	}
+	return true, nil
+}
+
+// Push delivers entries oldest first. Each delivery is recorded as soon as
+// the endpoint accepts it; failed entries stay pending and are retried by the
+// next push.
+func (d *WebhookDestination) Push(ctx context.Context, m *Manager, options PushOptions) (PushReport, error) {
+	if d.config.WebhookSecretLoadError != nil {
+		return PushReport{}, fmt.Errorf("%w; run 'til config webhook --secret'", d.config.WebhookSecretLoadError)
+	}
+	states, err := m.DestinationSyncStates(webhookDestinationName)
+	if err != nil {
+		return PushReport{}, err
+	}
+	pending, err := m.PendingEntries(webhookDestinationName, options.Force)
+	if err != nil {
+		return PushReport{}, err
+	}
+
+	report := PushReport{}
+	var deliveryErrors []error
+	for _, entry := range pending {
+		if err := ctx.Err(); err != nil {
+			report.Remaining = len(pending) - report.Pushed - report.Failed
+			return report, errors.Join(append(deliveryErrors, err)...)
+		}
+		_, delivered := states[entry.CommitID]
+		err := d.deliver(ctx, entry, delivered)
+		if err == nil {
+			err = m.RecordDestinationSync(webhookDestinationName, map[string]DestinationSync{
+				entry.CommitID: {Synced: true, ContentHash: EntryContentHash(entry), SyncedAt: time.Now()},
+			})
+		}
+		if err != nil {
+			report.Failed++
+			deliveryErrors = append(deliveryErrors, fmt.Errorf("%q: %w", entry.Message, err))
+		} else {
+			report.Pushed++
+		}
+		if options.Progress != nil {
+			options.Progress(PushProgress{
+				Entry: entry,
+				Err:   err,
+				Done:  report.Pushed + report.Failed,
+				Total: len(pending),
+			})
+		}
+	}
+
+	fmt.Fprintf(
+		options.output(),
+		"Delivered %d %s to %s.\n",
+		report.Pushed,
+		pluralizeEntry(report.Pushed),
+		RedactWebhookURL(d.config.WebhookURL),
+	)
+	return report, errors.Join(deliveryErrors...)
+}
+
+func (d *WebhookDestination) deliver(ctx context.Context, entry Entry, delivered bool) error {
+	body, err := json.Marshal(NewEntryJSON(entry))
+	if err != nil {
+		return fmt.Errorf("encode entry: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, d.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create webhook request: %w", err)
+	}
+	for _, header := range d.config.WebhookHeaders {
+		request.Header.Add(header.Name, header.Value)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	event := "entry.created"
+	if delivered {
+		event = "entry.updated"
+	}
+	request.Header.Set(WebhookEventHeader, event)
+	if d.config.WebhookSecret != "" {
+		request.Header.Set(WebhookSignatureHeader, SignWebhookPayload(d.config.WebhookSecret, body))
+	}
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		// The error message includes the URL, which may carry a token.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("deliver webhook: %w", err)
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 1<<20))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", response.Status)
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the X-TIL-Signature value for a request body.
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Preview lists the entries that would be delivered.
+func (d *WebhookDestination) Preview(_ context.Context, m *Manager, options PushOptions) error {
+	output := options.output()
+	states, err := m.DestinationSyncStates(webhookDestinationName)
+	if err != nil {
+		return err
+	}
+	pending, err := m.PendingEntries(webhookDestinationName, options.Force)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(output, "\nWebhook:")
+	for _, entry := range pending {
+		event := "create"
+		if _, delivered := states[entry.CommitID]; delivered {
+			event = "update"
+		}
+		fmt.Fprintf(output, "  %s %s %q\n", event, entry.CommitID, entry.Message)
+	}
+	fmt.Fprintf(
+		output,
+		"Would deliver %d %s to %s.\n",
+		len(pending),
+		pluralizeEntry(len(pending)),
+		RedactWebhookURL(d.config.WebhookURL),
+	)
+	return nil
+}
+
+func (d *WebhookDestination) Status(m *Manager) (DestinationStatus, error) {
+	return m.destinationStatus(webhookDestinationName)
+}
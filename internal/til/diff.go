@@ -0,0 +1,141 @@
+package til
+
+import (
+	"fmt"
+	"strings"
+)
+
+type diffOperation struct {
+	kind byte
+	line string
+}
+
+// UnifiedDiff returns a line-based unified diff of two texts with the given
+// number of context lines, or an empty string when they are equal.
+func UnifiedDiff(fromName, toName, from, to string, context int) string {
+	fromLines := splitDiffLines(from)
+	toLines := splitDiffLines(to)
+	operations := diffLines(fromLines, toLines)
+
+	changed := false
+	for _, operation := range operations {
+		if operation.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var output strings.Builder
+	fmt.Fprintf(&output, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(operations); {
+		if operations[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		hunkStart := max(start-context, 0)
+		hunkEnd := start
+		for index := start; index < len(operations); index++ {
+			if operations[index].kind != ' ' {
+				hunkEnd = index + 1
+				continue
+			}
+			if index-hunkEnd >= 2*context {
+				break
+			}
+		}
+		hunkEnd = min(hunkEnd+context, len(operations))
+
+		fromStart, toStart := 1, 1
+		for _, operation := range operations[:hunkStart] {
+			if operation.kind != '+' {
+				fromStart++
+			}
+			if operation.kind != '-' {
+				toStart++
+			}
+		}
+		fromCount, toCount := 0, 0
+		for _, operation := range operations[hunkStart:hunkEnd] {
+			if operation.kind != '+' {
+				fromCount++
+			}
+			if operation.kind != '-' {
+				toCount++
+			}
+		}
+		fmt.Fprintf(
+			&output,
+			"@@ -%s +%s @@\n",
+			hunkRange(fromStart, fromCount),
+			hunkRange(toStart, toCount),
+		)
+		for _, operation := range operations[hunkStart:hunkEnd] {
+			fmt.Fprintf(&output, "%c%s\n", operation.kind, operation.line)
+		}
+		start = hunkEnd
+	}
+	return output.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitDiffLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(text, "\n")
+}
+
+// diffLines computes an edit script from the longest common subsequence of two line slices.
+func diffLines(from, to []string) []diffOperation {
+	lengths := make([][]int, len(from)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	operations := make([]diffOperation, 0, len(from)+len(to))
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			operations = append(operations, diffOperation{kind: ' ', line: from[i]})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			operations = append(operations, diffOperation{kind: '-', line: from[i]})
+			i++
+		default:
+			operations = append(operations, diffOperation{kind: '+', line: to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		operations = append(operations, diffOperation{kind: '-', line: from[i]})
+	}
+	for ; j < len(to); j++ {
+		operations = append(operations, diffOperation{kind: '+', line: to[j]})
+	}
+	return operations
+}
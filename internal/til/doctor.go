@@ -0,0 +1,433 @@
+package til
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// DoctorFinding is something Doctor found, with how to fix it. Warnings are
+// worth tidying up but do not make the repository unhealthy.
+type DoctorFinding struct {
+	Problem string
+	Fix     string
+	Warning bool
+}
+
+// DoctorCheck is the outcome of one of Doctor's checks. Skipped explains why
+// a check did not apply, such as a destination that is not configured.
+type DoctorCheck struct {
+	Name     string
+	Skipped  string
+	Findings []DoctorFinding
+}
+
+// Problems counts the findings that are not warnings.
+func (check DoctorCheck) Problems() int {
+	problems := 0
+	for _, finding := range check.Findings {
+		if !finding.Warning {
+			problems++
+		}
+	}
+	return problems
+}
+
+type DoctorReport struct {
+	Checks []DoctorCheck
+}
+
+// Problems counts the findings of every check that are not warnings.
+func (report DoctorReport) Problems() int {
+	problems := 0
+	for _, check := range report.Checks {
+		problems += check.Problems()
+	}
+	return problems
+}
+
+func (report DoctorReport) Healthy() bool {
+	return report.Problems() == 0
+}
+
+// notionPropertyValidator checks a Notion database's properties.
+type notionPropertyValidator interface {
+	ValidateProperties(ctx context.Context) error
+}
+
+// Doctor checks the configuration, OS keychain, database, staging
+// directory, stored files, README, Git remote, and Notion database. Checks
+// that contact Git or Notion run only when they are configured.
+func (m *Manager) Doctor(ctx context.Context) (DoctorReport, error) {
+	return m.doctor(ctx, func() (notionPropertyValidator, error) {
+		return NewNotionClientFromConfig(m.Config, "")
+	})
+}
+
+func (m *Manager) doctor(ctx context.Context, notionClient func() (notionPropertyValidator, error)) (DoctorReport, error) {
+	if !m.IsInitialized() {
+		return DoctorReport{}, ErrRepositoryNotInitialized
+	}
+	report := DoctorReport{Checks: []DoctorCheck{
+		m.doctorConfig(),
+		m.doctorCredentials(),
+	}}
+	for _, check := range []func() (DoctorCheck, error){
+		m.doctorDatabase,
+		m.doctorStaging,
+		m.doctorStoredFiles,
+		m.doctorReadme,
+	} {
+		result, err := check()
+		if err != nil {
+			return DoctorReport{}, err
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	report.Checks = append(report.Checks, m.doctorGit(), m.doctorNotion(ctx, notionClient))
+	return report, nil
+}
+
+// DoctorConfigError reports a configuration file that could not be loaded,
+// for when Doctor cannot run at all.
+func DoctorConfigError(err error) DoctorReport {
+	return DoctorReport{Checks: []DoctorCheck{{
+		Name: "Configuration",
+		Findings: []DoctorFinding{{
+			Problem: err.Error(),
+			Fix:     "Correct the setting in .til/config, or run 'til config edit' to rewrite it.",
+		}},
+	}}}
+}
+
+func (m *Manager) doctorConfig() DoctorCheck {
+	check := DoctorCheck{Name: "Configuration"}
+	add := func(problem, fix string, warning bool) {
+		check.Findings = append(check.Findings, DoctorFinding{Problem: problem, Fix: fix, Warning: warning})
+	}
+
+	configPath := filepath.Join(m.Config.DataDir, metadataDirectoryName, "config")
+	if info, err := os.Stat(configPath); err != nil {
+		add(fmt.Sprintf("cannot read %s: %v", configPath, err), "Run 'til config edit' to write it again.", false)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		add(
+			fmt.Sprintf("%s can be read by other users (mode %04o)", configPath, info.Mode().Perm()),
+			fmt.Sprintf("Run 'chmod 600 %s'.", configPath),
+			false,
+		)
+	}
+
+	if m.Config.SyncToNotion {
+		if strings.TrimSpace(m.Config.NotionDBID) == "" {
+			add("Notion sync is enabled but no database ID is set", "Run 'til config edit' to set it.", false)
+		}
+		if !m.Config.NotionAPIKeyInKeyring && strings.TrimSpace(m.Config.NotionAPIKey) == "" {
+			add("Notion sync is enabled but no API key is set", "Run 'til config edit' to set it.", false)
+		}
+		if !m.Config.NotionAPIKeyInKeyring && strings.TrimSpace(m.Config.NotionAPIKey) != "" {
+			add(
+				"the Notion API key is stored in .til/config in plain text",
+				"Run 'til config edit' and choose to store it in the OS keychain.",
+				true,
+			)
+		}
+	}
+	if m.Config.SyncToGit && strings.TrimSpace(m.Config.GitRemoteURL) == "" {
+		add("Git sync is enabled but no remote URL is set", "Run 'til config edit' to set it.", false)
+	}
+	if m.Config.SyncToWebhook {
+		if err := ValidateWebhookURL(m.Config.WebhookURL); err != nil {
+			add(fmt.Sprintf("webhook URL is invalid: %v", err), "Run 'til config webhook --url <url>' to set it.", false)
+		}
+	}
+	return check
+}
+
+func (m *Manager) doctorCredentials() DoctorCheck {
+	check := DoctorCheck{Name: "OS keychain"}
+	notion := m.Config.SyncToNotion && m.Config.NotionAPIKeyInKeyring
+	webhook := m.Config.SyncToWebhook && m.Config.WebhookSecretAccount != ""
+	if !notion && !webhook {
+		check.Skipped = "no secrets are stored in the OS keychain"
+		return check
+	}
+	if notion && m.Config.NotionAPIKeyLoadError != nil {
+		check.Findings = append(check.Findings, DoctorFinding{
+			Problem: m.Config.NotionAPIKeyLoadError.Error(),
+			Fix:     "Unlock the OS keychain, or run 'til config edit' to store the Notion API key again.",
+		})
+	}
+	if webhook && m.Config.WebhookSecretLoadError != nil {
+		check.Findings = append(check.Findings, DoctorFinding{
+			Problem: m.Config.WebhookSecretLoadError.Error(),
+			Fix:     "Unlock the OS keychain, or run 'til config webhook --secret' to store the signing secret again.",
+		})
+	}
+	return check
+}
+
+func (m *Manager) doctorDatabase() (DoctorCheck, error) {
+	check := DoctorCheck{Name: "Database"}
+	report, err := m.CheckDatabaseIntegrity()
+	if err != nil {
+		return DoctorCheck{}, err
+	}
+	for _, problem := range report.Problems() {
+		check.Findings = append(check.Findings, DoctorFinding{
+			Problem: problem,
+			Fix:     "Restore a snapshot from .til/backups, or a portable archive with 'til restore'.",
+		})
+	}
+	return check, nil
+}
+
+func (m *Manager) doctorStaging() (DoctorCheck, error) {
+	check := DoctorCheck{Name: "Staging"}
+	info, err := os.Lstat(m.stagingDir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return check, nil
+	}
+	if err != nil {
+		return DoctorCheck{}, fmt.Errorf("inspect staging directory: %w", err)
+	}
+	if !info.IsDir() {
+		check.Findings = append(check.Findings, DoctorFinding{
+			Problem: fmt.Sprintf("%s is not a directory", m.stagingDir()),
+			Fix:     fmt.Sprintf("Remove %s; 'til add' creates it again.", m.stagingDir()),
+		})
+		return check, nil
+	}
+
+	err = filepath.WalkDir(m.stagingDir(), func(filePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			check.Findings = append(check.Findings, DoctorFinding{
+				Problem: fmt.Sprintf("%s is not a regular file and will not be committed", filePath),
+				Fix:     fmt.Sprintf("Remove %s.", filePath),
+				Warning: true,
+			})
+			return nil
+		}
+		if isTemporaryFileName(entry.Name()) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > MaxFileSize {
+			relative, err := filepath.Rel(m.stagingDir(), filePath)
+			if err != nil {
+				return err
+			}
+			check.Findings = append(check.Findings, DoctorFinding{
+				Problem: fmt.Sprintf("staged file %s is larger than %d bytes", filepath.ToSlash(relative), MaxFileSize),
+				Fix:     fmt.Sprintf("Run 'til reset %s' before the next commit.", filepath.ToSlash(relative)),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return DoctorCheck{}, fmt.Errorf("inspect staging directory: %w", err)
+	}
+	return check, nil
+}
+
+// doctorStoredFiles reports missing bodies and attachments, attachment
+// content that no longer matches its recorded digest, and orphaned files.
+func (m *Manager) doctorStoredFiles() (DoctorCheck, error) {
+	check := DoctorCheck{Name: "Stored files"}
+	report, err := m.CollectGarbage(GCOptions{})
+	if err != nil {
+		return DoctorCheck{}, err
+	}
+	for _, missing := range report.Missing {
+		what := "the body of " + missing.CommitID
+		if missing.Name != "" {
+			what = fmt.Sprintf("attachment %s of %s", missing.Name, missing.CommitID)
+		}
+		if missing.Revision > 0 {
+			what += fmt.Sprintf(" revision %d", missing.Revision)
+		}
+		check.Findings = append(check.Findings, DoctorFinding{
+			Problem: fmt.Sprintf("%s is missing (%s)", missing.Path, what),
+			Fix:     "Restore the file from a backup or the Git remote, or remove the entry with 'til rm'.",
+		})
+	}
+
+	entries, err := m.QueryEntries(EntryQuery{OldestFirst: true})
+	if err != nil {
+		return DoctorCheck{}, err
+	}
+	verified := map[string]bool{}
+	for _, entry := range entries {
+		revisions, err := m.EntryRevisions(entry.CommitID)
+		if err != nil {
+			return DoctorCheck{}, err
+		}
+		for _, owner := range append([]Entry{entry}, revisionEntries(entry, revisions)...) {
+			for _, attachment := range owner.Attachments {
+				storedName := storedAttachmentName(owner, attachment.Name)
+				if attachment.SHA256 == "" || verified[storedName] {
+					continue
+				}
+				verified[storedName] = true
+				problem, err := m.verifyAttachmentObject(attachment, storedName)
+				if err != nil {
+					return DoctorCheck{}, err
+				}
+				if problem != "" {
+					check.Findings = append(check.Findings, DoctorFinding{
+						Problem: fmt.Sprintf("%s %s (attachment %s of %s)", filepath.ToSlash(filepath.Join(repositoryDirectory, filesDirectoryName, storedName)), problem, attachment.Name, entry.CommitID),
+						Fix:     "Restore the file from a backup or the Git remote.",
+					})
+				}
+			}
+		}
+	}
+
+	if len(report.Orphans) > 0 {
+		check.Findings = append(check.Findings, DoctorFinding{
+			Problem: fmt.Sprintf(
+				"%d orphaned file(s) (%d bytes) are not referenced by any entry",
+				len(report.Orphans),
+				report.OrphanedBytes(),
+			),
+			Fix:     "Run 'til gc' to list them and 'til gc --prune' to delete them.",
+			Warning: true,
+		})
+	}
+	return check, nil
+}
+
+// verifyAttachmentObject describes how a stored attachment differs from its
+// recorded size and digest, or returns an empty string when it matches or
+// is missing.
+func (m *Manager) verifyAttachmentObject(attachment Attachment, storedName string) (string, error) {
+	file, err := os.Open(filepath.Join(m.filesDir(), storedName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read attachment %s: %w", attachment.Name, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", fmt.Errorf("read attachment %s: %w", attachment.Name, err)
+	}
+	if size != attachment.Size {
+		return fmt.Sprintf("has %d bytes but %d were recorded", size, attachment.Size), nil
+	}
+	if digest := hex.EncodeToString(hash.Sum(nil)); digest != attachment.SHA256 {
+		return "does not match its recorded SHA-256 checksum", nil
+	}
+	return "", nil
+}
+
+func (m *Manager) doctorReadme() (DoctorCheck, error) {
+	check := DoctorCheck{Name: "README"}
+	if !m.Config.SyncToGit {
+		check.Skipped = "Git sync is disabled, so the README is not maintained"
+		return check, nil
+	}
+	diff, err := m.ReadmeDiff()
+	if err != nil {
+		return DoctorCheck{}, err
+	}
+	if diff != "" {
+		check.Findings = append(check.Findings, DoctorFinding{
+			Problem: "README.md does not match the entries in the database",
+			Fix:     "Run 'til push --git --dry-run' to see the difference and 'til push --git' to regenerate and publish it.",
+		})
+	}
+	return check, nil
+}
+
+func (m *Manager) doctorGit() DoctorCheck {
+	check := DoctorCheck{Name: "Git remote"}
+	if !m.Config.SyncToGit {
+		check.Skipped = "Git sync is disabled"
+		return check
+	}
+	gitManager := NewGitManager(m.repositoryDir())
+	if !gitManager.IsInitialized() {
+		check.Findings = append(check.Findings, DoctorFinding{
+			Problem: fmt.Sprintf("%s is not a Git repository", m.repositoryDir()),
+			Fix:     "Run 'til config edit' and enable Git sync again to set it up.",
+		})
+		return check
+	}
+	remoteURL, err := gitManager.RemoteURL()
+	if err != nil {
+		check.Findings = append(check.Findings, DoctorFinding{
+			Problem: err.Error(),
+			Fix:     "Run 'til config edit' and enter the remote URL again.",
+		})
+		return check
+	}
+	if strings.TrimSpace(m.Config.GitRemoteURL) != "" && remoteURL != m.Config.GitRemoteURL {
+		check.Findings = append(check.Findings, DoctorFinding{
+			Problem: fmt.Sprintf(
+				"origin points at %s but .til/config has %s",
+				RedactGitRemoteURL(remoteURL),
+				RedactGitRemoteURL(m.Config.GitRemoteURL),
+			),
+			Fix: "Run 'til config edit' and enter the remote URL you want to use.",
+		})
+	}
+	if err := gitManager.CheckRemote(); err != nil {
+		check.Findings = append(check.Findings, DoctorFinding{
+			Problem: err.Error(),
+			Fix:     "Check the remote URL, your network connection, and your Git credentials.",
+		})
+	}
+	return check
+}
+
+func (m *Manager) doctorNotion(ctx context.Context, notionClient func() (notionPropertyValidator, error)) DoctorCheck {
+	check := DoctorCheck{Name: "Notion database"}
+	if !m.Config.SyncToNotion {
+		check.Skipped = "Notion sync is disabled"
+		return check
+	}
+	client, err := notionClient()
+	if err != nil {
+		check.Findings = append(check.Findings, DoctorFinding{
+			Problem: err.Error(),
+			Fix:     "Run 'til config edit' to set the Notion API key.",
+		})
+		return check
+	}
+	err = client.ValidateProperties(ctx)
+	if err == nil {
+		return check
+	}
+	problems := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		problems = joined.Unwrap()
+	}
+	for _, problem := range problems {
+		fix := "Add the property to the Notion database, or map another one with NOTION_PROPERTY_* in .til/config."
+		if strings.HasPrefix(problem.Error(), "read Notion database") {
+			fix = "Check the database ID with 'til config', and share the database with your Notion integration."
+		}
+		check.Findings = append(check.Findings, DoctorFinding{Problem: problem.Error(), Fix: fix})
+	}
+	return check
+}
@@ -0,0 +1,114 @@
+package til
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoctorReportsProblemsWithFixes(t *testing.T) {
+	requireGit(t)
+	setGitIdentity(t)
+	remote := newBareRepository(t)
+	fake := newFakeNotionServer(t)
+	manager, root := newTestManager(t, Config{
+		SyncToGit:    true,
+		GitRemoteURL: remote,
+		SyncToNotion: true,
+		NotionAPIKey: "secret-token",
+		NotionDBID:   "database-id",
+	})
+	require.NoError(t, SaveConfig(manager.Config))
+	require.NoError(t, NewGitManager(manager.repositoryDir()).Configure(remote))
+	source := filepath.Join(root, "notes.txt")
+	require.NoError(t, os.WriteFile(source, []byte("notes"), 0644))
+	require.NoError(t, manager.AddFile(source))
+	require.NoError(t, manager.CommitEntryWithBody("Checked entry", "A body"))
+	require.NoError(t, manager.RefreshReadme())
+	notionClient := func() (notionPropertyValidator, error) { return fake.notionClient(), nil }
+
+	report, err := manager.doctor(context.Background(), notionClient)
+	require.NoError(t, err)
+	assert.True(t, report.Healthy(), "%+v", report)
+	checks := doctorChecksByName(report)
+	assert.Equal(t, "no secrets are stored in the OS keychain", checks["OS keychain"].Skipped)
+	require.Len(t, checks["Configuration"].Findings, 1)
+	assert.True(t, checks["Configuration"].Findings[0].Warning, "a plain-text token is only a warning")
+	for _, name := range []string{"Database", "Staging", "Stored files", "README", "Git remote", "Notion database"} {
+		assert.Empty(t, checks[name].Findings, name)
+		assert.Empty(t, checks[name].Skipped, name)
+	}
+
+	entries, err := manager.GetLatestEntries(1)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(storedAttachmentPath(manager, entries[0], "notes.txt"), []byte("NOTES"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(manager.filesDir(), bodyFileName(entries[0]))))
+	require.NoError(t, os.WriteFile(filepath.Join(manager.filesDir(), "stray.txt"), []byte("stray"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(manager.repositoryDir(), readmeFileName), []byte("# Stale\n"), 0644))
+	require.NoError(t, os.Chmod(filepath.Join(root, metadataDirectoryName, "config"), 0644))
+	require.NoError(t, NewGitManager(manager.repositoryDir()).SetRemote(filepath.Join(root, "missing.git")))
+	delete(fake.schema, "Attachments")
+	manager.Config.NotionAPIKey = ""
+	manager.Config.NotionAPIKeyInKeyring = true
+	manager.Config.NotionAPIKeyLoadError = errors.New("read Notion API key from OS keychain: locked")
+
+	report, err = manager.doctor(context.Background(), notionClient)
+	require.NoError(t, err)
+	assert.False(t, report.Healthy())
+	checks = doctorChecksByName(report)
+	assertDoctorFinding(t, checks["Configuration"], "can be read by other users", "chmod 600")
+	assertDoctorFinding(t, checks["OS keychain"], "locked", "til config edit")
+	assertDoctorFinding(t, checks["Stored files"], bodyFileName(entries[0])+" is missing", "til rm")
+	assertDoctorFinding(t, checks["Stored files"], "does not match its recorded SHA-256 checksum", "Restore the file")
+	assertDoctorFinding(t, checks["Stored files"], "1 orphaned file(s)", "til gc --prune")
+	assertDoctorFinding(t, checks["README"], "README.md does not match", "til push --git")
+	assertDoctorFinding(t, checks["Git remote"], "origin points at", "til config edit")
+	assertDoctorFinding(t, checks["Git remote"], "reach Git remote", "network connection")
+	assertDoctorFinding(t, checks["Notion database"], `"Attachments"`, "NOTION_PROPERTY_")
+	assert.Equal(t, 8, report.Problems())
+}
+
+func TestDoctorChecksStagingDirectory(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	require.NoError(t, SaveConfig(manager.Config))
+	large, err := os.Create(filepath.Join(manager.stagingDir(), "large.bin"))
+	require.NoError(t, err)
+	require.NoError(t, large.Truncate(MaxFileSize+1))
+	require.NoError(t, large.Close())
+	require.NoError(t, os.Mkdir(filepath.Join(manager.stagingDir(), "nested"), 0755))
+	require.NoError(t, os.Symlink(filepath.Join(manager.stagingDir(), "large.bin"), filepath.Join(manager.stagingDir(), "nested", "link.bin")))
+
+	report, err := manager.Doctor(context.Background())
+	require.NoError(t, err)
+	checks := doctorChecksByName(report)
+	assertDoctorFinding(t, checks["Staging"], "large.bin is larger than", "til reset large.bin")
+	assertDoctorFinding(t, checks["Staging"], "link.bin is not a regular file", "Remove")
+	assert.Equal(t, 1, report.Problems(), "the symbolic link is only a warning")
+	assert.Equal(t, "Git sync is disabled", checks["Git remote"].Skipped)
+	assert.Equal(t, "Notion sync is disabled", checks["Notion database"].Skipped)
+}
+
+func doctorChecksByName(report DoctorReport) map[string]DoctorCheck {
+	checks := map[string]DoctorCheck{}
+	for _, check := range report.Checks {
+		checks[check.Name] = check
+	}
+	return checks
+}
+
+func assertDoctorFinding(t *testing.T, check DoctorCheck, problem, fix string) {
+	t.Helper()
+	for _, finding := range check.Findings {
+		if strings.Contains(finding.Problem, problem) {
+			assert.Contains(t, finding.Fix, fix, finding.Problem)
+			return
+		}
+	}
+	assert.Failf(t, "finding not reported", "%s: no finding containing %q in %+v", check.Name, problem, check.Findings)
+}
@@ -0,0 +1,146 @@
+package til
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const minCommitIDPrefixLength = 4
+
+var ErrEntryNotFound = errors.New("entry not found")
+
+// ResolveCommitID expands a full commit ID or a unique prefix of one, like git does.
+func (m *Manager) ResolveCommitID(commitID string) (string, error) {
+	if !m.IsInitialized() {
+		return "", ErrRepositoryNotInitialized
+	}
+
+	commitID = strings.TrimSpace(commitID)
+	if commitID == "" {
+		return "", errors.New("commit ID cannot be empty")
+	}
+	exists, err := m.commitIDExists(commitID)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return commitID, nil
+	}
+	if len(commitID) < minCommitIDPrefixLength {
+		return "", fmt.Errorf(
+			"%w: %s (prefixes need at least %d characters)",
+			ErrEntryNotFound,
+			commitID,
+			minCommitIDPrefixLength,
+		)
+	}
+
+	matches, err := m.commitIDsWithPrefix(commitID)
+	if err != nil {
+		return "", err
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", ErrEntryNotFound, commitID)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf(
+			"commit ID %s is ambiguous; candidates: %s",
+			commitID,
+			strings.Join(matches, ", "),
+		)
+	}
+}
+
+func (m *Manager) GetEntry(commitID string) (Entry, error) {
+	resolved, err := m.ResolveCommitID(commitID)
+	if err != nil {
+		return Entry{}, err
+	}
+	entries, err := m.QueryEntries(EntryQuery{CommitID: resolved, Limit: 1})
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(entries) == 0 {
+		return Entry{}, fmt.Errorf("%w: %s", ErrEntryNotFound, commitID)
+	}
+	return entries[0], nil
+}
+
+// EditEntry rewrites the title and body of any entry. Staged files are left for the next commit.
+func (m *Manager) EditEntry(commitID, message, messageBody string) error {
+	entry, err := m.GetEntry(commitID)
+	if err != nil {
+		return err
+	}
+	return m.amendEntry(entry, message, messageBody, nil, nil)
+}
+
+// RemoveEntry deletes an entry with its attachments, revisions, and stored files,
+// returning the removed entry.
+func (m *Manager) RemoveEntry(commitID string) (Entry, error) {
+	entry, err := m.GetEntry(commitID)
+	if err != nil {
+		return Entry{}, err
+	}
+	revisions, err := m.EntryRevisions(entry.CommitID)
+	if err != nil {
+		return Entry{}, err
+	}
+	if err := m.deleteEntry(entry.CommitID); err != nil {
+		return Entry{}, err
+	}
+
+	// Per-entry files belong to this entry alone; objects are removed once
+	// no other entry or revision records the same content.
+	storedFiles := []string{bodyFileName(entry)}
+	objects := []Attachment{}
+	for _, owner := range append([]Entry{entry}, revisionEntries(entry, revisions)...) {
+		for _, fileName := range owner.Files {
+			if attachment, ok := owner.attachment(fileName); ok && attachment.SHA256 != "" {
+				objects = append(objects, attachment)
+				continue
+			}
+			storedFiles = mergeFileNames(storedFiles, []string{storedAttachmentName(owner, fileName)})
+		}
+	}
+	unreferenced, err := m.unreferencedAttachmentObjects(objects)
+	if err != nil {
+		return entry, fmt.Errorf("entry removed, but stored files remain: %w", err)
+	}
+	storedFiles = append(storedFiles, unreferenced...)
+	var removeErrors []error
+	for _, fileName := range storedFiles {
+		path := filepath.Join(m.filesDir(), fileName)
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			removeErrors = append(removeErrors, err)
+		}
+	}
+
+	if m.Config.SyncToGit {
+		_ = m.RefreshReadme()
+	}
+	if len(removeErrors) > 0 {
+		return entry, fmt.Errorf("entry removed, but stored files remain: %w", errors.Join(removeErrors...))
+	}
+	return entry, nil
+}
+
+// revisionEntries returns the stored attachments of each revision as an
+// entry, so their files can be located with storedAttachmentName.
+func revisionEntries(entry Entry, revisions []EntryRevision) []Entry {
+	entries := make([]Entry, 0, len(revisions))
+	for _, revision := range revisions {
+		entries = append(entries, Entry{
+			Date:        entry.Date,
+			CommitID:    entry.CommitID,
+			Files:       revision.Files,
+			Attachments: revision.Attachments,
+		})
+	}
+	return entries
+}
@@ -0,0 +1,107 @@
+package til
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveCommitIDPrefixes(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	date := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, commitID := range []string{"abcd1111", "abcd2222", "ef012345"} {
+		require.NoError(t, manager.insertEntry(Entry{
+			Date:        date.Add(time.Duration(i) * time.Minute),
+			Message:     "Entry " + commitID,
+			IsCommitted: true,
+			CommitID:    commitID,
+		}))
+	}
+
+	resolved, err := manager.ResolveCommitID("abcd1111")
+	require.NoError(t, err)
+	assert.Equal(t, "abcd1111", resolved)
+
+	resolved, err = manager.ResolveCommitID("ef01")
+	require.NoError(t, err)
+	assert.Equal(t, "ef012345", resolved)
+
+	_, err = manager.ResolveCommitID("abcd")
+	assert.ErrorContains(t, err, "ambiguous")
+	assert.ErrorContains(t, err, "abcd1111")
+	assert.ErrorContains(t, err, "abcd2222")
+
+	_, err = manager.ResolveCommitID("ef0")
+	assert.ErrorIs(t, err, ErrEntryNotFound)
+	_, err = manager.ResolveCommitID("9999")
+	assert.ErrorIs(t, err, ErrEntryNotFound)
+}
+
+func TestEditEntryByCommitID(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntryWithTags("Old typo", "", []string{"go"}))
+	require.NoError(t, manager.CommitEntry("Newest"))
+
+	staged := filepath.Join(root, "staged.txt")
+	require.NoError(t, os.WriteFile(staged, []byte("staged"), 0644))
+	require.NoError(t, manager.AddFile(staged))
+
+	entries, err := manager.QueryEntries(EntryQuery{OldestFirst: true})
+	require.NoError(t, err)
+	older := entries[0]
+
+	require.NoError(t, manager.EditEntry(older.CommitID[:6], "Fixed typo", "Now with a body"))
+	edited, err := manager.GetEntry(older.CommitID)
+	require.NoError(t, err)
+	assert.Equal(t, "Fixed typo", edited.Message)
+	assert.Equal(t, "Now with a body", edited.MessageBody)
+	assert.Equal(t, []string{"go"}, edited.Tags)
+	assert.Empty(t, edited.Files)
+	assert.FileExists(t, filepath.Join(root, "til", "files", "body_"+older.CommitID+".md"))
+
+	stagedFiles, err := manager.GetStagedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"staged.txt"}, stagedFiles)
+}
+
+func TestRemoveEntryDeletesRowsAndFiles(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	attachment := filepath.Join(root, "notes.txt")
+	require.NoError(t, os.WriteFile(attachment, []byte("notes"), 0644))
+	require.NoError(t, manager.AddFile(attachment))
+	require.NoError(t, manager.CommitEntryWithTags("Removable", "Body", []string{"temporary"}))
+	require.NoError(t, manager.CommitEntry("Kept"))
+
+	entries, err := manager.QueryEntries(EntryQuery{Search: "Removable"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	removed := entries[0]
+	files := filepath.Join(root, "til", "files")
+	require.FileExists(t, storedAttachmentPath(manager, removed, "notes.txt"))
+
+	result, err := manager.RemoveEntry(removed.CommitID)
+	require.NoError(t, err)
+	assert.Equal(t, removed.CommitID, result.CommitID)
+	assert.NoFileExists(t, storedAttachmentPath(manager, removed, "notes.txt"))
+	assert.NoFileExists(t, filepath.Join(files, "body_"+removed.CommitID+".md"))
+
+	remaining, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, "Kept", remaining[0].Message)
+
+	tags, err := manager.ListTags()
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	report, err := manager.CheckDatabaseIntegrity()
+	require.NoError(t, err)
+	assert.True(t, report.Healthy())
+
+	_, err = manager.RemoveEntry(removed.CommitID)
+	assert.ErrorIs(t, err, ErrEntryNotFound)
+}
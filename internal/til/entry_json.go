@@ -0,0 +1,29 @@
+package til
+
+import "time"
+
+// EntryJSON is the JSON form of an entry written by 'til log --json', JSON
+// exports, and webhook deliveries.
+type EntryJSON struct {
+	CommitID     string   `json:"commit_id"`
+	Date         string   `json:"date"`
+	Message      string   `json:"message"`
+	MessageBody  string   `json:"message_body,omitempty"`
+	Files        []string `json:"files"`
+	Tags         []string `json:"tags"`
+	IsCommitted  bool     `json:"is_committed"`
+	NotionSynced bool     `json:"notion_synced"`
+}
+
+func NewEntryJSON(entry Entry) EntryJSON {
+	return EntryJSON{
+		CommitID:     entry.CommitID,
+		Date:         entry.Date.Format(time.RFC3339Nano),
+		Message:      entry.Message,
+		MessageBody:  entry.MessageBody,
+		Files:        append([]string{}, entry.Files...),
+		Tags:         append([]string{}, entry.Tags...),
+		IsCommitted:  entry.IsCommitted,
+		NotionSynced: entry.NotionSynced,
+	}
+}
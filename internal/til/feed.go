@@ -0,0 +1,294 @@
+package til
+
+import (
+	"crypto/rand"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultFeedEntries is the number of entries a feed includes by default.
+	DefaultFeedEntries = 20
+	feedFileName       = "feed.xml"
+	feedTitle          = "Today I Learned"
+	// feedIDPrefix starts every entry ID. The rest is the entry's commit ID,
+	// so an entry keeps its ID when it is edited or the feed moves.
+	feedIDPrefix = "urn:til:"
+	// feedIDMetadataKey stores the feed's own ID in repository_metadata.
+	feedIDMetadataKey = "feed_id"
+)
+
+type FeedOptions struct {
+	// Limit is the number of latest entries to include. Zero selects
+	// DefaultFeedEntries.
+	Limit int
+	// Author names the feed's author. It defaults to Git's user.name in the
+	// repository and then to the feed title.
+	Author string
+	// SelfURL is where the feed is published. It defaults to the raw
+	// feed.xml on the current branch when the Git remote is on GitHub.
+	SelfURL string
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Author    atomPerson  `xml:"author"`
+	Generator string      `xml:"generator"`
+	Links     []atomLink  `xml:"link"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel    string `xml:"rel,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Type   string `xml:"type,attr,omitempty"`
+	Title  string `xml:"title,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomText       `xml:"content"`
+}
+
+// RenderFeed returns an Atom feed of the latest entries, newest first. Each
+// entry's ID is urn:til: followed by its commit ID, and its body is rendered
+// as HTML. Attachments are linked as enclosures when the Git remote is on
+// GitHub, pointing at the raw file on the current branch.
+func (m *Manager) RenderFeed(options FeedOptions) ([]byte, error) {
+	if !m.IsInitialized() {
+		return nil, ErrRepositoryNotInitialized
+	}
+	limit := options.Limit
+	if limit <= 0 {
+		limit = DefaultFeedEntries
+	}
+	entries, err := m.GetLatestEntries(limit)
+	if err != nil {
+		return nil, err
+	}
+	updatedAt, err := m.entryUpdateTimes()
+	if err != nil {
+		return nil, err
+	}
+
+	author := options.Author
+	if author == "" {
+		author = NewGitManager(m.repositoryDir()).UserName()
+	}
+	if author == "" {
+		author = feedTitle
+	}
+	feedID, err := m.feedID()
+	if err != nil {
+		return nil, err
+	}
+	feed := atomFeed{
+		ID:        feedID,
+		Title:     feedTitle,
+		Updated:   feedTime(time.Unix(0, 0)),
+		Author:    atomPerson{Name: author},
+		Generator: "til",
+		Entries:   make([]atomEntry, 0, len(entries)),
+	}
+	branch := gitBranch(m.Config, m.repositoryDir())
+	if webURL, err := remoteWebURL(m.Config.GitRemoteURL); err == nil {
+		// The repository page identifies a published log across devices.
+		feed.ID = webURL
+		feed.Links = append(feed.Links, atomLink{Rel: "alternate", Href: webURL, Type: "text/html"})
+	}
+	selfURL := options.SelfURL
+	if selfURL == "" {
+		selfURL, _ = GitHubRawFileURL(m.Config.GitRemoteURL, branch, feedFileName)
+	}
+	if selfURL != "" {
+		feed.Links = append(feed.Links, atomLink{Rel: "self", Href: selfURL, Type: "application/atom+xml"})
+	}
+
+	var newest time.Time
+	for _, entry := range entries {
+		updated := entry.Date
+		if revised, ok := updatedAt[entry.CommitID]; ok && revised.After(updated) {
+			updated = revised
+		}
+		if updated.After(newest) {
+			newest = updated
+		}
+
+		content := "<p>" + html.EscapeString(entry.Message) + "</p>\n"
+		if strings.TrimSpace(entry.MessageBody) != "" {
+			content = string(markdownHTML(entry.MessageBody))
+		}
+		item := atomEntry{
+			ID:        feedIDPrefix + entry.CommitID,
+			Title:     entry.Message,
+			Published: feedTime(entry.Date),
+			Updated:   feedTime(updated),
+			Content:   atomText{Type: "html", Body: content},
+		}
+		if entry.MessageBody != "" {
+			if bodyURL, err := GitWebFileURL(
+				m.Config.GitRemoteURL,
+				branch,
+				filepath.Join(filesDirectoryName, bodyFileName(entry)),
+			); err == nil {
+				item.Links = append(item.Links, atomLink{Rel: "alternate", Href: bodyURL, Type: "text/html"})
+			}
+		}
+		for _, fileName := range entry.Files {
+			storedName := storedAttachmentName(entry, fileName)
+			rawURL, err := GitHubRawFileURL(
+				m.Config.GitRemoteURL,
+				branch,
+				filepath.Join(filesDirectoryName, storedName),
+			)
+			if err != nil {
+				continue
+			}
+			enclosure := atomLink{
+				Rel:   "enclosure",
+				Href:  rawURL,
+				Type:  feedMediaType(fileName),
+				Title: fileName,
+			}
+			if info, err := os.Stat(filepath.Join(m.filesDir(), storedName)); err == nil {
+				enclosure.Length = info.Size()
+			}
+			item.Links = append(item.Links, enclosure)
+		}
+		for _, tag := range entry.Tags {
+			item.Categories = append(item.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, item)
+	}
+	if !newest.IsZero() {
+		feed.Updated = feedTime(newest)
+	}
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode feed: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// RefreshFeed writes feed.xml next to README.md.
+func (m *Manager) RefreshFeed(options FeedOptions) error {
+	content, err := m.RenderFeed(options)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(m.feedPath(), content, 0644); err != nil {
+		return fmt.Errorf("refresh feed: %w", err)
+	}
+	return nil
+}
+
+// feedID is the ID generated for the repository's feed when its database
+// was created or upgraded. It is stored in the database, so every device
+// publishing the repository uses the same one.
+func (m *Manager) feedID() (string, error) {
+	db, err := m.openDatabase()
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	var feedID string
+	if err := db.QueryRow("SELECT value FROM repository_metadata WHERE key = ?", feedIDMetadataKey).Scan(&feedID); err != nil {
+		return "", fmt.Errorf("read feed ID: %w", err)
+	}
+	return feedID, nil
+}
+
+// newFeedID returns a random version 4 UUID URN.
+func newFeedID() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("generate feed ID: %w", err)
+	}
+	random[6] = random[6]&0x0f | 0x40
+	random[8] = random[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", random[0:4], random[4:6], random[6:8], random[8:10], random[10:]), nil
+}
+
+func (m *Manager) feedPath() string {
+	return filepath.Join(m.repositoryDir(), feedFileName)
+}
+
+// entryUpdateTimes returns when each revised entry last changed, keyed by
+// commit ID. Entries that were never edited are absent.
+func (m *Manager) entryUpdateTimes() (map[string]time.Time, error) {
+	db, err := m.openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(
+		`SELECT e.commit_id, r.recorded_at
+         FROM entry_revisions r
+         JOIN entries e ON e.id = r.entry_id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query entry revisions: %w", err)
+	}
+	defer rows.Close()
+
+	updated := map[string]time.Time{}
+	for rows.Next() {
+		var commitID, recordedAt string
+		if err := rows.Scan(&commitID, &recordedAt); err != nil {
+			return nil, fmt.Errorf("scan entry revision: %w", err)
+		}
+		timestamp, err := time.Parse(time.RFC3339Nano, recordedAt)
+		if err != nil {
+			return nil, fmt.Errorf("parse revision timestamp: %w", err)
+		}
+		if timestamp.After(updated[commitID]) {
+			updated[commitID] = timestamp
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate entry revisions: %w", err)
+	}
+	return updated, nil
+}
+
+func feedTime(value time.Time) string {
+	return value.UTC().Format(time.RFC3339)
+}
+
+func feedMediaType(fileName string) string {
+	if mediaType := mime.TypeByExtension(strings.ToLower(filepath.Ext(fileName))); mediaType != "" {
+		return mediaType
+	}
+	return "application/octet-stream"
+}
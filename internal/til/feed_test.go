@@ -0,0 +1,140 @@
+package til
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderFeed(t *testing.T) {
+	manager, _ := newTestManager(t, Config{GitRemoteURL: "git@github.com:example/learning.git"})
+	entries := []Entry{
+		{
+			Date:        time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC),
+			Message:     "Go <generics>",
+			MessageBody: "Use **any** & comparable.",
+			Files:       []string{"diagram.png"},
+			Tags:        []string{"go"},
+			IsCommitted: true,
+			CommitID:    "go000001",
+		},
+		{
+			Date:        time.Date(2025, 4, 2, 9, 0, 0, 0, time.UTC),
+			Message:     "SQLite WAL",
+			IsCommitted: true,
+			CommitID:    "wal00002",
+		},
+	}
+	for _, entry := range entries {
+		require.NoError(t, manager.insertEntry(entry))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(manager.filesDir(), "go000001_diagram.png"), []byte("png"), 0644))
+
+	data, err := manager.RenderFeed(FeedOptions{Author: "Ada"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<feed xmlns="http://www.w3.org/2005/Atom">`)
+
+	var feed atomFeed
+	require.NoError(t, xml.Unmarshal(data, &feed))
+	assert.Equal(t, "https://github.com/example/learning", feed.ID)
+	assert.Equal(t, "Ada", feed.Author.Name)
+	assert.Equal(t, "2025-04-02T09:00:00Z", feed.Updated)
+	assert.Contains(t, feed.Links, atomLink{
+		Rel:  "self",
+		Href: "https://raw.githubusercontent.com/example/learning/main/feed.xml",
+		Type: "application/atom+xml",
+	})
+	require.Len(t, feed.Entries, 2)
+
+	assert.Equal(t, "urn:til:wal00002", feed.Entries[0].ID)
+	assert.Equal(t, "<p>SQLite WAL</p>\n", feed.Entries[0].Content.Body)
+
+	generics := feed.Entries[1]
+	assert.Equal(t, "urn:til:go000001", generics.ID)
+	assert.Equal(t, "Go <generics>", generics.Title)
+	assert.Equal(t, "html", generics.Content.Type)
+	assert.Equal(t, "<p>Use <strong>any</strong> &amp; comparable.</p>\n", generics.Content.Body)
+	assert.Equal(t, []atomCategory{{Term: "go"}}, generics.Categories)
+	assert.Equal(t, []atomLink{
+		{
+			Rel:  "alternate",
+			Href: "https://github.com/example/learning/blob/main/files/body_go000001.md",
+			Type: "text/html",
+		},
+		{
+			Rel:    "enclosure",
+			Href:   "https://raw.githubusercontent.com/example/learning/main/files/go000001_diagram.png",
+			Type:   "image/png",
+			Title:  "diagram.png",
+			Length: 3,
+		},
+	}, generics.Links)
+
+	// Editing an entry moves its updated time but keeps its ID.
+	require.NoError(t, manager.EditEntry("go000001", "Go generics", ""))
+	data, err = manager.RenderFeed(FeedOptions{Author: "Ada", Limit: 1})
+	require.NoError(t, err)
+	feed = atomFeed{}
+	require.NoError(t, xml.Unmarshal(data, &feed))
+	require.Len(t, feed.Entries, 1)
+	assert.Equal(t, "urn:til:wal00002", feed.Entries[0].ID)
+
+	data, err = manager.RenderFeed(FeedOptions{Author: "Ada"})
+	require.NoError(t, err)
+	feed = atomFeed{}
+	require.NoError(t, xml.Unmarshal(data, &feed))
+	updated, err := time.Parse(time.RFC3339, feed.Entries[1].Updated)
+	require.NoError(t, err)
+	assert.Equal(t, "urn:til:go000001", feed.Entries[1].ID)
+	assert.Equal(t, "2025-03-30T09:00:00Z", feed.Entries[1].Published)
+	assert.WithinDuration(t, time.Now(), updated, time.Minute)
+	assert.Equal(t, feed.Entries[1].Updated, feed.Updated)
+}
+
+func TestRenderFeedWithoutGitHubRemote(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	data, err := manager.RenderFeed(FeedOptions{Author: "Ada", SelfURL: "https://example.com/til/feed.xml"})
+	require.NoError(t, err)
+	var feed atomFeed
+	require.NoError(t, xml.Unmarshal(data, &feed))
+	feedID := feed.ID
+	assert.Regexp(t, `^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, feedID)
+	assert.Equal(t, []atomLink{{Rel: "self", Href: "https://example.com/til/feed.xml", Type: "application/atom+xml"}}, feed.Links)
+	assert.Empty(t, feed.Entries)
+
+	require.NoError(t, manager.insertEntry(Entry{
+		Date:        time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC),
+		Message:     "Local learning",
+		Files:       []string{"notes.txt"},
+		IsCommitted: true,
+		CommitID:    "loc00001",
+	}))
+	data, err = manager.RenderFeed(FeedOptions{Author: "Ada"})
+	require.NoError(t, err)
+	feed = atomFeed{}
+	require.NoError(t, xml.Unmarshal(data, &feed))
+	assert.Equal(t, feedID, feed.ID, "adding entries keeps the feed ID")
+	assert.Empty(t, feed.Links)
+	require.Len(t, feed.Entries, 1)
+	assert.Empty(t, feed.Entries[0].Links)
+
+	_, err = manager.RemoveEntry("loc00001")
+	require.NoError(t, err)
+	data, err = manager.RenderFeed(FeedOptions{Author: "Ada"})
+	require.NoError(t, err)
+	feed = atomFeed{}
+	require.NoError(t, xml.Unmarshal(data, &feed))
+	assert.Equal(t, feedID, feed.ID, "removing the oldest entry keeps the feed ID")
+
+	other, _ := newTestManager(t, Config{})
+	data, err = other.RenderFeed(FeedOptions{Author: "Ada"})
+	require.NoError(t, err)
+	feed = atomFeed{}
+	require.NoError(t, xml.Unmarshal(data, &feed))
+	assert.NotEqual(t, feedID, feed.ID, "each repository has its own feed ID")
+}
@@ -1,11 +1,15 @@
 package til
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -13,11 +17,41 @@ import (
 
 const MaxFileSize int64 = 10 * 1024 * 1024
 
+// ErrFileAlreadyStaged is returned when adding a file would replace a
+// different staged file with the same name.
+var ErrFileAlreadyStaged = errors.New("a different file with this name is already staged")
+
+// AddOptions changes how files are staged.
+type AddOptions struct {
+	// Force replaces a staged file with the same name and different content.
+	Force bool
+	// Bundle stages each directory as a single "tar" (gzip-compressed) or
+	// "zip" archive instead of staging the files inside it.
+	Bundle string
+	// Hidden includes directories whose names start with a dot, such as
+	// .git, when adding a directory.
+	Hidden bool
+}
+
 func (m *Manager) AddFile(filePath string) error {
+	return m.AddFileWithOptions(filePath, AddOptions{})
+}
+
+// AddFileWithOptions copies a file into the staging directory under the name
+// stagedFileName gives it. Adding a file identical to the one already staged
+// under that name is a no-op; replacing different content requires
+// options.Force.
+func (m *Manager) AddFileWithOptions(filePath string, options AddOptions) error {
 	if !m.IsInitialized() {
 		return ErrRepositoryNotInitialized
 	}
+	return m.stageFile(filePath, stagedFileName(filePath), options)
+}
 
+func (m *Manager) stageFile(filePath, name string, options AddOptions) error {
+	if err := validateStagedName(name); err != nil {
+		return err
+	}
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
 		return fmt.Errorf("file not found: %w", err)
@@ -38,34 +72,122 @@ func (m *Manager) AddFile(filePath string) error {
 		return fmt.Errorf("create staging directory: %w", err)
 	}
 
-	targetPath := filepath.Join(m.stagingDir(), filepath.Base(filePath))
+	targetPath := filepath.Join(m.stagingDir(), filepath.FromSlash(name))
+	if !options.Force {
+		same, err := sameFileContent(filePath, targetPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("compare with staged %s: %w", name, err)
+		}
+		if err == nil && same {
+			return nil
+		}
+		if err == nil {
+			return fmt.Errorf("%w: %s; use --force to replace it", ErrFileAlreadyStaged, name)
+		}
+	}
 	if err := copyFile(filePath, targetPath); err != nil {
 		return fmt.Errorf("stage %s: %w", filePath, err)
 	}
 	return nil
 }
 
+// stagedFileName is the name a file is staged and attached under: its path
+// as given when that is relative and stays below the working directory, so
+// a/main.go and b/main.go do not collide, and its base name otherwise.
+func stagedFileName(filePath string) string {
+	if filepath.IsLocal(filePath) {
+		return filepath.ToSlash(filepath.Clean(filePath))
+	}
+	return filepath.Base(filePath)
+}
+
+func validateStagedName(name string) error {
+	if name == "" || name == "." || !filepath.IsLocal(filepath.FromSlash(name)) || strings.Contains(name, "\\") {
+		return fmt.Errorf("invalid file name %q: use a relative path such as notes/example.go", name)
+	}
+	if isTemporaryFileName(path.Base(name)) {
+		return fmt.Errorf("invalid file name %q: the name is reserved for temporary files", name)
+	}
+	return nil
+}
+
+// GetStagedFiles returns the names of the staged files, sorted, with
+// forward slashes separating directories.
 func (m *Manager) GetStagedFiles() ([]string, error) {
 	if !m.IsInitialized() {
 		return nil, ErrRepositoryNotInitialized
 	}
 
-	entries, err := os.ReadDir(m.stagingDir())
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return []string{}, nil
+	files := []string{}
+	err := filepath.WalkDir(m.stagingDir(), func(filePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if errors.Is(walkErr, fs.ErrNotExist) && filePath == m.stagingDir() {
+				return nil
+			}
+			return walkErr
+		}
+		if !entry.Type().IsRegular() || isTemporaryFileName(entry.Name()) {
+			return nil
+		}
+		relative, err := filepath.Rel(m.stagingDir(), filePath)
+		if err != nil {
+			return err
 		}
+		files = append(files, filepath.ToSlash(relative))
+		return nil
+	})
+	if err != nil {
 		return nil, fmt.Errorf("read staging directory: %w", err)
 	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// UnstageFiles removes the named files from the staging directory, or every
+// staged file when no names are given, and returns the names it removed.
+// Names may be the paths files were added from, and a directory unstages
+// everything staged below it. Nothing is removed if any name is not staged.
+func (m *Manager) UnstageFiles(names []string) ([]string, error) {
+	staged, err := m.GetStagedFiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		if err := m.ClearStagedFiles(); err != nil {
+			return nil, err
+		}
+		return staged, nil
+	}
 
-	files := make([]string, 0, len(entries))
-	for _, entry := range entries {
-		if entry.Type().IsRegular() {
-			files = append(files, entry.Name())
+	removed := make([]string, 0, len(names))
+	var missing []string
+	for _, name := range names {
+		name = stagedFileName(name)
+		matched := false
+		for _, stagedName := range staged {
+			if stagedName != name && !strings.HasPrefix(stagedName, name+"/") {
+				continue
+			}
+			matched = true
+			if !slices.Contains(removed, stagedName) {
+				removed = append(removed, stagedName)
+			}
+		}
+		if !matched {
+			missing = append(missing, name)
 		}
 	}
-	sort.Strings(files)
-	return files, nil
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("not staged: %s", strings.Join(missing, ", "))
+	}
+	for _, name := range removed {
+		if err := os.Remove(filepath.Join(m.stagingDir(), filepath.FromSlash(name))); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("unstage %s: %w", name, err)
+		}
+	}
+	removeEmptyDirectories(m.stagingDir())
+	sort.Strings(removed)
+	return removed, nil
 }
 
 func (m *Manager) ClearStagedFiles() error {
@@ -87,6 +209,10 @@ func (m *Manager) CommitEntry(message string) error {
 }
 
 func (m *Manager) CommitEntryWithBody(message, messageBody string) error {
+	return m.CommitEntryWithTags(message, messageBody, nil)
+}
+
+func (m *Manager) CommitEntryWithTags(message, messageBody string, tags []string) error {
 	if !m.IsInitialized() {
 		return ErrRepositoryNotInitialized
 	}
@@ -95,24 +221,19 @@ func (m *Manager) CommitEntryWithBody(message, messageBody string) error {
 	if err != nil {
 		return err
 	}
+	tags, err = normalizeTags(tags)
+	if err != nil {
+		return err
+	}
 
 	stagedFiles, err := m.GetStagedFiles()
 	if err != nil {
 		return err
 	}
 
-	now := time.Now()
-	commitID := GenerateCommitID(message, now)
-	for {
-		exists, err := m.commitIDExists(commitID)
-		if err != nil {
-			return err
-		}
-		if !exists {
-			break
-		}
-		now = now.Add(time.Nanosecond)
-		commitID = GenerateCommitID(message, now)
+	now, commitID, err := m.availableCommitID(message, time.Now())
+	if err != nil {
+		return err
 	}
 
 	entry := Entry{
@@ -120,13 +241,17 @@ func (m *Manager) CommitEntryWithBody(message, messageBody string) error {
 		Message:      message,
 		MessageBody:  messageBody,
 		Files:        append([]string(nil), stagedFiles...),
+		Tags:         tags,
 		IsCommitted:  true,
 		NotionSynced: false,
 		CommitID:     commitID,
 	}
 
-	createdPaths, err := m.storeStagedFiles(entry, stagedFiles)
+	entry, createdPaths, err := m.storeStagedFiles(entry, stagedFiles)
 	if err != nil {
+		for _, path := range createdPaths {
+			_ = os.Remove(path)
+		}
 		return err
 	}
 	cleanupCreated := func() {
@@ -159,6 +284,23 @@ func (m *Manager) CommitEntryWithBody(message, messageBody string) error {
 	return nil
 }
 
+// availableCommitID generates a commit ID for a new entry, nudging the timestamp
+// forward until the ID is unused.
+func (m *Manager) availableCommitID(message string, date time.Time) (time.Time, string, error) {
+	commitID := GenerateCommitID(message, date)
+	for {
+		exists, err := m.commitIDExists(commitID)
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		if !exists {
+			return date, commitID, nil
+		}
+		date = date.Add(time.Nanosecond)
+		commitID = GenerateCommitID(message, date)
+	}
+}
+
 func (m *Manager) AmendLastEntry(message string) error {
 	entries, err := m.GetLatestEntries(1)
 	if err != nil {
@@ -171,26 +313,50 @@ func (m *Manager) AmendLastEntry(message string) error {
 }
 
 func (m *Manager) AmendLastEntryWithBody(message, messageBody string) error {
+	return m.AmendLastEntryWithTags(message, messageBody, nil)
+}
+
+// AmendLastEntryWithTags rewrites the latest entry and adds tags to the ones it already has.
+func (m *Manager) AmendLastEntryWithTags(message, messageBody string, tags []string) error {
 	if !m.IsInitialized() {
 		return ErrRepositoryNotInitialized
 	}
 
-	message, messageBody, err := normalizeCommitMessage(message, messageBody)
+	entries, err := m.QueryEntries(EntryQuery{Limit: 1})
 	if err != nil {
 		return err
 	}
+	if len(entries) == 0 {
+		return errors.New("no entries found to amend")
+	}
 
-	entries, err := m.QueryEntries(EntryQuery{Limit: 1})
+	stagedFiles, err := m.GetStagedFiles()
 	if err != nil {
 		return err
 	}
-	if len(entries) == 0 {
-		return errors.New("no entries found to amend")
+
+	if err := m.amendEntry(entries[0], message, messageBody, tags, stagedFiles); err != nil {
+		return err
 	}
 
-	current := entries[0]
+	if err := m.ClearStagedFiles(); err != nil {
+		return fmt.Errorf("entry amended, but staging cleanup failed: %w", err)
+	}
+	return nil
+}
 
-	stagedFiles, err := m.GetStagedFiles()
+func (m *Manager) amendEntry(
+	current Entry,
+	message string,
+	messageBody string,
+	tags []string,
+	stagedFiles []string,
+) error {
+	message, messageBody, err := normalizeCommitMessage(message, messageBody)
+	if err != nil {
+		return err
+	}
+	tags, err = normalizeTags(tags)
 	if err != nil {
 		return err
 	}
@@ -199,36 +365,56 @@ func (m *Manager) AmendLastEntryWithBody(message, messageBody string) error {
 	updated.Message = message
 	updated.MessageBody = messageBody
 	updated.Files = mergeFileNames(current.Files, stagedFiles)
+	updated.Tags, err = normalizeTags(mergeFileNames(current.Tags, tags))
+	if err != nil {
+		return err
+	}
 	if current.Message != updated.Message ||
 		current.MessageBody != updated.MessageBody ||
-		len(stagedFiles) > 0 {
+		len(stagedFiles) > 0 ||
+		!slices.Equal(current.Tags, updated.Tags) {
 		updated.NotionSynced = false
 	}
 
-	if _, err := m.storeStagedFiles(updated, stagedFiles); err != nil {
+	updated, createdPaths, err := m.storeStagedFiles(updated, stagedFiles)
+	cleanupCreated := func() {
+		for _, path := range createdPaths {
+			_ = os.Remove(path)
+		}
+	}
+	if err != nil {
+		cleanupCreated()
 		return err
 	}
 
 	bodyPath := filepath.Join(m.filesDir(), bodyFileName(updated))
+	previousBody, readErr := os.ReadFile(bodyPath)
+	restoreBody := func() {
+		if readErr == nil {
+			_ = writeFileAtomic(bodyPath, previousBody, 0644)
+		} else {
+			_ = os.Remove(bodyPath)
+		}
+	}
 	if messageBody == "" {
 		if err := os.Remove(bodyPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			cleanupCreated()
 			return fmt.Errorf("remove commit body: %w", err)
 		}
 	} else if err := writeFileAtomic(bodyPath, []byte(messageBody), 0644); err != nil {
+		cleanupCreated()
 		return fmt.Errorf("save commit body: %w", err)
 	}
 
 	if err := m.updateEntry(updated); err != nil {
+		cleanupCreated()
+		restoreBody()
 		return err
 	}
 
 	if m.Config.SyncToGit {
 		_ = m.RefreshReadme()
 	}
-
-	if err := m.ClearStagedFiles(); err != nil {
-		return fmt.Errorf("entry amended, but staging cleanup failed: %w", err)
-	}
 	return nil
 }
 
@@ -240,24 +426,28 @@ func (m *Manager) GetLatestEntries(limit int) ([]Entry, error) {
 	return m.QueryEntries(EntryQuery{Limit: limit})
 }
 
-func (m *Manager) storeStagedFiles(entry Entry, files []string) ([]string, error) {
+// storeStagedFiles adds staged files to the object store and records their
+// content on the entry. It returns the objects it created, which a failed
+// commit can remove without touching content other entries share.
+func (m *Manager) storeStagedFiles(entry Entry, files []string) (Entry, []string, error) {
 	if len(files) == 0 {
-		return nil, nil
-	}
-	if err := os.MkdirAll(m.filesDir(), 0755); err != nil {
-		return nil, fmt.Errorf("create files directory: %w", err)
+		return entry, nil, nil
 	}
 
-	storedPaths := make([]string, 0, len(files))
+	createdPaths := make([]string, 0, len(files))
 	for _, fileName := range files {
-		sourcePath := filepath.Join(m.stagingDir(), filepath.Base(fileName))
-		targetPath := filepath.Join(m.filesDir(), storedAttachmentName(entry, fileName))
-		if err := copyFile(sourcePath, targetPath); err != nil {
-			return storedPaths, fmt.Errorf("store attachment %s: %w", fileName, err)
+		sourcePath := filepath.Join(m.stagingDir(), filepath.FromSlash(fileName))
+		attachment, createdPath, err := m.storeAttachmentObject(sourcePath, fileName)
+		if err != nil {
+			return entry, createdPaths, fmt.Errorf("store attachment %s: %w", fileName, err)
+		}
+		attachment.Name = fileName
+		entry.Attachments = withAttachment(entry.Attachments, attachment)
+		if createdPath != "" {
+			createdPaths = append(createdPaths, createdPath)
 		}
-		storedPaths = append(storedPaths, targetPath)
 	}
-	return storedPaths, nil
+	return entry, createdPaths, nil
 }
 
 func normalizeCommitMessage(message, body string) (string, string, error) {
@@ -288,6 +478,34 @@ func mergeFileNames(existing, staged []string) []string {
 	return merged
 }
 
+// sameFileContent reports whether two files have the same content. It
+// returns an error wrapping os.ErrNotExist when either is missing.
+func sameFileContent(first, second string) (bool, error) {
+	firstInfo, err := os.Stat(first)
+	if err != nil {
+		return false, err
+	}
+	secondInfo, err := os.Stat(second)
+	if err != nil {
+		return false, err
+	}
+	if os.SameFile(firstInfo, secondInfo) {
+		return true, nil
+	}
+	if firstInfo.Size() != secondInfo.Size() {
+		return false, nil
+	}
+	firstContent, err := os.ReadFile(first)
+	if err != nil {
+		return false, err
+	}
+	secondContent, err := os.ReadFile(second)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(firstContent, secondContent), nil
+}
+
 func copyFile(src, dst string) (retErr error) {
 	source, err := os.Open(src)
 	if err != nil {
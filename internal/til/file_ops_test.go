@@ -1,10 +1,13 @@
 package til
 
 import (
+	"database/sql"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,7 +29,7 @@ func TestCommitAndAmendWorkflow(t *testing.T) {
 	assert.Equal(t, []string{"daily note.txt"}, entry.Files)
 	assert.Equal(t, "First paragraph.\n\nSecond paragraph.", entry.MessageBody)
 
-	attachmentPath := filepath.Join(root, "til", "files", entry.CommitID+"_daily note.txt")
+	attachmentPath := storedAttachmentPath(manager, entry, "daily note.txt")
 	bodyPath := filepath.Join(root, "til", "files", "body_"+entry.CommitID+".md")
 	assert.FileExists(t, attachmentPath)
 	assert.FileExists(t, bodyPath)
@@ -50,10 +53,11 @@ func TestCommitAndAmendWorkflow(t *testing.T) {
 	assert.Equal(t, []string{"daily note.txt", "example.go"}, amended.Files)
 	assert.False(t, amended.NotionSynced)
 
-	updatedAttachment, err := os.ReadFile(attachmentPath)
+	updatedAttachment, err := os.ReadFile(storedAttachmentPath(manager, amended, "daily note.txt"))
 	require.NoError(t, err)
 	assert.Equal(t, "version two", string(updatedAttachment))
-	assert.FileExists(t, filepath.Join(root, "til", "files", entry.CommitID+"_example.go"))
+	assert.FileExists(t, attachmentPath, "the replaced version is kept for the entry's revision")
+	assert.FileExists(t, storedAttachmentPath(manager, amended, "example.go"))
 
 	staged, err := manager.GetStagedFiles()
 	require.NoError(t, err)
@@ -63,7 +67,7 @@ func TestCommitAndAmendWorkflow(t *testing.T) {
 	require.NoError(t, err)
 	readmeText := string(readme)
 	assert.Contains(t, readmeText, "(files/body_"+entry.CommitID+".md)")
-	assert.Contains(t, readmeText, "(files/"+entry.CommitID+"_daily%20note.txt)")
+	assert.Contains(t, readmeText, "[daily note.txt](files/"+storedAttachmentName(amended, "daily note.txt")+")")
 	assert.NotContains(t, readmeText, "til/files/")
 }
 
@@ -83,8 +87,9 @@ func TestMultipleSameDayCommitsHaveDistinctAssets(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, entries, 2)
 	assert.NotEqual(t, entries[0].CommitID, entries[1].CommitID)
-	assert.FileExists(t, filepath.Join(root, "til", "files", entries[0].CommitID+"_example.txt"))
-	assert.FileExists(t, filepath.Join(root, "til", "files", entries[1].CommitID+"_example.txt"))
+	assert.NotEqual(t, entries[0].Attachments[0].SHA256, entries[1].Attachments[0].SHA256)
+	assert.FileExists(t, storedAttachmentPath(manager, entries[0], "example.txt"))
+	assert.FileExists(t, storedAttachmentPath(manager, entries[1], "example.txt"))
 }
 
 func TestCommitValidationDoesNotMutateRepository(t *testing.T) {
@@ -99,6 +104,38 @@ func TestCommitValidationDoesNotMutateRepository(t *testing.T) {
 	assert.Empty(t, entries)
 }
 
+func TestFailedAmendRemovesStoredFiles(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntryWithBody("Entry", "First body"))
+	source := filepath.Join(root, "notes.txt")
+	require.NoError(t, os.WriteFile(source, []byte("notes"), 0644))
+	require.NoError(t, manager.AddFile(source))
+
+	database, err := sql.Open("sqlite", manager.DatabasePath())
+	require.NoError(t, err)
+	_, err = database.Exec(`CREATE TRIGGER refuse_amend BEFORE UPDATE ON entries BEGIN SELECT RAISE(ABORT, 'amend refused'); END;`)
+	require.NoError(t, err)
+	require.NoError(t, database.Close())
+
+	assert.ErrorContains(t, manager.AmendLastEntryWithBody("Entry, amended", "Second body"), "amend refused")
+	entries, err := manager.GetLatestEntries(1)
+	require.NoError(t, err)
+	body, err := os.ReadFile(filepath.Join(manager.filesDir(), bodyFileName(entries[0])))
+	require.NoError(t, err)
+	assert.Equal(t, "First body", string(body))
+	objects := []string{}
+	require.NoError(t, filepath.WalkDir(manager.filesDir(), func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.Type().IsRegular() && strings.HasSuffix(path, ".txt") {
+			objects = append(objects, path)
+		}
+		return err
+	}))
+	assert.Empty(t, objects, "the attachment stored for the amend is removed")
+	staged, err := manager.GetStagedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes.txt"}, staged)
+}
+
 func TestAddFileValidation(t *testing.T) {
 	manager, root := newTestManager(t, Config{})
 
@@ -113,6 +150,49 @@ func TestAddFileValidation(t *testing.T) {
 	assert.ErrorContains(t, manager.AddFile(largeFile), "file too large")
 }
 
+func TestAddFileRefusesToReplaceStagedFileAndUnstage(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	for _, name := range []string{"first/notes.txt", "second/notes.txt", "diagram.png"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(name), 0644))
+	}
+	require.NoError(t, manager.AddFile(filepath.Join(root, "first", "notes.txt")))
+	require.NoError(t, manager.AddFile(filepath.Join(root, "first", "notes.txt")), "re-adding the same content is a no-op")
+
+	err := manager.AddFile(filepath.Join(root, "second", "notes.txt"))
+	assert.ErrorIs(t, err, ErrFileAlreadyStaged)
+	content, err := os.ReadFile(filepath.Join(manager.stagingDir(), "notes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "first/notes.txt", string(content))
+
+	require.NoError(t, manager.AddFileWithOptions(filepath.Join(root, "second", "notes.txt"), AddOptions{Force: true}))
+	content, err = os.ReadFile(filepath.Join(manager.stagingDir(), "notes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "second/notes.txt", string(content))
+
+	require.NoError(t, manager.AddFile(filepath.Join(root, "diagram.png")))
+	_, err = manager.UnstageFiles([]string{"notes.txt", "missing.txt"})
+	assert.ErrorContains(t, err, "not staged: missing.txt")
+	staged, err := manager.GetStagedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"diagram.png", "notes.txt"}, staged, "nothing is unstaged when a name is unknown")
+
+	removed, err := manager.UnstageFiles([]string{filepath.Join(root, "second", "notes.txt")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes.txt"}, removed)
+	staged, err = manager.GetStagedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"diagram.png"}, staged)
+
+	removed, err = manager.UnstageFiles(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"diagram.png"}, removed)
+	staged, err = manager.GetStagedFiles()
+	require.NoError(t, err)
+	assert.Empty(t, staged)
+}
+
 func TestMigrateMarkdownRepository(t *testing.T) {
 	root := t.TempDir()
 	filesDir := filepath.Join(root, "til", "files")
@@ -151,7 +231,7 @@ Second entry
 	assert.Equal(t, "First entry", first.Message)
 	assert.Equal(t, "Legacy body", strings.TrimSpace(first.MessageBody))
 	assert.True(t, first.NotionSynced)
-	assert.FileExists(t, filepath.Join(filesDir, first.CommitID+"_example.txt"))
+	assert.FileExists(t, storedAttachmentPath(manager, first, "example.txt"))
 	assert.FileExists(t, filepath.Join(filesDir, "body_"+first.CommitID+".md"))
 	assert.NoFileExists(t, filepath.Join(filesDir, "2024-06-01_example.txt"))
 	assert.ErrorContains(t, manager.MigrateToSQL(), "already uses SQLite")
@@ -168,3 +248,25 @@ kept
 	require.Len(t, entries, 1)
 	assert.Equal(t, "kept", entries[0].Message)
 }
+
+func TestReadmeDiffPreviewsRefreshWithoutWriting(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntry("First entry"))
+	require.NoError(t, manager.RefreshReadme())
+	diff, err := manager.ReadmeDiff()
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	require.NoError(t, manager.CommitEntry("Second | entry"))
+	readmePath := filepath.Join(root, "til", "README.md")
+	before, err := os.ReadFile(readmePath)
+	require.NoError(t, err)
+
+	diff, err = manager.ReadmeDiff()
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- a/README.md\n+++ b/README.md\n")
+	assert.Contains(t, diff, `+| `+time.Now().Format("2006-01-02")+` | Second \| entry |  |`)
+	after, err := os.ReadFile(readmePath)
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+}
@@ -0,0 +1,272 @@
+package til
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// GCOptions selects what CollectGarbage does with orphaned files.
+type GCOptions struct {
+	// Prune deletes orphaned files. With QuarantineDir set, they are moved
+	// there instead, keeping their paths relative to the data directory.
+	Prune         bool
+	QuarantineDir string
+}
+
+// GCMissingFile is a body or attachment the database refers to that is not
+// stored. Revision is zero for an entry's current files.
+type GCMissingFile struct {
+	CommitID string
+	Revision int
+	// Name is the attachment's file name, or empty for the entry's body.
+	Name string
+	Path string
+}
+
+// GCOrphan is a stored file that no entry or revision refers to. Path is
+// relative to the data directory, such as til/files/body_1a2b3c4d.md.
+type GCOrphan struct {
+	Path string
+	Size int64
+}
+
+// GCReport lists what CollectGarbage found and, when pruning, removed.
+type GCReport struct {
+	Referenced    int
+	Staged        int
+	Missing       []GCMissingFile
+	Orphans       []GCOrphan
+	Pruned        bool
+	QuarantineDir string
+}
+
+// OrphanedBytes is the total size of the orphaned files.
+func (report GCReport) OrphanedBytes() int64 {
+	var total int64
+	for _, orphan := range report.Orphans {
+		total += orphan.Size
+	}
+	return total
+}
+
+// CollectGarbage cross-references the bodies and attachments recorded in
+// the database with the files under til/files and the leftovers of
+// interrupted copies in .til/staging. Orphans are only removed with
+// options.Prune.
+func (m *Manager) CollectGarbage(options GCOptions) (GCReport, error) {
+	if !m.IsInitialized() {
+		return GCReport{}, ErrRepositoryNotInitialized
+	}
+	report := GCReport{Missing: []GCMissingFile{}, Orphans: []GCOrphan{}}
+	quarantineDir := ""
+	if options.QuarantineDir != "" {
+		if !options.Prune {
+			return GCReport{}, errors.New("a quarantine directory requires pruning")
+		}
+		var err error
+		quarantineDir, err = m.gcQuarantineDir(options.QuarantineDir)
+		if err != nil {
+			return GCReport{}, err
+		}
+	}
+
+	referenced, err := m.gcReferencedFiles(&report)
+	if err != nil {
+		return GCReport{}, err
+	}
+	report.Referenced = len(referenced)
+
+	err = filepath.WalkDir(m.filesDir(), func(filePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if errors.Is(walkErr, fs.ErrNotExist) && filePath == m.filesDir() {
+				return nil
+			}
+			return walkErr
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relative, err := filepath.Rel(m.filesDir(), filePath)
+		if err != nil {
+			return err
+		}
+		if referenced[filepath.ToSlash(relative)] {
+			return nil
+		}
+		return m.addGCOrphan(&report, filePath, entry)
+	})
+	if err != nil {
+		return GCReport{}, fmt.Errorf("scan stored files: %w", err)
+	}
+
+	err = filepath.WalkDir(m.stagingDir(), func(filePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if errors.Is(walkErr, fs.ErrNotExist) && filePath == m.stagingDir() {
+				return nil
+			}
+			return walkErr
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		if !isTemporaryFileName(entry.Name()) {
+			report.Staged++
+			return nil
+		}
+		return m.addGCOrphan(&report, filePath, entry)
+	})
+	if err != nil {
+		return GCReport{}, fmt.Errorf("read staging directory: %w", err)
+	}
+	sort.Slice(report.Orphans, func(i, j int) bool {
+		return report.Orphans[i].Path < report.Orphans[j].Path
+	})
+
+	if !options.Prune || len(report.Orphans) == 0 {
+		return report, nil
+	}
+	for _, orphan := range report.Orphans {
+		source := filepath.Join(m.Config.DataDir, filepath.FromSlash(orphan.Path))
+		if quarantineDir == "" {
+			err = os.Remove(source)
+		} else {
+			err = moveFile(source, filepath.Join(quarantineDir, filepath.FromSlash(orphan.Path)))
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return report, fmt.Errorf("prune %s: %w", orphan.Path, err)
+		}
+	}
+	removeEmptyDirectories(filepath.Join(m.filesDir(), attachmentObjectsDirectoryName))
+	report.Pruned = true
+	report.QuarantineDir = quarantineDir
+	return report, nil
+}
+
+// gcReferencedFiles returns the stored names, relative to the files
+// directory, of every body and attachment an entry or revision refers to,
+// and records the ones that are missing.
+func (m *Manager) gcReferencedFiles(report *GCReport) (map[string]bool, error) {
+	entries, err := m.QueryEntries(EntryQuery{OldestFirst: true})
+	if err != nil {
+		return nil, err
+	}
+	db, err := m.openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	referenced := map[string]bool{}
+	refer := func(entry Entry, revision int, name, storedName string) {
+		if referenced[storedName] {
+			return
+		}
+		referenced[storedName] = true
+		if _, err := os.Stat(filepath.Join(m.filesDir(), storedName)); err == nil {
+			return
+		}
+		report.Missing = append(report.Missing, GCMissingFile{
+			CommitID: entry.CommitID,
+			Revision: revision,
+			Name:     name,
+			Path:     filepath.ToSlash(filepath.Join(repositoryDirectory, filesDirectoryName, storedName)),
+		})
+	}
+	for _, entry := range entries {
+		if entry.MessageBody != "" {
+			refer(entry, 0, "", bodyFileName(entry))
+		}
+		for _, fileName := range entry.Files {
+			refer(entry, 0, fileName, storedAttachmentName(entry, fileName))
+		}
+		revisions, err := loadEntryRevisions(db, entry.CommitID)
+		if err != nil {
+			return nil, err
+		}
+		for index, owner := range revisionEntries(entry, revisions) {
+			for _, fileName := range owner.Files {
+				refer(entry, revisions[index].Revision, fileName, storedAttachmentName(owner, fileName))
+			}
+		}
+	}
+	return referenced, nil
+}
+
+func (m *Manager) addGCOrphan(report *GCReport, filePath string, entry fs.DirEntry) error {
+	info, err := entry.Info()
+	if err != nil {
+		return fmt.Errorf("inspect %s: %w", filePath, err)
+	}
+	relative, err := filepath.Rel(m.Config.DataDir, filePath)
+	if err != nil {
+		return err
+	}
+	report.Orphans = append(report.Orphans, GCOrphan{Path: filepath.ToSlash(relative), Size: info.Size()})
+	return nil
+}
+
+// gcQuarantineDir resolves a quarantine directory, which must be outside
+// the directories garbage collection scans.
+func (m *Manager) gcQuarantineDir(directory string) (string, error) {
+	directory, err := filepath.Abs(directory)
+	if err != nil {
+		return "", fmt.Errorf("resolve quarantine directory: %w", err)
+	}
+	for _, scanned := range []string{m.filesDir(), m.stagingDir()} {
+		scanned, err := filepath.Abs(scanned)
+		if err != nil {
+			return "", fmt.Errorf("resolve quarantine directory: %w", err)
+		}
+		if relative, err := filepath.Rel(scanned, directory); err == nil &&
+			relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("quarantine directory cannot be inside %s", scanned)
+		}
+	}
+	return directory, nil
+}
+
+// isTemporaryFileName reports whether a file name is one used while copying
+// a file into place, which an interrupted copy can leave behind.
+func isTemporaryFileName(name string) bool {
+	return strings.HasPrefix(name, ".incoming-") ||
+		(strings.HasPrefix(name, ".") && strings.Contains(name, ".tmp-"))
+}
+
+// moveFile renames a file, copying it when the target is on another file
+// system.
+func moveFile(source, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(target); err == nil {
+		return fmt.Errorf("%s already exists", target)
+	}
+	if err := os.Rename(source, target); err == nil {
+		return nil
+	}
+	if err := copyFile(source, target); err != nil {
+		return err
+	}
+	return os.Remove(source)
+}
+
+// removeEmptyDirectories removes the empty directories below root.
+func removeEmptyDirectories(root string) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		directory := filepath.Join(root, entry.Name())
+		removeEmptyDirectories(directory)
+		_ = os.Remove(directory)
+	}
+}
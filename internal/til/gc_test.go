@@ -0,0 +1,86 @@
+package til
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectGarbageReportsAndPrunesOrphans(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	source := filepath.Join(root, "notes.txt")
+	require.NoError(t, os.WriteFile(source, []byte("first"), 0644))
+	require.NoError(t, manager.AddFile(source))
+	require.NoError(t, manager.CommitEntryWithBody("Kept entry", "A body"))
+	require.NoError(t, os.WriteFile(source, []byte("second"), 0644))
+	require.NoError(t, manager.AddFile(source))
+	require.NoError(t, manager.AmendLastEntryWithBody("Kept entry", ""))
+	entry, err := manager.GetLatestEntries(1)
+	require.NoError(t, err)
+	require.NoError(t, manager.CommitEntryWithBody("Missing body", "Gone"))
+	missing, err := manager.GetLatestEntries(1)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(manager.filesDir(), bodyFileName(missing[0]))))
+
+	orphans := map[string]string{
+		"til/files/body_deadbeef.md":       "old body",
+		"til/files/legacy01_old.txt":       "legacy",
+		"til/files/objects/ff/ff00.txt":    "unused",
+		".til/staging/.notes.txt.tmp-1234": "partial",
+	}
+	for name, content := range orphans {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(manager.stagingDir(), "next.txt"), []byte("next"), 0644))
+	staged, err := manager.GetStagedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"next.txt"}, staged, "leftover copies are not staged")
+
+	report, err := manager.CollectGarbage(GCOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Referenced, "both versions of notes.txt and the missing body")
+	assert.Equal(t, 1, report.Staged)
+	assert.Equal(t, []GCMissingFile{{
+		CommitID: missing[0].CommitID,
+		Path:     "til/files/" + bodyFileName(missing[0]),
+	}}, report.Missing)
+	assert.Equal(t, []GCOrphan{
+		{Path: ".til/staging/.notes.txt.tmp-1234", Size: 7},
+		{Path: "til/files/body_deadbeef.md", Size: 8},
+		{Path: "til/files/legacy01_old.txt", Size: 6},
+		{Path: "til/files/objects/ff/ff00.txt", Size: 6},
+	}, report.Orphans)
+	assert.False(t, report.Pruned)
+	for name := range orphans {
+		assert.FileExists(t, filepath.Join(root, filepath.FromSlash(name)), "reporting does not delete")
+	}
+
+	_, err = manager.CollectGarbage(GCOptions{Prune: true, QuarantineDir: filepath.Join(manager.filesDir(), "quarantine")})
+	assert.ErrorContains(t, err, "quarantine directory cannot be inside")
+
+	quarantine := filepath.Join(t.TempDir(), "quarantine")
+	report, err = manager.CollectGarbage(GCOptions{Prune: true, QuarantineDir: quarantine})
+	require.NoError(t, err)
+	assert.True(t, report.Pruned)
+	assert.Equal(t, quarantine, report.QuarantineDir)
+	for name, content := range orphans {
+		assert.NoFileExists(t, filepath.Join(root, filepath.FromSlash(name)))
+		moved, err := os.ReadFile(filepath.Join(quarantine, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		assert.Equal(t, content, string(moved))
+	}
+	assert.NoDirExists(t, filepath.Join(manager.filesDir(), "objects", "ff"))
+	assert.FileExists(t, storedAttachmentPath(manager, entry[0], "notes.txt"))
+	assert.FileExists(t, filepath.Join(manager.stagingDir(), "next.txt"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(manager.filesDir(), "stray.txt"), []byte("stray"), 0644))
+	report, err = manager.CollectGarbage(GCOptions{Prune: true})
+	require.NoError(t, err)
+	assert.Equal(t, []GCOrphan{{Path: "til/files/stray.txt", Size: 5}}, report.Orphans)
+	assert.NoFileExists(t, filepath.Join(manager.filesDir(), "stray.txt"))
+}
@@ -7,7 +7,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 )
 
 var ErrNoChanges = errors.New("no staged changes")
@@ -145,6 +147,22 @@ func (gm *GitManager) AddAll() error {
 	return gm.Add(".")
 }
 
+// AddAllDryRun lists the changes AddAll would stage, in the form git prints
+// them, such as "add 'README.md'".
+func (gm *GitManager) AddAllDryRun() ([]string, error) {
+	if !gm.IsInitialized() {
+		return nil, errors.New("git repository not initialized")
+	}
+	output, err := gm.run("add", "--dry-run", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	if output == "" {
+		return []string{}, nil
+	}
+	return strings.Split(output, "\n"), nil
+}
+
 func (gm *GitManager) HasStagedChanges() (bool, error) {
 	if !gm.IsInitialized() {
 		return false, errors.New("git repository not initialized")
@@ -164,19 +182,80 @@ func (gm *GitManager) HasStagedChanges() (bool, error) {
 	return false, fmt.Errorf("inspect staged Git changes: %w", err)
 }
 
+// CommitOptions controls who a commit is attributed to and when.
+type CommitOptions struct {
+	// Author is "Name <email>". Empty uses Git's configured identity.
+	Author string
+	// Date is the author date. Zero uses the current time.
+	Date time.Time
+	// AllowEmpty records the commit even when nothing is staged.
+	AllowEmpty bool
+}
+
+func (options CommitOptions) environment() ([]string, error) {
+	environment := []string{}
+	if options.Author != "" {
+		name, email, err := parseGitAuthor(options.Author)
+		if err != nil {
+			return nil, err
+		}
+		environment = append(environment, "GIT_AUTHOR_NAME="+name, "GIT_AUTHOR_EMAIL="+email)
+	}
+	if !options.Date.IsZero() {
+		environment = append(environment, "GIT_AUTHOR_DATE="+options.Date.Format(time.RFC3339))
+	}
+	return environment, nil
+}
+
+// ValidateGitAuthor checks a commit author written as "Name <email>". Empty
+// uses Git's configured identity.
+func ValidateGitAuthor(author string) error {
+	if author == "" {
+		return nil
+	}
+	_, _, err := parseGitAuthor(author)
+	return err
+}
+
+func parseGitAuthor(author string) (string, string, error) {
+	name, rest, ok := strings.Cut(author, "<")
+	name = strings.TrimSpace(name)
+	email, trailing, closed := strings.Cut(rest, ">")
+	email = strings.TrimSpace(email)
+	if !ok || !closed || name == "" || email == "" || strings.TrimSpace(trailing) != "" ||
+		strings.ContainsAny(name, "<>\r\n") || strings.ContainsAny(email, "<> \t\r\n") {
+		return "", "", fmt.Errorf("Git author must look like \"Name <email>\", got %q", author)
+	}
+	return name, email, nil
+}
+
 func (gm *GitManager) Commit(message string) error {
+	return gm.CommitWithOptions(message, CommitOptions{})
+}
+
+// CommitWithOptions commits the staged changes with the given authorship.
+func (gm *GitManager) CommitWithOptions(message string, options CommitOptions) error {
 	if strings.TrimSpace(message) == "" {
 		return errors.New("Git commit message cannot be empty")
 	}
-	hasChanges, err := gm.HasStagedChanges()
+	environment, err := options.environment()
 	if err != nil {
 		return err
 	}
-	if !hasChanges {
-		return ErrNoChanges
+	args := []string{"commit", "-m", message}
+	if options.AllowEmpty {
+		args = append(args, "--allow-empty")
+	} else {
+		hasChanges, err := gm.HasStagedChanges()
+		if err != nil {
+			return err
+		}
+		if !hasChanges {
+			return ErrNoChanges
+		}
 	}
 
-	_, err = gm.run("commit", "-m", message)
+	_, err = gm.runWithEnvironment(environment, args...)
 	return err
 }
 
@@ -198,6 +277,284 @@ func (gm *GitManager) Push() error {
 	return nil
 }
 
+// Fetch downloads the origin remote's branches without changing the
+// working tree.
+func (gm *GitManager) Fetch() error {
+	if !gm.IsInitialized() {
+		return errors.New("git repository not initialized")
+	}
+	if _, err := gm.run("remote", "get-url", "origin"); err != nil {
+		return errors.New("Git remote 'origin' is not configured")
+	}
+	if _, err := gm.run("fetch", "origin"); err != nil {
+		return fmt.Errorf("fetch Git remote: %w", err)
+	}
+	return nil
+}
+
+// RemoteURL returns the URL of the origin remote.
+func (gm *GitManager) RemoteURL() (string, error) {
+	remoteURL, err := gm.run("remote", "get-url", "origin")
+	if err != nil {
+		return "", errors.New("Git remote 'origin' is not configured")
+	}
+	return remoteURL, nil
+}
+
+// CheckRemote contacts the origin remote without changing anything. Git is
+// not allowed to prompt for credentials, so a remote that needs them fails.
+func (gm *GitManager) CheckRemote() error {
+	if !gm.IsInitialized() {
+		return errors.New("git repository not initialized")
+	}
+	if _, err := gm.RemoteURL(); err != nil {
+		return err
+	}
+	if _, err := gm.runWithEnvironment([]string{"GIT_TERMINAL_PROMPT=0"}, "ls-remote", "--heads", "origin"); err != nil {
+		return fmt.Errorf("reach Git remote: %w", err)
+	}
+	return nil
+}
+
+// RemoteCommit returns the commit origin's copy of the current branch points
+// at, as of the last fetch. It reports false when the branch was never pushed.
+func (gm *GitManager) RemoteCommit() (string, bool, error) {
+	branch, err := gm.CurrentBranch()
+	if err != nil {
+		return "", false, err
+	}
+	return gm.resolveCommit("refs/remotes/origin/" + branch)
+}
+
+// HeadCommit returns the commit HEAD points at, or false before the first
+// commit.
+func (gm *GitManager) HeadCommit() (string, bool, error) {
+	return gm.resolveCommit("HEAD")
+}
+
+// IsAncestor reports whether ancestor is reachable from commit.
+func (gm *GitManager) IsAncestor(ancestor, commit string) (bool, error) {
+	cmd := exec.Command("git", "merge-base", "--is-ancestor", ancestor, commit)
+	cmd.Dir = gm.WorkDir
+	err := cmd.Run()
+	if err == nil {
+		return true, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("compare Git commits: %w", err)
+}
+
+// MergeBase returns the best common ancestor of two commits, or false when
+// their histories are unrelated.
+func (gm *GitManager) MergeBase(first, second string) (string, bool, error) {
+	cmd := exec.Command("git", "merge-base", first, second)
+	cmd.Dir = gm.WorkDir
+	output, err := cmd.Output()
+	if err == nil {
+		return strings.TrimSpace(string(output)), true, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "", false, nil
+	}
+	return "", false, fmt.Errorf("find Git merge base: %w", err)
+}
+
+// ListFiles returns the paths of the files under directory in a commit,
+// relative to the repository root and slash-separated.
+func (gm *GitManager) ListFiles(commit, directory string) ([]string, error) {
+	files, err := gm.listPaths("ls-tree", "-r", "--name-only", "-z", commit, "--", directory)
+	if err != nil {
+		return nil, fmt.Errorf("list Git files: %w", err)
+	}
+	return files, nil
+}
+
+// ExportFile writes a file as it is in a commit to destination. It reports
+// false, writing nothing, when the commit does not have the file.
+func (gm *GitManager) ExportFile(commit, path, destination string) (bool, error) {
+	object := commit + ":" + path
+	if _, err := gm.run("cat-file", "-e", object); err != nil {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return false, fmt.Errorf("create directory for %s: %w", path, err)
+	}
+	file, err := os.CreateTemp(filepath.Dir(destination), "."+filepath.Base(destination)+".*")
+	if err != nil {
+		return false, fmt.Errorf("export %s: %w", path, err)
+	}
+	defer os.Remove(file.Name())
+
+	var stderr strings.Builder
+	cmd := exec.Command("git", "cat-file", "blob", object)
+	cmd.Dir = gm.WorkDir
+	cmd.Stdout = file
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	if err := errors.Join(runErr, file.Close()); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return false, fmt.Errorf("export %s: %s: %w", path, message, err)
+		}
+		return false, fmt.Errorf("export %s: %w", path, err)
+	}
+	if err := os.Rename(file.Name(), destination); err != nil {
+		return false, fmt.Errorf("export %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// CommitStaged records the staged tree as a commit with the given parents
+// and moves the current branch to it. Unlike Commit, the commit is made even
+// when nothing is staged, which is how merges are recorded.
+func (gm *GitManager) CommitStaged(message string, options CommitOptions, parents ...string) error {
+	if strings.TrimSpace(message) == "" {
+		return errors.New("Git commit message cannot be empty")
+	}
+	environment, err := options.environment()
+	if err != nil {
+		return err
+	}
+	tree, err := gm.run("write-tree")
+	if err != nil {
+		return fmt.Errorf("write Git tree: %w", err)
+	}
+	args := []string{"commit-tree", tree, "-m", message}
+	for _, parent := range parents {
+		args = append(args, "-p", parent)
+	}
+	commit, err := gm.runWithEnvironment(environment, args...)
+	if err != nil {
+		return fmt.Errorf("create Git commit: %w", err)
+	}
+	return gm.MoveBranch(commit)
+}
+
+// emptyGitTree is the ID Git gives a tree with no files.
+const emptyGitTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// StageMerge stages the merge of the working tree into commit remote. The
+// index starts from the remote tree; paths the working tree changed since
+// base are taken from the working tree, and every other path keeps the
+// remote's version, which is then checked out. Paths in keep are taken
+// from the working tree as if changed there. base may be empty when the
+// two sides share no commit.
+func (gm *GitManager) StageMerge(base, remote string, keep []string) error {
+	if base == "" {
+		base = emptyGitTree
+	}
+	if err := gm.AddAll(); err != nil {
+		return err
+	}
+	changed, err := gm.listPaths("diff", "--cached", "--name-only", "--no-renames", "-z", base)
+	if err != nil {
+		return fmt.Errorf("list local Git changes: %w", err)
+	}
+	for _, name := range keep {
+		if !slices.Contains(changed, name) {
+			changed = append(changed, name)
+		}
+	}
+	working, err := gm.listPaths("ls-files", "-z")
+	if err != nil {
+		return fmt.Errorf("list Git files: %w", err)
+	}
+	inWorkingTree := make(map[string]bool, len(working))
+	for _, name := range working {
+		inWorkingTree[name] = true
+	}
+
+	if _, err := gm.run("read-tree", remote); err != nil {
+		return fmt.Errorf("read Git tree: %w", err)
+	}
+	var added, removed []string
+	for _, name := range changed {
+		if inWorkingTree[name] {
+			added = append(added, name)
+		} else {
+			removed = append(removed, name)
+		}
+	}
+	for start := 0; start < len(added); start += gitPathBatchSize {
+		batch := added[start:min(start+gitPathBatchSize, len(added))]
+		if _, err := gm.run(append([]string{"--literal-pathspecs", "add", "--"}, batch...)...); err != nil {
+			return err
+		}
+	}
+	for start := 0; start < len(removed); start += gitPathBatchSize {
+		batch := removed[start:min(start+gitPathBatchSize, len(removed))]
+		args := append([]string{"--literal-pathspecs", "rm", "--cached", "--quiet", "--ignore-unmatch", "--"}, batch...)
+		if _, err := gm.run(args...); err != nil {
+			return err
+		}
+	}
+
+	// Bring the working tree in line with the merged index.
+	if _, err := gm.run("checkout-index", "--all", "--force"); err != nil {
+		return fmt.Errorf("check out Git merge: %w", err)
+	}
+	merged, err := gm.listPaths("ls-files", "-z")
+	if err != nil {
+		return fmt.Errorf("list Git files: %w", err)
+	}
+	inMerge := make(map[string]bool, len(merged))
+	for _, name := range merged {
+		inMerge[name] = true
+	}
+	for _, name := range working {
+		if inMerge[name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(gm.WorkDir, filepath.FromSlash(name))); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// gitPathBatchSize limits how many paths are passed to one git command.
+const gitPathBatchSize = 200
+
+// listPaths runs a git command that prints NUL-separated paths.
+func (gm *GitManager) listPaths(args ...string) ([]string, error) {
+	output, err := gm.run(args...)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for _, name := range strings.Split(output, "\x00") {
+		if name != "" {
+			paths = append(paths, name)
+		}
+	}
+	return paths, nil
+}
+
+// MoveBranch points the current branch at commit without touching the
+// working tree or the index.
+func (gm *GitManager) MoveBranch(commit string) error {
+	if _, err := gm.run("update-ref", "HEAD", commit); err != nil {
+		return fmt.Errorf("update Git branch: %w", err)
+	}
+	return nil
+}
+
+// StagedTreeMatches reports whether the index holds the same files as commit.
+func (gm *GitManager) StagedTreeMatches(commit string) (bool, error) {
+	tree, err := gm.run("write-tree")
+	if err != nil {
+		return false, fmt.Errorf("write Git tree: %w", err)
+	}
+	commitTree, err := gm.run("rev-parse", commit+"^{tree}")
+	if err != nil {
+		return false, fmt.Errorf("read Git tree: %w", err)
+	}
+	return tree == commitTree, nil
+}
+
 func (gm *GitManager) CurrentBranch() (string, error) {
 	if !gm.IsInitialized() {
 		return "", errors.New("git repository not initialized")
@@ -212,6 +569,16 @@ func (gm *GitManager) CurrentBranch() (string, error) {
 	return branch, nil
 }
 
+// UserName returns Git's user.name for the repository, or an empty string
+// when it is not set.
+func (gm *GitManager) UserName() string {
+	name, err := gm.run("config", "user.name")
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
 func (gm *GitManager) GetFileURL(remoteURL, filePath string) string {
 	webURL, err := remoteWebURL(remoteURL)
 	if err != nil {
@@ -233,6 +600,24 @@ func (gm *GitManager) GetFileURL(remoteURL, filePath string) string {
 	)
 }
 
+// GitWebFileURL returns the web page of a file in the repository on the Git
+// host, using GitHub's blob URL layout.
+func GitWebFileURL(remoteURL, branch, filePath string) (string, error) {
+	webURL, err := remoteWebURL(remoteURL)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(branch) == "" {
+		branch = "main"
+	}
+	return fmt.Sprintf(
+		"%s/blob/%s/%s",
+		webURL,
+		url.PathEscape(branch),
+		escapeURLPath(filepath.ToSlash(filePath)),
+	), nil
+}
+
 func GitHubRawFileURL(remoteURL, branch, filePath string) (string, error) {
 	webURL, err := remoteWebURL(remoteURL)
 	if err != nil {
@@ -294,9 +679,35 @@ func (gm *GitManager) remoteBranch() (string, bool, error) {
 	return "", false, nil
 }
 
+// resolveCommit returns the commit a ref points at, or false when the ref
+// does not exist.
+func (gm *GitManager) resolveCommit(ref string) (string, bool, error) {
+	if !gm.IsInitialized() {
+		return "", false, errors.New("git repository not initialized")
+	}
+	commit, err := gm.run("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("resolve %s: %w", ref, err)
+	}
+	return commit, true, nil
+}
+
 func (gm *GitManager) run(args ...string) (string, error) {
+	return gm.runWithEnvironment(nil, args...)
+}
+
+// runWithEnvironment runs git with extra environment variables, such as
+// the author of a commit.
+func (gm *GitManager) runWithEnvironment(environment []string, args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = gm.WorkDir
+	if len(environment) > 0 {
+		cmd.Env = append(os.Environ(), environment...)
+	}
 	output, err := cmd.CombinedOutput()
 	trimmed := strings.TrimSpace(string(output))
 	if err != nil {
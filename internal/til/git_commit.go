@@ -0,0 +1,249 @@
+package til
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// gitEntryChange is an entry added, amended, or removed since the last Git
+// commit.
+type gitEntryChange struct {
+	Action string
+	Entry  Entry
+}
+
+const (
+	gitChangeAdd    = "Add"
+	gitChangeAmend  = "Amend"
+	gitChangeRemove = "Remove"
+)
+
+// gitEntryChanges compares the entries in the working database with the
+// ones committed at HEAD. Added and amended entries come first, oldest
+// first, followed by removed entries.
+func (m *Manager) gitEntryChanges(gitManager *GitManager) ([]gitEntryChange, error) {
+	committed := map[string]Entry{}
+	if _, hasHead, err := gitManager.HeadCommit(); err != nil {
+		return nil, err
+	} else if hasHead {
+		workDir, err := os.MkdirTemp("", "til-commit-*")
+		if err != nil {
+			return nil, fmt.Errorf("create commit directory: %w", err)
+		}
+		defer os.RemoveAll(workDir)
+		committed, err = gitEntriesAt(gitManager, "HEAD", workDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	entries, err := m.GetLatestEntries(0)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []gitEntryChange{}
+	for index := len(entries) - 1; index >= 0; index-- {
+		entry := entries[index]
+		previous, ok := committed[entry.CommitID]
+		delete(committed, entry.CommitID)
+		switch {
+		case !ok:
+			changes = append(changes, gitEntryChange{Action: gitChangeAdd, Entry: entry})
+		case EntryContentHash(previous) != EntryContentHash(entry):
+			changes = append(changes, gitEntryChange{Action: gitChangeAmend, Entry: entry})
+		}
+	}
+	removed := make([]Entry, 0, len(committed))
+	for _, entry := range committed {
+		removed = append(removed, entry)
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		if !removed[i].Date.Equal(removed[j].Date) {
+			return removed[i].Date.Before(removed[j].Date)
+		}
+		return removed[i].CommitID < removed[j].CommitID
+	})
+	for _, entry := range removed {
+		changes = append(changes, gitEntryChange{Action: gitChangeRemove, Entry: entry})
+	}
+	return changes, nil
+}
+
+// commitGitChanges stages every change in the repository and commits it
+// with a message listing the changed entries. With perEntry, each entry
+// gets its own commit holding its body and attachments, dated when the
+// entry was written; the last one also holds the database and README.
+// Entries without stored files are folded into the next commit, so no
+// commit is empty. It returns the number of commits created.
+func (m *Manager) commitGitChanges(gitManager *GitManager, perEntry bool) (int, error) {
+	changes, err := m.gitEntryChanges(gitManager)
+	if err != nil {
+		return 0, err
+	}
+	options := CommitOptions{Author: m.Config.GitAuthor}
+
+	if !perEntry || len(changes) == 0 {
+		if err := gitManager.AddAll(); err != nil {
+			return 0, fmt.Errorf("stage Git changes: %w", err)
+		}
+		hasChanges, err := gitManager.HasStagedChanges()
+		if err != nil || !hasChanges {
+			return 0, err
+		}
+		if err := gitManager.CommitWithOptions(gitCommitMessageFor(changes), options); err != nil {
+			return 0, fmt.Errorf("commit Git changes: %w", err)
+		}
+		return 1, nil
+	}
+
+	groups, err := m.gitEntryCommits(gitManager, changes)
+	if err != nil {
+		return 0, err
+	}
+	commits := 0
+	folded := []gitEntryChange{}
+	for index, group := range groups {
+		change := group.Changes[len(group.Changes)-1]
+		if index == len(groups)-1 {
+			err = gitManager.AddAll()
+		} else {
+			err = gitManager.Add(group.Paths...)
+		}
+		if err != nil {
+			return commits, fmt.Errorf("stage Git changes: %w", err)
+		}
+		// An entry's files may already match HEAD, such as a removed
+		// entry whose files were never committed.
+		folded = append(folded, group.Changes...)
+		hasChanges, err := gitManager.HasStagedChanges()
+		if err != nil {
+			return commits, err
+		}
+		if !hasChanges {
+			continue
+		}
+
+		entryOptions := options
+		if change.Action != gitChangeRemove {
+			entryOptions.Date = change.Entry.Date
+		}
+		if err := gitManager.CommitWithOptions(gitCommitMessageFor(folded), entryOptions); err != nil {
+			return commits, fmt.Errorf("commit Git changes: %w", err)
+		}
+		commits++
+		folded = nil
+	}
+	return commits, nil
+}
+
+// gitEntryCommit is one commit of a per-entry push: the changes it lists
+// and the stored files of the last one, which it stages.
+type gitEntryCommit struct {
+	Changes []gitEntryChange
+	Paths   []string
+}
+
+// gitEntryCommits splits changes into the per-entry commits that hold
+// them. Each commit ends with an entry that has stored files, so entries
+// without any are folded into the next commit; the last commit takes
+// whatever remains.
+func (m *Manager) gitEntryCommits(gitManager *GitManager, changes []gitEntryChange) ([]gitEntryCommit, error) {
+	tracked := map[string]bool{}
+	if _, hasHead, err := gitManager.HeadCommit(); err != nil {
+		return nil, err
+	} else if hasHead {
+		files, err := gitManager.ListFiles("HEAD", filesDirectoryName)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range files {
+			tracked[name] = true
+		}
+	}
+	commits := []gitEntryCommit{}
+	first := 0
+	for index, change := range changes {
+		paths := m.gitEntryPaths(change.Entry, tracked)
+		if index == len(changes)-1 || len(paths) > 0 {
+			commits = append(commits, gitEntryCommit{Changes: changes[first : index+1], Paths: paths})
+			first = index + 1
+		}
+	}
+	return commits, nil
+}
+
+// gitEntryPaths lists the stored files of an entry that exist or are
+// tracked, relative to the repository.
+func (m *Manager) gitEntryPaths(entry Entry, tracked map[string]bool) []string {
+	names := []string{bodyFileName(entry)}
+	for _, fileName := range entry.Files {
+		names = append(names, storedAttachmentName(entry, fileName))
+	}
+	paths := []string{}
+	for _, name := range names {
+		relative := path.Join(filesDirectoryName, name)
+		if _, err := os.Stat(filepath.Join(m.filesDir(), name)); err == nil || tracked[relative] {
+			paths = append(paths, relative)
+		}
+	}
+	return paths
+}
+
+// gitCommitMessageFor describes entry changes in a commit message. One
+// change is named in the subject; several are counted there and listed in
+// the body. Without entry changes the message is the generic one.
+func gitCommitMessageFor(changes []gitEntryChange) string {
+	switch len(changes) {
+	case 0:
+		return gitCommitMessage
+	case 1:
+		return fmt.Sprintf("%s %s\n\nEntry %s", changes[0].Action, changes[0].Entry.Message, changes[0].Entry.CommitID)
+	}
+
+	var counts []string
+	var body strings.Builder
+	for _, action := range []string{gitChangeAdd, gitChangeAmend, gitChangeRemove} {
+		count := 0
+		for _, change := range changes {
+			if change.Action != action {
+				continue
+			}
+			if count == 0 {
+				if body.Len() > 0 {
+					body.WriteString("\n")
+				}
+				fmt.Fprintf(&body, "%s:\n", gitChangeHeading(action))
+			}
+			count++
+			fmt.Fprintf(&body, "- %s %s\n", change.Entry.CommitID, change.Entry.Message)
+		}
+		if count > 0 {
+			verb := strings.ToLower(action)
+			if len(counts) == 0 {
+				verb = action
+			}
+			counts = append(counts, fmt.Sprintf("%s %d %s", verb, count, pluralizeEntry(count)))
+		}
+	}
+	return strings.Join(counts, ", ") + "\n\n" + strings.TrimSuffix(body.String(), "\n")
+}
+
+// gitCommitSubject returns the first line of a commit message.
+func gitCommitSubject(message string) string {
+	subject, _, _ := strings.Cut(message, "\n")
+	return subject
+}
+
+func gitChangeHeading(action string) string {
+	switch action {
+	case gitChangeAdd:
+		return "Added"
+	case gitChangeAmend:
+		return "Amended"
+	}
+	return "Removed"
+}
@@ -0,0 +1,108 @@
+package til
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitCommitMessageFor(t *testing.T) {
+	assert.Equal(t, "Update TIL entries", gitCommitMessageFor(nil))
+	assert.Equal(
+		t,
+		"Add Go generics\n\nEntry go000001",
+		gitCommitMessageFor([]gitEntryChange{{Action: gitChangeAdd, Entry: Entry{Message: "Go generics", CommitID: "go000001"}}}),
+	)
+	assert.Equal(
+		t,
+		"Add 2 entries, remove 1 entry\n\n"+
+			"Added:\n- go000001 Go generics\n- wal00002 SQLite WAL\n\n"+
+			"Removed:\n- old00003 Old entry",
+		gitCommitMessageFor([]gitEntryChange{
+			{Action: gitChangeAdd, Entry: Entry{Message: "Go generics", CommitID: "go000001"}},
+			{Action: gitChangeAdd, Entry: Entry{Message: "SQLite WAL", CommitID: "wal00002"}},
+			{Action: gitChangeRemove, Entry: Entry{Message: "Old entry", CommitID: "old00003"}},
+		}),
+	)
+}
+
+func TestGitPushCommitsPerEntryWithConfiguredAuthor(t *testing.T) {
+	requireGit(t)
+	setGitIdentity(t)
+	remote := newBareRepository(t)
+
+	manager, _ := newTestManager(t, Config{
+		SyncToGit:         true,
+		GitRemoteURL:      remote,
+		GitAuthor:         "Ada Lovelace <ada@example.test>",
+		GitCommitPerEntry: true,
+	})
+	require.NoError(t, NewGitManager(manager.repositoryDir()).Configure(remote))
+	require.NoError(t, manager.insertEntry(Entry{
+		Date:        time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC),
+		Message:     "Go generics",
+		MessageBody: "Use any.",
+		IsCommitted: true,
+		CommitID:    "go000001",
+	}))
+	require.NoError(t, writeFileAtomic(filepath.Join(manager.filesDir(), "body_go000001.md"), []byte("Use any."), 0644))
+	require.NoError(t, manager.insertEntry(Entry{
+		Date:        time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC),
+		Message:     "Plain entry",
+		IsCommitted: true,
+		CommitID:    "plain003",
+	}))
+	require.NoError(t, manager.insertEntry(Entry{
+		Date:        time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC),
+		Message:     "Rust lifetimes",
+		MessageBody: "Elision rules.",
+		IsCommitted: true,
+		CommitID:    "rust0004",
+	}))
+	require.NoError(t, writeFileAtomic(filepath.Join(manager.filesDir(), "body_rust0004.md"), []byte("Elision rules."), 0644))
+	require.NoError(t, manager.insertEntry(Entry{
+		Date:        time.Date(2025, 4, 2, 9, 0, 0, 0, time.UTC),
+		Message:     "SQLite WAL",
+		IsCommitted: true,
+		CommitID:    "wal00002",
+	}))
+	pushGit(t, manager)
+
+	assert.Equal(
+		t,
+		"Add SQLite WAL|Ada Lovelace <ada@example.test>|2025-04-02T09:00:00+00:00\n"+
+			"Add 2 entries|Ada Lovelace <ada@example.test>|2025-04-01T12:00:00+00:00\n"+
+			"Add Go generics|Ada Lovelace <ada@example.test>|2025-03-30T09:00:00+00:00",
+		gitOutput(t, manager.repositoryDir(), "log", "--format=%s|%an <%ae>|%aI"),
+	)
+	// Entries without stored files are folded into the next commit, and
+	// the last commit holds the database and README.
+	assert.Equal(t, "files/body_go000001.md", gitOutput(t, manager.repositoryDir(), "show", "--name-only", "--format=", "HEAD~2"))
+	assert.Equal(t, "files/body_rust0004.md", gitOutput(t, manager.repositoryDir(), "show", "--name-only", "--format=", "HEAD^"))
+	assert.Contains(
+		t,
+		gitOutput(t, manager.repositoryDir(), "log", "-1", "--format=%b", "HEAD^"),
+		"- plain003 Plain entry\n- rust0004 Rust lifetimes",
+	)
+	lastFiles := gitOutput(t, manager.repositoryDir(), "show", "--name-only", "--format=", "HEAD")
+	assert.Contains(t, lastFiles, "til.db")
+	assert.Contains(t, lastFiles, readmeFileName)
+	assert.NotContains(t, lastFiles, "body_")
+
+	// Without per-entry commits, one commit lists every change.
+	manager.Config.GitCommitPerEntry = false
+	require.NoError(t, manager.EditEntry("go000001", "Go generics and constraints", "Use any."))
+	_, err := manager.RemoveEntry("wal00002")
+	require.NoError(t, err)
+	pushGit(t, manager)
+	assert.Equal(
+		t,
+		"Amend 1 entry, remove 1 entry\n\n"+
+			"Amended:\n- go000001 Go generics and constraints\n\n"+
+			"Removed:\n- wal00002 SQLite WAL",
+		gitOutput(t, manager.repositoryDir(), "log", "-1", "--format=%B"),
+	)
+}
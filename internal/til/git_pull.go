@@ -0,0 +1,517 @@
+package til
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const gitMergeCommitMessage = "Merge TIL entries from origin"
+
+// GitDivergence is an entry that changed on this device and on another one
+// since they last shared a commit. Local or Remote is zero when that side
+// removed the entry.
+type GitDivergence struct {
+	Local  Entry
+	Remote Entry
+}
+
+// GitPullReport describes how entries pushed from other devices were merged.
+type GitPullReport struct {
+	// UpToDate is true when the remote had no commits missing locally.
+	UpToDate bool
+	Imported []Entry
+	Updated  []Entry
+	Removed  []Entry
+	// Diverged entries keep the local version. When both sides edited an
+	// entry, the remote version is saved as its latest revision; an entry
+	// removed here but edited elsewhere is restored.
+	Diverged []GitDivergence
+}
+
+// PullGit fetches the origin remote and merges the entries pushed there into
+// the local database by commit ID, using the last shared commit to tell
+// which side changed an entry. Uncommitted local changes are committed
+// first. Attachments and bodies of merged entries are copied from the
+// remote, the README is regenerated, and the result is committed with the
+// remote branch as a parent, so a push that follows does not conflict.
+func (m *Manager) PullGit() (GitPullReport, error) {
+	if !m.IsInitialized() {
+		return GitPullReport{}, ErrRepositoryNotInitialized
+	}
+	gitManager := NewGitManager(m.repositoryDir())
+	if !gitManager.IsInitialized() {
+		return GitPullReport{}, errors.New("Git repository is not initialized; run 'til config edit' to configure it")
+	}
+	if err := gitManager.Fetch(); err != nil {
+		return GitPullReport{}, err
+	}
+	remote, found, err := gitManager.RemoteCommit()
+	if err != nil {
+		return GitPullReport{}, err
+	}
+	if !found {
+		return GitPullReport{UpToDate: true}, nil
+	}
+	head, hasHead, err := gitManager.HeadCommit()
+	if err != nil {
+		return GitPullReport{}, err
+	}
+	if hasHead {
+		merged, err := gitManager.IsAncestor(remote, head)
+		if err != nil {
+			return GitPullReport{}, err
+		}
+		if merged {
+			return GitPullReport{UpToDate: true}, nil
+		}
+	}
+	// Local changes are committed first, so the merge commit only holds
+	// what came from other devices.
+	if _, err := m.commitGitChanges(gitManager, m.Config.GitCommitPerEntry); err != nil {
+		return GitPullReport{}, err
+	}
+	head, hasHead, err = gitManager.HeadCommit()
+	if err != nil {
+		return GitPullReport{}, err
+	}
+
+	workDir, err := os.MkdirTemp("", "til-pull-*")
+	if err != nil {
+		return GitPullReport{}, fmt.Errorf("create pull directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	remoteEntries, err := gitEntriesAt(gitManager, remote, filepath.Join(workDir, "remote"))
+	if err != nil {
+		return GitPullReport{}, err
+	}
+	baseEntries := map[string]Entry{}
+	base := ""
+	if hasHead {
+		var found bool
+		base, found, err = gitManager.MergeBase(head, remote)
+		if err != nil {
+			return GitPullReport{}, err
+		}
+		if found {
+			baseEntries, err = gitEntriesAt(gitManager, base, filepath.Join(workDir, "base"))
+			if err != nil {
+				return GitPullReport{}, err
+			}
+		}
+	}
+	localList, err := m.GetLatestEntries(0)
+	if err != nil {
+		return GitPullReport{}, err
+	}
+	localEntries := make(map[string]Entry, len(localList))
+	for _, entry := range localList {
+		localEntries[entry.CommitID] = entry
+	}
+
+	report, copied, err := m.mergeGitEntries(gitManager, remote, localEntries, remoteEntries, baseEntries)
+	if err != nil {
+		return report, err
+	}
+	if err := m.exportGitFiles(gitManager, remote, copied); err != nil {
+		return report, err
+	}
+	if err := m.RefreshReadme(); err != nil {
+		return report, err
+	}
+	// Files other devices pushed that no entry accounts for, such as
+	// another device's feed, keep the remote version unless changed here.
+	// Entries that diverged keep the local version of their files.
+	keep := []string{}
+	for _, divergence := range report.Diverged {
+		if divergence.Local.CommitID == "" || divergence.Remote.CommitID == "" {
+			continue
+		}
+		keep = append(keep, m.gitEntryPaths(divergence.Local, nil)...)
+		for _, fileName := range divergence.Remote.Files {
+			keep = append(keep, path.Join(filesDirectoryName, storedAttachmentName(divergence.Remote, fileName)))
+		}
+	}
+	if err := gitManager.StageMerge(base, remote, keep); err != nil {
+		return report, fmt.Errorf("stage Git merge: %w", err)
+	}
+
+	parents := []string{remote}
+	if hasHead {
+		fastForward, err := gitManager.IsAncestor(head, remote)
+		if err != nil {
+			return report, err
+		}
+		if !fastForward {
+			parents = []string{head, remote}
+		}
+	}
+	if len(parents) == 1 {
+		unchanged, err := gitManager.StagedTreeMatches(remote)
+		if err != nil {
+			return report, err
+		}
+		if unchanged {
+			return report, gitManager.MoveBranch(remote)
+		}
+	}
+	if err := gitManager.CommitStaged(gitMergeCommitMessage, CommitOptions{Author: m.Config.GitAuthor}, parents...); err != nil {
+		return report, fmt.Errorf("commit Git merge: %w", err)
+	}
+	return report, nil
+}
+
+// mergeGitEntries applies the remote side of a three-way merge to the local
+// database. It returns the remote entries whose stored files should be
+// copied from the remote commit; the files of remote versions saved as
+// revisions are stored as they are merged.
+func (m *Manager) mergeGitEntries(
+	gitManager *GitManager,
+	remoteCommit string,
+	local, remote, base map[string]Entry,
+) (GitPullReport, []Entry, error) {
+	report := GitPullReport{}
+	copied := []Entry{}
+	commitIDs := make([]string, 0, len(local)+len(remote))
+	for commitID := range local {
+		commitIDs = append(commitIDs, commitID)
+	}
+	for commitID := range remote {
+		if _, ok := local[commitID]; !ok {
+			commitIDs = append(commitIDs, commitID)
+		}
+	}
+	sort.Strings(commitIDs)
+
+	pulledAt := time.Now()
+	for _, commitID := range commitIDs {
+		localEntry, inLocal := local[commitID]
+		remoteEntry, inRemote := remote[commitID]
+		baseEntry, inBase := base[commitID]
+		localChanged := !inBase || !inLocal || EntryContentHash(localEntry) != EntryContentHash(baseEntry)
+		remoteChanged := !inBase || !inRemote || EntryContentHash(remoteEntry) != EntryContentHash(baseEntry)
+
+		switch {
+		case inLocal && inRemote && EntryContentHash(localEntry) == EntryContentHash(remoteEntry):
+			continue
+
+		case !inRemote:
+			// Removed on the other device, or added here since the base.
+			if !inBase {
+				continue
+			}
+			if localChanged {
+				report.Diverged = append(report.Diverged, GitDivergence{Local: localEntry})
+				continue
+			}
+			removed, err := m.removePulledEntry(localEntry)
+			if err != nil {
+				return report, copied, err
+			}
+			report.Removed = append(report.Removed, removed)
+
+		case !inLocal:
+			// Added on the other device, or removed here since the base.
+			if inBase && !remoteChanged {
+				continue
+			}
+			if err := m.applyPulledEntry(remoteEntry, false, pulledAt); err != nil {
+				return report, copied, err
+			}
+			copied = append(copied, remoteEntry)
+			if inBase {
+				report.Diverged = append(report.Diverged, GitDivergence{Remote: remoteEntry})
+			} else {
+				report.Imported = append(report.Imported, remoteEntry)
+			}
+
+		case !localChanged:
+			if err := m.applyPulledEntry(remoteEntry, true, pulledAt); err != nil {
+				return report, copied, err
+			}
+			copied = append(copied, remoteEntry)
+			report.Updated = append(report.Updated, remoteEntry)
+
+		case !remoteChanged:
+			continue
+
+		default:
+			// The local entry is kept, so its files must not be replaced
+			// with the remote's.
+			revision, err := m.storeRevisionFiles(gitManager, remoteCommit, remoteEntry)
+			if err != nil {
+				return report, copied, err
+			}
+			if err := m.saveRevision(commitID, revision); err != nil {
+				return report, copied, err
+			}
+			report.Diverged = append(report.Diverged, GitDivergence{Local: localEntry, Remote: remoteEntry})
+		}
+	}
+	return report, copied, nil
+}
+
+// applyPulledEntry stores the remote version of an entry with the remote's
+// Notion sync state, and marks it pushed to Git since it came from there.
+func (m *Manager) applyPulledEntry(entry Entry, exists bool, pulledAt time.Time) error {
+	bodyPath := filepath.Join(m.filesDir(), bodyFileName(entry))
+	if entry.MessageBody == "" {
+		if err := os.Remove(bodyPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove commit body: %w", err)
+		}
+	} else if err := writeFileAtomic(bodyPath, []byte(entry.MessageBody), 0644); err != nil {
+		return fmt.Errorf("save commit body: %w", err)
+	}
+
+	if exists {
+		if err := m.updateEntry(entry); err != nil {
+			return err
+		}
+	} else {
+		if err := m.insertEntry(entry); err != nil {
+			return err
+		}
+		// An entry removed here is restored with its published page.
+		if entry.NotionPageID != "" {
+			if err := m.ClearNotionPageTombstone(entry.NotionPageID); err != nil {
+				return err
+			}
+		}
+	}
+	if entry.NotionSynced {
+		if err := m.recordDestinationSync(notionDestinationName, map[string]DestinationSync{entry.CommitID: {
+			Synced:      true,
+			RemoteID:    entry.NotionPageID,
+			ContentHash: entry.NotionContentHash,
+			SyncedAt:    entry.NotionSyncedAt,
+		}}); err != nil {
+			return err
+		}
+	}
+	return m.recordDestinationSync(gitDestinationName, map[string]DestinationSync{entry.CommitID: {
+		Synced:      true,
+		ContentHash: EntryContentHash(entry),
+		SyncedAt:    pulledAt,
+	}})
+}
+
+// removePulledEntry deletes an entry removed on another device. That device
+// archives its Notion page, so no tombstone is kept here.
+func (m *Manager) removePulledEntry(entry Entry) (Entry, error) {
+	removed, err := m.RemoveEntry(entry.CommitID)
+	if err != nil {
+		return removed, err
+	}
+	if entry.NotionPageID != "" {
+		if err := m.ClearNotionPageTombstone(entry.NotionPageID); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// saveRevision records content as an entry's latest revision without
+// changing the entry.
+func (m *Manager) saveRevision(commitID string, content Entry) error {
+	db, err := m.openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	transaction, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin revision transaction: %w", err)
+	}
+	defer transaction.Rollback()
+
+	var entryID int64
+	if err := transaction.QueryRow(
+		"SELECT id FROM entries WHERE commit_id = ?",
+		commitID,
+	).Scan(&entryID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("entry %s not found", commitID)
+		}
+		return fmt.Errorf("find entry: %w", err)
+	}
+	if err := insertEntryRevision(transaction, entryID, content); err != nil {
+		return err
+	}
+	if err := transaction.Commit(); err != nil {
+		return fmt.Errorf("commit revision transaction: %w", err)
+	}
+	return nil
+}
+
+// exportGitFiles copies the stored attachments of entries from a commit,
+// replacing local copies.
+func (m *Manager) exportGitFiles(gitManager *GitManager, commit string, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	available, err := gitManager.ListFiles(commit, filesDirectoryName)
+	if err != nil {
+		return err
+	}
+	inCommit := make(map[string]bool, len(available))
+	for _, name := range available {
+		inCommit[name] = true
+	}
+	for _, entry := range entries {
+		for _, fileName := range entry.Files {
+			storedName := storedAttachmentName(entry, fileName)
+			name := path.Join(filesDirectoryName, storedName)
+			if !inCommit[name] {
+				continue
+			}
+			target := filepath.Join(m.filesDir(), storedName)
+			if attachment, ok := entry.attachment(fileName); ok && attachment.SHA256 != "" {
+				if _, err := os.Stat(target); err == nil {
+					continue
+				}
+			}
+			if _, err := gitManager.ExportFile(commit, name, target); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// storeRevisionFiles copies the attachments of an entry version from a
+// commit into the object store and records their digests on it, so a
+// version saved as a revision never shares per-entry file names with the
+// current entry. Files missing from the commit are left out of the store.
+func (m *Manager) storeRevisionFiles(gitManager *GitManager, commit string, entry Entry) (Entry, error) {
+	if len(entry.Files) == 0 {
+		return entry, nil
+	}
+	workDir, err := os.MkdirTemp("", "til-revision-*")
+	if err != nil {
+		return entry, fmt.Errorf("create revision directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	for index, fileName := range entry.Files {
+		storedName := storedAttachmentName(entry, fileName)
+		if attachment, ok := entry.attachment(fileName); ok && attachment.SHA256 != "" {
+			target := filepath.Join(m.filesDir(), storedName)
+			if _, err := os.Stat(target); err == nil {
+				continue
+			}
+			if _, err := gitManager.ExportFile(commit, path.Join(filesDirectoryName, storedName), target); err != nil {
+				return entry, err
+			}
+			continue
+		}
+		exported := filepath.Join(workDir, fmt.Sprintf("%d", index))
+		found, err := gitManager.ExportFile(commit, path.Join(filesDirectoryName, storedName), exported)
+		if err != nil {
+			return entry, err
+		}
+		if !found {
+			continue
+		}
+		attachment, _, err := m.storeAttachmentObject(exported, fileName)
+		if err != nil {
+			return entry, fmt.Errorf("store attachment %s: %w", fileName, err)
+		}
+		attachment.Name = fileName
+		entry.Attachments = withAttachment(entry.Attachments, attachment)
+	}
+	return entry, nil
+}
+
+// gitEntriesAt reads the entries in the database stored in a commit, keyed
+// by commit ID. A commit without a database has no entries.
+func gitEntriesAt(gitManager *GitManager, commit, dataDir string) (map[string]Entry, error) {
+	manager := NewManager(Config{DataDir: dataDir})
+	found, err := gitManager.ExportFile(commit, databaseFileName, manager.databasePath())
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string]Entry{}
+	if !found {
+		return entries, nil
+	}
+	list, err := manager.GetLatestEntries(0)
+	if err != nil {
+		return nil, fmt.Errorf("read entries from Git commit %s: %w", shortCommit(commit), err)
+	}
+	for _, entry := range list {
+		entries[entry.CommitID] = entry
+	}
+	return entries, nil
+}
+
+// WriteGitPullReport prints what a Git pull merged, one line per entry.
+func WriteGitPullReport(output io.Writer, report GitPullReport) {
+	if report.UpToDate {
+		fmt.Fprintln(output, "Local entries are up to date with Git.")
+		return
+	}
+	sections := []struct {
+		label   string
+		entries []Entry
+	}{
+		{"Imported", report.Imported},
+		{"Updated", report.Updated},
+		{"Removed", report.Removed},
+	}
+	changed := false
+	for _, section := range sections {
+		if len(section.entries) == 0 {
+			continue
+		}
+		changed = true
+		fmt.Fprintf(output, "%s %d %s from Git.\n", section.label, len(section.entries), pluralizeEntry(len(section.entries)))
+		for _, entry := range section.entries {
+			fmt.Fprintf(output, "  %s %s\n", entry.CommitID, entry.Message)
+		}
+	}
+	for _, divergence := range report.Diverged {
+		changed = true
+		switch {
+		case divergence.Local.CommitID == "":
+			fmt.Fprintf(
+				output,
+				"Diverged: %s %q was removed here but changed on another device; restored it.\n",
+				divergence.Remote.CommitID,
+				divergence.Remote.Message,
+			)
+		case divergence.Remote.CommitID == "":
+			fmt.Fprintf(
+				output,
+				"Diverged: %s %q was removed on another device but changed here; kept it.\n",
+				divergence.Local.CommitID,
+				divergence.Local.Message,
+			)
+		default:
+			fmt.Fprintf(
+				output,
+				"Diverged: %s %q changed on both devices; kept the local version and saved the other as its latest revision (see 'til log --revisions %s').\n",
+				divergence.Local.CommitID,
+				divergence.Local.Message,
+				divergence.Local.CommitID,
+			)
+		}
+	}
+	if !changed {
+		fmt.Fprintln(output, "Merged Git history; no entries changed.")
+	}
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
@@ -0,0 +1,351 @@
+package til
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullGitMergesEntriesFromAnotherDevice(t *testing.T) {
+	requireGit(t)
+	setGitIdentity(t)
+	remote := newBareRepository(t)
+
+	laptop, _ := newTestManager(t, Config{SyncToGit: true, GitRemoteURL: remote})
+	require.NoError(t, NewGitManager(laptop.repositoryDir()).Configure(remote))
+	for _, entry := range []Entry{
+		{Message: "Shared entry", CommitID: "shared01"},
+		{Message: "Body entry", MessageBody: "First body", CommitID: "body0002"},
+		{Message: "Old entry", CommitID: "old00003"},
+	} {
+		entry.Date = time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC)
+		entry.IsCommitted = true
+		require.NoError(t, laptop.insertEntry(entry))
+	}
+	pushGit(t, laptop)
+
+	desktopDir := t.TempDir()
+	require.NoError(t, NewGitManager(filepath.Join(desktopDir, repositoryDirectory)).Init(remote))
+	desktop := NewManager(Config{DataDir: desktopDir, SyncToGit: true, GitRemoteURL: remote})
+	require.True(t, desktop.IsInitialized())
+	require.NoError(t, desktop.EditEntry("shared01", "Shared entry from the desktop", ""))
+	require.NoError(t, desktop.insertEntry(Entry{
+		Date:        time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC),
+		Message:     "Desktop entry",
+		IsCommitted: true,
+		CommitID:    "desk0004",
+	}))
+
+	require.NoError(t, laptop.EditEntry("shared01", "Shared entry from the laptop", ""))
+	require.NoError(t, laptop.EditEntry("body0002", "Body entry", "Second body"))
+	_, err := laptop.RemoveEntry("old00003")
+	require.NoError(t, err)
+	require.NoError(t, laptop.insertEntry(Entry{
+		Date:        time.Date(2025, 4, 2, 9, 0, 0, 0, time.UTC),
+		Message:     "Laptop entry",
+		Files:       []string{"notes.txt"},
+		IsCommitted: true,
+		CommitID:    "lap00005",
+	}))
+	require.NoError(t, os.WriteFile(filepath.Join(laptop.filesDir(), "lap00005_notes.txt"), []byte("notes"), 0644))
+	pushGit(t, laptop)
+
+	report, err := desktop.PullGit()
+	require.NoError(t, err)
+	assert.False(t, report.UpToDate)
+	assert.Equal(t, []string{"lap00005"}, entryCommitIDs(report.Imported))
+	assert.Equal(t, []string{"body0002"}, entryCommitIDs(report.Updated))
+	assert.Equal(t, []string{"old00003"}, entryCommitIDs(report.Removed))
+	require.Len(t, report.Diverged, 1)
+	assert.Equal(t, "Shared entry from the desktop", report.Diverged[0].Local.Message)
+	assert.Equal(t, "Shared entry from the laptop", report.Diverged[0].Remote.Message)
+
+	var output bytes.Buffer
+	WriteGitPullReport(&output, report)
+	assert.Contains(t, output.String(), "Imported 1 entry from Git.\n  lap00005 Laptop entry\n")
+	assert.Contains(t, output.String(), `Diverged: shared01 "Shared entry from the desktop" changed on both devices`)
+
+	shared, err := desktop.GetEntry("shared01")
+	require.NoError(t, err)
+	assert.Equal(t, "Shared entry from the desktop", shared.Message)
+	revisions, err := desktop.EntryRevisions("shared01")
+	require.NoError(t, err)
+	require.NotEmpty(t, revisions)
+	assert.Equal(t, "Shared entry from the laptop", revisions[len(revisions)-1].Message)
+	_, err = desktop.GetEntry("old00003")
+	assert.ErrorIs(t, err, ErrEntryNotFound)
+	body, err := os.ReadFile(filepath.Join(desktop.filesDir(), "body_body0002.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "Second body", string(body))
+	assert.FileExists(t, filepath.Join(desktop.filesDir(), "lap00005_notes.txt"))
+	readme, err := os.ReadFile(filepath.Join(desktop.repositoryDir(), readmeFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(readme), "Laptop entry")
+	pending, err := desktop.PendingEntries(gitDestinationName, false)
+	require.NoError(t, err)
+	assert.NotContains(t, entryCommitIDs(pending), "lap00005", "pulled entries are already in Git")
+
+	assert.Equal(
+		t,
+		gitOutput(t, desktop.repositoryDir(), "rev-parse", "origin/main"),
+		gitOutput(t, desktop.repositoryDir(), "rev-parse", "HEAD^2"),
+		"the merge has the remote branch as a parent",
+	)
+	assert.Equal(
+		t,
+		"Add 1 entry, amend 1 entry",
+		gitOutput(t, desktop.repositoryDir(), "log", "-1", "--format=%s", "HEAD^1"),
+		"local changes are committed before the merge",
+	)
+	again, err := desktop.PullGit()
+	require.NoError(t, err)
+	assert.True(t, again.UpToDate)
+
+	// The merged branch fast-forwards the remote, and the laptop takes the
+	// desktop's changes to entries it has not touched since.
+	pushGit(t, desktop)
+	report, err = laptop.PullGit()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"desk0004"}, entryCommitIDs(report.Imported))
+	assert.Equal(t, []string{"shared01"}, entryCommitIDs(report.Updated))
+	assert.Empty(t, report.Diverged)
+	shared, err = laptop.GetEntry("shared01")
+	require.NoError(t, err)
+	assert.Equal(t, "Shared entry from the desktop", shared.Message)
+}
+
+func TestPullGitRestoresEntryRemovedLocallyButEditedRemotely(t *testing.T) {
+	requireGit(t)
+	setGitIdentity(t)
+	remote := newBareRepository(t)
+
+	laptop, _ := newTestManager(t, Config{SyncToGit: true, GitRemoteURL: remote})
+	require.NoError(t, NewGitManager(laptop.repositoryDir()).Configure(remote))
+	require.NoError(t, laptop.insertEntry(Entry{
+		Date:        time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC),
+		Message:     "Kept entry",
+		IsCommitted: true,
+		CommitID:    "kept0001",
+	}))
+	pushGit(t, laptop)
+
+	desktopDir := t.TempDir()
+	require.NoError(t, NewGitManager(filepath.Join(desktopDir, repositoryDirectory)).Init(remote))
+	desktop := NewManager(Config{DataDir: desktopDir, SyncToGit: true, GitRemoteURL: remote})
+	_, err := desktop.RemoveEntry("kept0001")
+	require.NoError(t, err)
+	gitOutput(t, desktop.repositoryDir(), "add", "--all")
+	gitOutput(t, desktop.repositoryDir(), "commit", "-m", "Remove entry")
+
+	require.NoError(t, laptop.EditEntry("kept0001", "Kept entry, edited", ""))
+	pushGit(t, laptop)
+
+	report, err := desktop.PullGit()
+	require.NoError(t, err)
+	require.Len(t, report.Diverged, 1)
+	assert.Empty(t, report.Diverged[0].Local.CommitID)
+	restored, err := desktop.GetEntry("kept0001")
+	require.NoError(t, err)
+	assert.Equal(t, "Kept entry, edited", restored.Message)
+	parents := gitOutput(t, desktop.repositoryDir(), "log", "-1", "--format=%P")
+	assert.Len(t, strings.Fields(parents), 2, "local commits are merged with the remote branch")
+}
+
+func TestPullGitKeepsLocalFilesOfDivergedEntries(t *testing.T) {
+	requireGit(t)
+	setGitIdentity(t)
+	remote := newBareRepository(t)
+
+	laptop, _ := newTestManager(t, Config{SyncToGit: true, GitRemoteURL: remote})
+	require.NoError(t, NewGitManager(laptop.repositoryDir()).Configure(remote))
+	require.NoError(t, laptop.insertEntry(Entry{
+		Date:        time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC),
+		Message:     "Legacy entry",
+		Files:       []string{"notes.txt"},
+		IsCommitted: true,
+		CommitID:    "legacy07",
+	}))
+	laptopNotes := filepath.Join(laptop.filesDir(), "legacy07_notes.txt")
+	require.NoError(t, os.WriteFile(laptopNotes, []byte("original notes"), 0644))
+	pushGit(t, laptop)
+
+	desktopDir := t.TempDir()
+	require.NoError(t, NewGitManager(filepath.Join(desktopDir, repositoryDirectory)).Init(remote))
+	desktop := NewManager(Config{DataDir: desktopDir, SyncToGit: true, GitRemoteURL: remote})
+	require.NoError(t, desktop.EditEntry("legacy07", "Legacy entry from the desktop", ""))
+
+	require.NoError(t, laptop.EditEntry("legacy07", "Legacy entry from the laptop", ""))
+	require.NoError(t, os.WriteFile(laptopNotes, []byte("laptop notes"), 0644))
+	pushGit(t, laptop)
+
+	report, err := desktop.PullGit()
+	require.NoError(t, err)
+	require.Len(t, report.Diverged, 1)
+	notes, err := os.ReadFile(filepath.Join(desktop.filesDir(), "legacy07_notes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "original notes", string(notes), "the kept entry's file is not replaced")
+
+	revisions, err := desktop.EntryRevisions("legacy07")
+	require.NoError(t, err)
+	require.NotEmpty(t, revisions)
+	revision := revisions[len(revisions)-1]
+	assert.Equal(t, "Legacy entry from the laptop", revision.Message)
+	require.Len(t, revision.Attachments, 1)
+	assert.NotEmpty(t, revision.Attachments[0].SHA256)
+	stored, err := os.ReadFile(storedAttachmentPath(desktop, Entry{Attachments: revision.Attachments}, "notes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "laptop notes", string(stored), "the remote version's file is kept in the object store")
+}
+
+func TestPullGitTakesAttachmentContentChangedRemotely(t *testing.T) {
+	requireGit(t)
+	setGitIdentity(t)
+	remote := newBareRepository(t)
+
+	laptop, laptopRoot := newTestManager(t, Config{SyncToGit: true, GitRemoteURL: remote})
+	require.NoError(t, NewGitManager(laptop.repositoryDir()).Configure(remote))
+	source := filepath.Join(laptopRoot, "notes.txt")
+	require.NoError(t, os.WriteFile(source, []byte("first notes"), 0644))
+	require.NoError(t, laptop.AddFile(source))
+	require.NoError(t, laptop.CommitEntry("Notes entry"))
+	pushGit(t, laptop)
+
+	desktopDir := t.TempDir()
+	require.NoError(t, NewGitManager(filepath.Join(desktopDir, repositoryDirectory)).Init(remote))
+	desktop := NewManager(Config{DataDir: desktopDir, SyncToGit: true, GitRemoteURL: remote})
+
+	require.NoError(t, os.WriteFile(source, []byte("revised notes"), 0644))
+	require.NoError(t, laptop.AddFile(source))
+	require.NoError(t, laptop.AmendLastEntry("Notes entry"))
+	amended, err := laptop.GetLatestEntries(1)
+	require.NoError(t, err)
+	pushGit(t, laptop)
+
+	report, err := desktop.PullGit()
+	require.NoError(t, err)
+	assert.Equal(t, []string{amended[0].CommitID}, entryCommitIDs(report.Updated), "only the attachment content changed")
+	entry, err := desktop.GetEntry(amended[0].CommitID)
+	require.NoError(t, err)
+	assert.Equal(t, amended[0].Attachments, entry.Attachments)
+	content, err := os.ReadFile(storedAttachmentPath(desktop, entry, "notes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "revised notes", string(content))
+}
+
+func TestPullGitKeepsRemoteFilesOutsideEntries(t *testing.T) {
+	requireGit(t)
+	setGitIdentity(t)
+	remote := newBareRepository(t)
+
+	laptop, _ := newTestManager(t, Config{SyncToGit: true, GitRemoteURL: remote})
+	require.NoError(t, NewGitManager(laptop.repositoryDir()).Configure(remote))
+	require.NoError(t, laptop.insertEntry(Entry{
+		Date:        time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC),
+		Message:     "Shared entry",
+		IsCommitted: true,
+		CommitID:    "shared01",
+	}))
+	require.NoError(t, os.WriteFile(filepath.Join(laptop.repositoryDir(), "notes.md"), []byte("shared notes"), 0644))
+	pushGit(t, laptop)
+
+	desktopDir := t.TempDir()
+	require.NoError(t, NewGitManager(filepath.Join(desktopDir, repositoryDirectory)).Init(remote))
+	desktop := NewManager(Config{DataDir: desktopDir, SyncToGit: true, GitRemoteURL: remote})
+	require.NoError(t, desktop.insertEntry(Entry{
+		Date:        time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC),
+		Message:     "Desktop entry",
+		IsCommitted: true,
+		CommitID:    "desk0002",
+	}))
+
+	require.NoError(t, os.WriteFile(filepath.Join(laptop.repositoryDir(), "feed.xml"), []byte("<feed/>"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(laptop.repositoryDir(), "notes.md"), []byte("edited notes"), 0644))
+	require.NoError(t, laptop.EditEntry("shared01", "Shared entry, edited", ""))
+	pushGit(t, laptop)
+
+	_, err := desktop.PullGit()
+	require.NoError(t, err)
+	parents := gitOutput(t, desktop.repositoryDir(), "log", "-1", "--format=%P")
+	require.Len(t, strings.Fields(parents), 2)
+	assert.Equal(t, "<feed/>", gitOutput(t, desktop.repositoryDir(), "show", "HEAD:feed.xml"))
+	assert.Equal(t, "edited notes", gitOutput(t, desktop.repositoryDir(), "show", "HEAD:notes.md"))
+	content, err := os.ReadFile(filepath.Join(desktop.repositoryDir(), "feed.xml"))
+	require.NoError(t, err)
+	assert.Equal(t, "<feed/>", string(content))
+	assert.Empty(t, gitOutput(t, desktop.repositoryDir(), "status", "--porcelain"), "the working tree matches the merge")
+	readme := gitOutput(t, desktop.repositoryDir(), "show", "HEAD:"+readmeFileName)
+	assert.Contains(t, readme, "Desktop entry")
+	assert.Contains(t, readme, "Shared entry, edited")
+}
+
+func TestGitPushKeepsCommittedSyncStateWhenMergeFails(t *testing.T) {
+	requireGit(t)
+	setGitIdentity(t)
+	remote := newBareRepository(t)
+
+	manager, root := newTestManager(t, Config{SyncToGit: true, GitRemoteURL: remote})
+	gitManager := NewGitManager(manager.repositoryDir())
+	require.NoError(t, gitManager.Configure(remote))
+	require.NoError(t, manager.insertEntry(Entry{
+		Date:        time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC),
+		Message:     "Committed entry",
+		IsCommitted: true,
+		CommitID:    "commit01",
+	}))
+	require.NoError(t, gitManager.SetRemote(filepath.Join(root, "missing.git")))
+
+	destination := &GitDestination{}
+	_, err := destination.Configure(manager.Config)
+	require.NoError(t, err)
+	_, err = destination.Push(context.Background(), manager, PushOptions{})
+	require.ErrorContains(t, err, "merge entries from Git")
+
+	assert.Equal(t, "Add Committed entry", gitOutput(t, manager.repositoryDir(), "log", "-1", "--format=%s"))
+	assert.Empty(t, gitOutput(t, manager.repositoryDir(), "status", "--porcelain"), "the committed database is left as it is")
+	pending, err := manager.PendingEntries(gitDestinationName, false)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "committed entries stay recorded")
+
+	require.NoError(t, gitManager.SetRemote(remote))
+	pushGit(t, manager)
+	assert.Equal(
+		t,
+		gitOutput(t, manager.repositoryDir(), "rev-parse", "HEAD"),
+		gitOutput(t, remote, "rev-parse", "main"),
+		"the next push sends the commit",
+	)
+}
+
+func pushGit(t *testing.T, manager *Manager) {
+	t.Helper()
+	destination := &GitDestination{}
+	_, err := destination.Configure(manager.Config)
+	require.NoError(t, err)
+	_, err = destination.Push(context.Background(), manager, PushOptions{})
+	require.NoError(t, err)
+}
+
+func entryCommitIDs(entries []Entry) []string {
+	commitIDs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		commitIDs = append(commitIDs, entry.CommitID)
+	}
+	return commitIDs
+}
+
+func gitOutput(t *testing.T, workDir string, args ...string) string {
+	t.Helper()
+	command := exec.Command("git", args...)
+	command.Dir = workDir
+	output, err := command.CombinedOutput()
+	require.NoError(t, err, "%s", output)
+	return strings.TrimSpace(string(output))
+}
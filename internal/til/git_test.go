@@ -71,6 +71,25 @@ func TestGitConfigureInitializesWithoutFetchingAndUpdatesRemote(t *testing.T) {
 	assert.Equal(t, secondRemote, remote)
 }
 
+func TestGitListFilesKeepsNonASCIINames(t *testing.T) {
+	requireGit(t)
+	setGitIdentity(t)
+
+	worktree := filepath.Join(t.TempDir(), "worktree")
+	manager := NewGitManager(worktree)
+	require.NoError(t, manager.Configure(filepath.Join(t.TempDir(), "remote.git")))
+	files := filepath.Join(worktree, "files")
+	require.NoError(t, os.MkdirAll(files, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(files, "abc12345_café notes.txt"), []byte("notes"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(files, "abc12345_plain.txt"), []byte("plain"), 0644))
+	require.NoError(t, manager.AddAll())
+	require.NoError(t, manager.Commit("Add files"))
+
+	listed, err := manager.ListFiles("HEAD", "files")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"files/abc12345_café notes.txt", "files/abc12345_plain.txt"}, listed)
+}
+
 func TestGitFileURLs(t *testing.T) {
 	rawURL, err := GitHubRawFileURL(
 		"git@github.com:example/learning.git",
@@ -0,0 +1,587 @@
+package til
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// The Markdown subset understood by til: ATX headings, bulleted, numbered
+// and task list items, block quotes, fenced code blocks, thematic breaks, and
+// paragraphs, with bold, italic, strikethrough, code, and link spans inline.
+// Nested lists are flattened.
+
+type markdownBlockKind int
+
+const (
+	markdownParagraph markdownBlockKind = iota
+	markdownHeading
+	markdownBulletedItem
+	markdownNumberedItem
+	markdownTaskItem
+	markdownQuote
+	markdownCode
+	markdownDivider
+)
+
+// markdownBlock holds the raw inline Markdown of a block, or the literal
+// contents of a code block.
+type markdownBlock struct {
+	kind     markdownBlockKind
+	level    int
+	language string
+	checked  bool
+	text     string
+}
+
+type markdownSpan struct {
+	text          string
+	bold          bool
+	italic        bool
+	strikethrough bool
+	code          bool
+	link          string
+}
+
+func (span markdownSpan) sameStyle(other markdownSpan) bool {
+	return span.bold == other.bold &&
+		span.italic == other.italic &&
+		span.strikethrough == other.strikethrough &&
+		span.code == other.code &&
+		span.link == other.link
+}
+
+var (
+	headingPattern      = regexp.MustCompile(`^(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	bulletedItemPattern = regexp.MustCompile(`^[-*+][ \t]+(.*)$`)
+	numberedItemPattern = regexp.MustCompile(`^\d{1,9}[.)][ \t]+(.*)$`)
+	taskPattern         = regexp.MustCompile(`^\[([ xX])\][ \t]+(.*)$`)
+	fencePattern        = regexp.MustCompile("^(`{3,}|~{3,})[ \t]*([^`\\s]*)")
+)
+
+func parseMarkdown(body string) []markdownBlock {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	blocks := []markdownBlock{}
+	paragraph := []string{}
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, markdownBlock{kind: markdownParagraph, text: strings.Join(paragraph, "\n")})
+			paragraph = nil
+		}
+	}
+
+	for index := 0; index < len(lines); index++ {
+		trimmed := strings.TrimSpace(lines[index])
+		if match := fencePattern.FindStringSubmatch(trimmed); match != nil {
+			flush()
+			fence := match[1]
+			code := []string{}
+			for index++; index < len(lines); index++ {
+				closing := strings.TrimSpace(lines[index])
+				if strings.HasPrefix(closing, fence) && strings.Trim(closing, fence[:1]) == "" {
+					break
+				}
+				code = append(code, lines[index])
+			}
+			blocks = append(blocks, markdownBlock{
+				kind:     markdownCode,
+				language: match[2],
+				text:     strings.Join(code, "\n"),
+			})
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flush()
+		case isThematicBreak(trimmed):
+			flush()
+			blocks = append(blocks, markdownBlock{kind: markdownDivider})
+		case headingPattern.MatchString(trimmed):
+			flush()
+			match := headingPattern.FindStringSubmatch(trimmed)
+			blocks = append(blocks, markdownBlock{kind: markdownHeading, level: len(match[1]), text: match[2]})
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			quote := []string{quoteLine(trimmed)}
+			for index+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[index+1]), ">") {
+				index++
+				quote = append(quote, quoteLine(strings.TrimSpace(lines[index])))
+			}
+			blocks = append(blocks, markdownBlock{kind: markdownQuote, text: strings.Join(quote, "\n")})
+		default:
+			item, ok := parseListItem(trimmed)
+			if !ok {
+				paragraph = append(paragraph, trimmed)
+				continue
+			}
+			flush()
+			for index+1 < len(lines) && isListContinuation(lines[index+1]) {
+				index++
+				item.text += "\n" + strings.TrimSpace(lines[index])
+			}
+			blocks = append(blocks, item)
+		}
+	}
+	flush()
+	return blocks
+}
+
+func parseListItem(line string) (markdownBlock, bool) {
+	if match := bulletedItemPattern.FindStringSubmatch(line); match != nil {
+		if task := taskPattern.FindStringSubmatch(match[1]); task != nil {
+			return markdownBlock{kind: markdownTaskItem, checked: task[1] != " ", text: task[2]}, true
+		}
+		return markdownBlock{kind: markdownBulletedItem, text: match[1]}, true
+	}
+	if match := numberedItemPattern.FindStringSubmatch(line); match != nil {
+		return markdownBlock{kind: markdownNumberedItem, text: match[1]}, true
+	}
+	return markdownBlock{}, false
+}
+
+// isListContinuation reports whether a line continues the text of the list item above it.
+func isListContinuation(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || isMarkdownBlockStart(trimmed) {
+		return false
+	}
+	_, isItem := parseListItem(trimmed)
+	return !isItem
+}
+
+func isMarkdownBlockStart(line string) bool {
+	return isThematicBreak(line) ||
+		headingPattern.MatchString(line) ||
+		strings.HasPrefix(line, ">") ||
+		fencePattern.MatchString(line)
+}
+
+func isThematicBreak(line string) bool {
+	compact := strings.ReplaceAll(strings.ReplaceAll(line, " ", ""), "\t", "")
+	if len(compact) < 3 {
+		return false
+	}
+	marker := compact[0]
+	return (marker == '-' || marker == '*' || marker == '_') && strings.Trim(compact, string(marker)) == ""
+}
+
+func quoteLine(line string) string {
+	line = strings.TrimPrefix(line, ">")
+	return strings.TrimPrefix(line, " ")
+}
+
+// parseInline splits inline Markdown into styled spans.
+func parseInline(text string) []markdownSpan {
+	return mergeSpans(parseInlineRunes([]rune(text), markdownSpan{}))
+}
+
+func parseInlineRunes(runes []rune, style markdownSpan) []markdownSpan {
+	spans := []markdownSpan{}
+	var literal strings.Builder
+	emit := func() {
+		if literal.Len() > 0 {
+			span := style
+			span.text = literal.String()
+			spans = append(spans, span)
+			literal.Reset()
+		}
+	}
+	nested := func(start, end int, nestedStyle markdownSpan) {
+		emit()
+		spans = append(spans, parseInlineRunes(runes[start:end], nestedStyle)...)
+	}
+
+	for index := 0; index < len(runes); {
+		character := runes[index]
+		switch {
+		case character == '\\' && index+1 < len(runes) && isMarkdownPunctuation(runes[index+1]):
+			literal.WriteRune(runes[index+1])
+			index += 2
+			continue
+		case character == '`':
+			length := runLength(runes, index, '`')
+			if end := findBacktickRun(runes, index+length, length); end >= 0 {
+				emit()
+				span := style
+				span.code = true
+				span.text = trimCodeSpan(string(runes[index+length : end]))
+				spans = append(spans, span)
+				index = end + length
+				continue
+			}
+			literal.WriteString(string(runes[index : index+length]))
+			index += length
+			continue
+		case character == '[':
+			if textEnd, url, end, ok := parseInlineLink(runes, index); ok {
+				linked := style
+				linked.link = url
+				nested(index+1, textEnd, linked)
+				index = end
+				continue
+			}
+		case character == '<':
+			if url, end, ok := parseAutolink(runes, index); ok {
+				emit()
+				span := style
+				span.link = url
+				span.text = url
+				spans = append(spans, span)
+				index = end
+				continue
+			}
+		case character == '~' && index+1 < len(runes) && runes[index+1] == '~':
+			if end := findClosingDelimiter(runes, index+2, "~~"); end >= 0 {
+				struck := style
+				struck.strikethrough = true
+				nested(index+2, end, struck)
+				index = end + 2
+				continue
+			}
+		case character == '*' || character == '_':
+			delimiter := string(character)
+			if index+1 < len(runes) && runes[index+1] == character {
+				delimiter += delimiter
+			}
+			if canOpenEmphasis(runes, index, len(delimiter)) {
+				if end := findClosingDelimiter(runes, index+len(delimiter), delimiter); end >= 0 {
+					emphasized := style
+					if len(delimiter) == 2 {
+						emphasized.bold = true
+					} else {
+						emphasized.italic = true
+					}
+					nested(index+len(delimiter), end, emphasized)
+					index = end + len(delimiter)
+					continue
+				}
+			}
+			literal.WriteString(delimiter)
+			index += len(delimiter)
+			continue
+		}
+		literal.WriteRune(character)
+		index++
+	}
+	emit()
+	return spans
+}
+
+func canOpenEmphasis(runes []rune, index, length int) bool {
+	next := index + length
+	if next >= len(runes) || unicode.IsSpace(runes[next]) {
+		return false
+	}
+	if runes[index] == '_' && index > 0 && isWordRune(runes[index-1]) {
+		return false
+	}
+	return true
+}
+
+// findClosingDelimiter returns the index of the delimiter closing a span that
+// starts at start, skipping escapes and code spans.
+func findClosingDelimiter(runes []rune, start int, delimiter string) int {
+	marker := []rune(delimiter)
+	for index := start; index < len(runes); index++ {
+		switch runes[index] {
+		case '\\':
+			index++
+			continue
+		case '`':
+			length := runLength(runes, index, '`')
+			if end := findBacktickRun(runes, index+length, length); end >= 0 {
+				index = end + length - 1
+				continue
+			}
+			index += length - 1
+			continue
+		}
+		if !hasRunesAt(runes, index, marker) {
+			continue
+		}
+		run := runLength(runes, index, marker[0])
+		if len(marker) == 1 && run > 1 {
+			// Part of a double delimiter; skip the whole run.
+			index += run - 1
+			continue
+		}
+		if index == start || unicode.IsSpace(runes[index-1]) {
+			index += run - 1
+			continue
+		}
+		if len(marker) == 2 && run > 2 {
+			// Prefer the last two characters of a longer run, as in ***bold italic***.
+			index += run - 2
+		}
+		after := index + len(marker)
+		if marker[0] == '_' && after < len(runes) && isWordRune(runes[after]) {
+			continue
+		}
+		return index
+	}
+	return -1
+}
+
+func parseInlineLink(runes []rune, start int) (int, string, int, bool) {
+	depth := 0
+	for index := start + 1; index < len(runes); index++ {
+		switch runes[index] {
+		case '\\':
+			index++
+		case '[':
+			depth++
+		case ']':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if index+1 >= len(runes) || runes[index+1] != '(' {
+				return 0, "", 0, false
+			}
+			url, end, ok := parseLinkDestination(runes, index+2)
+			if !ok {
+				return 0, "", 0, false
+			}
+			return index, url, end, true
+		}
+	}
+	return 0, "", 0, false
+}
+
+// parseLinkDestination reads a link target up to its closing parenthesis.
+// Targets containing spaces or parentheses are wrapped in angle brackets.
+func parseLinkDestination(runes []rune, start int) (string, int, bool) {
+	if start < len(runes) && runes[start] == '<' {
+		for index := start + 1; index < len(runes); index++ {
+			if runes[index] == '>' {
+				if index+1 < len(runes) && runes[index+1] == ')' && index > start+1 {
+					return string(runes[start+1 : index]), index + 2, true
+				}
+				return "", 0, false
+			}
+		}
+		return "", 0, false
+	}
+	for index := start; index < len(runes); index++ {
+		switch runes[index] {
+		case ')':
+			url := string(runes[start:index])
+			if url == "" {
+				return "", 0, false
+			}
+			return url, index + 1, true
+		case ' ', '\t', '\n':
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
+
+func parseAutolink(runes []rune, start int) (string, int, bool) {
+	for index := start + 1; index < len(runes); index++ {
+		switch runes[index] {
+		case '>':
+			url := string(runes[start+1 : index])
+			if isAutolinkURL(url) {
+				return url, index + 1, true
+			}
+			return "", 0, false
+		case ' ', '\t', '\n', '<':
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
+
+func isAutolinkURL(value string) bool {
+	for _, scheme := range []string{"http://", "https://", "mailto:"} {
+		if strings.HasPrefix(strings.ToLower(value), scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func findBacktickRun(runes []rune, start, length int) int {
+	for index := start; index < len(runes); {
+		if runes[index] != '`' {
+			index++
+			continue
+		}
+		run := runLength(runes, index, '`')
+		if run == length {
+			return index
+		}
+		index += run
+	}
+	return -1
+}
+
+func trimCodeSpan(code string) string {
+	if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+		return code[1 : len(code)-1]
+	}
+	return code
+}
+
+func runLength(runes []rune, start int, character rune) int {
+	end := start
+	for end < len(runes) && runes[end] == character {
+		end++
+	}
+	return end - start
+}
+
+func hasRunesAt(runes []rune, index int, marker []rune) bool {
+	if index+len(marker) > len(runes) {
+		return false
+	}
+	for offset, character := range marker {
+		if runes[index+offset] != character {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(character rune) bool {
+	return unicode.IsLetter(character) || unicode.IsDigit(character)
+}
+
+func isMarkdownPunctuation(character rune) bool {
+	return character < unicode.MaxASCII && unicode.IsPunct(character) || strings.ContainsRune("`^|~<>=+$", character)
+}
+
+func mergeSpans(spans []markdownSpan) []markdownSpan {
+	merged := []markdownSpan{}
+	for _, span := range spans {
+		if span.text == "" {
+			continue
+		}
+		if last := len(merged) - 1; last >= 0 && merged[last].sameStyle(span) {
+			merged[last].text += span.text
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// renderInline writes spans back as inline Markdown, escaping literal text so
+// that parseInline reproduces the same spans.
+func renderInline(spans []markdownSpan) string {
+	var output strings.Builder
+	for start := 0; start < len(spans); {
+		end := start + 1
+		for end < len(spans) && spans[end].link == spans[start].link {
+			end++
+		}
+		var group strings.Builder
+		for _, span := range spans[start:end] {
+			group.WriteString(renderSpan(span))
+		}
+		if link := spans[start].link; link != "" {
+			if group.String() == escapeInlineMarkdown(link) && isAutolinkURL(link) {
+				output.WriteString("<" + link + ">")
+			} else {
+				output.WriteString("[" + group.String() + "](" + linkDestination(link) + ")")
+			}
+		} else {
+			output.WriteString(group.String())
+		}
+		start = end
+	}
+	return output.String()
+}
+
+func renderSpan(span markdownSpan) string {
+	if span.code {
+		fence := strings.Repeat("`", longestRun(span.text, '`')+1)
+		text := span.text
+		if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") ||
+			(strings.HasPrefix(text, " ") && strings.HasSuffix(text, " ") && strings.Trim(text, " ") != "") {
+			text = " " + text + " "
+		}
+		return fence + text + fence
+	}
+
+	// Emphasis markers cannot sit next to whitespace, so keep it outside them.
+	core := strings.TrimSpace(span.text)
+	if core == "" || (!span.bold && !span.italic && !span.strikethrough) {
+		return escapeInlineMarkdown(span.text)
+	}
+	leading := span.text[:strings.Index(span.text, core)]
+	trailing := span.text[len(leading)+len(core):]
+	text := escapeInlineMarkdown(core)
+	if span.italic {
+		text = "*" + text + "*"
+	}
+	if span.bold {
+		text = "**" + text + "**"
+	}
+	if span.strikethrough {
+		text = "~~" + text + "~~"
+	}
+	return escapeInlineMarkdown(leading) + text + escapeInlineMarkdown(trailing)
+}
+
+func escapeInlineMarkdown(text string) string {
+	runes := []rune(text)
+	var output strings.Builder
+	for index, character := range runes {
+		escape := false
+		switch character {
+		case '\\', '*', '`', '[', ']':
+			escape = true
+		case '~':
+			escape = (index > 0 && runes[index-1] == '~') || (index+1 < len(runes) && runes[index+1] == '~')
+		case '_':
+			escape = !(index > 0 && isWordRune(runes[index-1]) && index+1 < len(runes) && isWordRune(runes[index+1]))
+		case '<':
+			escape = isAutolinkURL(string(runes[index+1:]))
+		}
+		if escape {
+			output.WriteRune('\\')
+		}
+		output.WriteRune(character)
+	}
+	return output.String()
+}
+
+// escapeBlockStart keeps a line of paragraph text from being read as the
+// start of another kind of block.
+func escapeBlockStart(line string) string {
+	trimmed := strings.TrimLeft(line, " \t")
+	if trimmed == "" {
+		return line
+	}
+	if isMarkdownBlockStart(trimmed) || bulletedItemPattern.MatchString(trimmed) {
+		return "\\" + trimmed
+	}
+	if numberedItemPattern.MatchString(trimmed) {
+		digits := strings.IndexFunc(trimmed, func(character rune) bool { return !unicode.IsDigit(character) })
+		return trimmed[:digits] + "\\" + trimmed[digits:]
+	}
+	return line
+}
+
+func linkDestination(url string) string {
+	if strings.ContainsAny(url, " ()") {
+		return "<" + url + ">"
+	}
+	return url
+}
+
+func longestRun(text string, character rune) int {
+	longest, current := 0, 0
+	for _, value := range text {
+		if value == character {
+			current++
+			longest = max(longest, current)
+		} else {
+			current = 0
+		}
+	}
+	return longest
+}
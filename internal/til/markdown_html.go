@@ -0,0 +1,140 @@
+package til
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+// markdownHTML renders an entry body as HTML. All text is escaped and links
+// are kept only when they are relative or use http, https, or mailto.
+// Headings move down a level because the page title is the only h1.
+func markdownHTML(body string) template.HTML {
+	var output strings.Builder
+	openList, closeList := "", ""
+	for _, block := range parseMarkdown(strings.TrimSpace(body)) {
+		if list, end := markdownHTMLList(block.kind); list != openList {
+			output.WriteString(closeList)
+			output.WriteString(list)
+			openList, closeList = list, end
+		}
+
+		switch block.kind {
+		case markdownHeading:
+			level := min(block.level+1, 6)
+			fmt.Fprintf(&output, "<h%d>%s</h%d>\n", level, inlineHTML(block.text), level)
+		case markdownBulletedItem, markdownNumberedItem:
+			fmt.Fprintf(&output, "<li>%s</li>\n", inlineHTML(block.text))
+		case markdownTaskItem:
+			checked := ""
+			if block.checked {
+				checked = " checked"
+			}
+			fmt.Fprintf(&output, "<li><input type=\"checkbox\" disabled%s> %s</li>\n", checked, inlineHTML(block.text))
+		case markdownQuote:
+			fmt.Fprintf(&output, "<blockquote><p>%s</p></blockquote>\n", inlineHTML(block.text))
+		case markdownCode:
+			class := ""
+			if block.language != "" {
+				class = fmt.Sprintf(" class=\"language-%s\"", html.EscapeString(block.language))
+			}
+			fmt.Fprintf(&output, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(block.text))
+		case markdownDivider:
+			output.WriteString("<hr>\n")
+		default:
+			fmt.Fprintf(&output, "<p>%s</p>\n", inlineHTML(block.text))
+		}
+	}
+	output.WriteString(closeList)
+	return template.HTML(output.String())
+}
+
+// markdownHTMLList returns the tags that open and close the list a block
+// belongs to, or empty strings when it is not a list item.
+func markdownHTMLList(kind markdownBlockKind) (string, string) {
+	switch kind {
+	case markdownBulletedItem:
+		return "<ul>\n", "</ul>\n"
+	case markdownNumberedItem:
+		return "<ol>\n", "</ol>\n"
+	case markdownTaskItem:
+		return "<ul class=\"tasks\">\n", "</ul>\n"
+	}
+	return "", ""
+}
+
+func inlineHTML(text string) string {
+	spans := parseInline(text)
+	var output strings.Builder
+	for start := 0; start < len(spans); {
+		end := start + 1
+		for end < len(spans) && spans[end].link == spans[start].link {
+			end++
+		}
+		var group strings.Builder
+		for _, span := range spans[start:end] {
+			group.WriteString(spanHTML(span))
+		}
+		if isSafeLink(spans[start].link) {
+			fmt.Fprintf(&output, "<a href=\"%s\">%s</a>", html.EscapeString(spans[start].link), group.String())
+		} else {
+			output.WriteString(group.String())
+		}
+		start = end
+	}
+	return output.String()
+}
+
+func spanHTML(span markdownSpan) string {
+	text := html.EscapeString(span.text)
+	if span.code {
+		return "<code>" + text + "</code>"
+	}
+	if span.italic {
+		text = "<em>" + text + "</em>"
+	}
+	if span.bold {
+		text = "<strong>" + text + "</strong>"
+	}
+	if span.strikethrough {
+		text = "<del>" + text + "</del>"
+	}
+	return text
+}
+
+func isSafeLink(link string) bool {
+	if link == "" {
+		return false
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// markdownPlainText returns the text of an entry body without Markdown
+// syntax, one block per line.
+func markdownPlainText(body string) string {
+	lines := []string{}
+	for _, block := range parseMarkdown(strings.TrimSpace(body)) {
+		if block.kind == markdownCode {
+			lines = append(lines, block.text)
+			continue
+		}
+		var line strings.Builder
+		for _, span := range parseInline(block.text) {
+			line.WriteString(span.text)
+		}
+		if line.Len() > 0 {
+			lines = append(lines, line.String())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
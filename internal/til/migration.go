@@ -89,6 +89,16 @@ func (m *Manager) MigrateToSQL() error {
 		}
 	}
 
+	db, err := m.openDatabase()
+	if err != nil {
+		return err
+	}
+	err = m.migrateAttachmentObjects(db)
+	db.Close()
+	if err != nil {
+		return err
+	}
+
 	if m.Config.SyncToGit {
 		if err := m.RefreshReadme(); err != nil {
 			return err
@@ -172,6 +182,11 @@ func validateAndAssignCommitIDs(records []legacyEntry) error {
 		if record.Message == "" {
 			return fmt.Errorf("legacy entry %d has no message", i+1)
 		}
+		tags, err := normalizeTags(record.Tags)
+		if err != nil {
+			return fmt.Errorf("legacy entry %d: %w", i+1, err)
+		}
+		record.Tags = tags
 
 		candidate := strings.TrimSpace(record.CommitID)
 		if isSafeCommitID(candidate) {
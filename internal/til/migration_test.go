@@ -22,6 +22,7 @@ func TestMigrateYAMLRepositoryAutomatically(t *testing.T) {
 		Message:      "Migrated YAML",
 		MessageBody:  "Stored body",
 		Files:        []string{"example.txt"},
+		Tags:         []string{"YAML", "#go"},
 		IsCommitted:  true,
 		NotionSynced: true,
 		CommitID:     "../unsafe",
@@ -46,8 +47,9 @@ func TestMigrateYAMLRepositoryAutomatically(t *testing.T) {
 	assert.Equal(t, "Migrated YAML", entries[0].Message)
 	assert.Equal(t, "Stored body", entries[0].MessageBody)
 	assert.True(t, entries[0].NotionSynced)
+	assert.Equal(t, []string{"go", "yaml"}, entries[0].Tags)
 	assert.NotEqual(t, "../unsafe", entries[0].CommitID)
-	assert.FileExists(t, filepath.Join(files, entries[0].CommitID+"_example.txt"))
+	assert.FileExists(t, storedAttachmentPath(manager, entries[0], "example.txt"))
 	assert.FileExists(t, filepath.Join(files, "body_"+entries[0].CommitID+".md"))
 	assert.NoFileExists(t, filepath.Join(files, "2025-02-04_example.txt"))
 	assert.NoFileExists(t, filepath.Join(repository, "unsafe_example.txt"))
@@ -95,6 +97,6 @@ func TestMigrateDuplicateLegacyCommitIDsPreservesCanonicalAssets(t *testing.T) {
 	require.Len(t, entries, 2)
 	assert.NotEqual(t, entries[0].CommitID, entries[1].CommitID)
 	for _, entry := range entries {
-		assert.FileExists(t, filepath.Join(files, entry.CommitID+"_shared.txt"))
+		assert.FileExists(t, storedAttachmentPath(manager, entry, "shared.txt"))
 	}
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/jomei/notionapi"
@@ -22,6 +24,21 @@ type NotionClient struct {
 	dbID         notionapi.DatabaseID
 	gitRemoteURL string
 	gitBranch    string
+	properties   NotionProperties
+}
+
+// NotionPage is a database page as seen by a pull. Entry holds the fields read
+// from page properties; bodies are fetched separately with PageMarkdown.
+type NotionPage struct {
+	ID             string
+	Entry          Entry
+	Attachments    []NotionAttachment
+	LastEditedTime time.Time
+}
+
+type NotionAttachment struct {
+	Name string
+	URL  string
 }
 
 type NotionClientOption func(*NotionClient)
@@ -33,9 +50,16 @@ func WithGitAttachments(remoteURL, branch string) NotionClientOption {
 	}
 }
 
+// WithNotionProperties sets the database properties entry fields are mapped to.
+func WithNotionProperties(properties NotionProperties) NotionClientOption {
+	return func(client *NotionClient) {
+		client.properties = properties
+	}
+}
+
 func NewNotionClient(apiKey string, dbID string, options ...NotionClientOption) *NotionClient {
 	client := &NotionClient{
-		client: notionapi.NewClient(notionapi.Token(apiKey)),
+		client: newNotionAPIClient(apiKey, newNotionTransport(http.DefaultTransport)),
 		dbID:   notionapi.DatabaseID(dbID),
 	}
 	for _, option := range options {
@@ -44,107 +68,309 @@ func NewNotionClient(apiKey string, dbID string, options ...NotionClientOption)
 	return client
 }
 
-func (nc *NotionClient) PushEntry(ctx context.Context, entry Entry, dataDir string) error {
+// NewNotionClientFromConfig creates a client for the configured database.
+// Attachments link to branch in the Git remote when Git sync is enabled.
+func NewNotionClientFromConfig(config Config, branch string) (*NotionClient, error) {
+	if config.NotionAPIKeyLoadError != nil {
+		return nil, fmt.Errorf(
+			"Notion API key is unavailable: %w; run 'til config edit'",
+			config.NotionAPIKeyLoadError,
+		)
+	}
+	if strings.TrimSpace(config.NotionAPIKey) == "" {
+		return nil, errors.New("Notion API key is empty; run 'til config edit'")
+	}
+
+	options := []NotionClientOption{WithNotionProperties(config.NotionProperties)}
+	if config.SyncToGit {
+		options = append(options, WithGitAttachments(config.GitRemoteURL, branch))
+	}
+	return NewNotionClient(config.NotionAPIKey, config.NotionDBID, options...), nil
+}
+
+// newNotionAPIClient sends requests through transport, normally a
+// notionTransport. The library's own 429 handling is turned off because it
+// resends an already consumed request body.
+func newNotionAPIClient(apiKey string, transport http.RoundTripper) *notionapi.Client {
+	return notionapi.NewClient(
+		notionapi.Token(apiKey),
+		notionapi.WithHTTPClient(&http.Client{Transport: transport}),
+		notionapi.WithRetry(1),
+	)
+}
+
+// PushEntry publishes an entry and returns the ID of its Notion page. Entries
+// with a recorded page are updated in place; a new page is created when that
+// page was deleted or archived in Notion.
+func (nc *NotionClient) PushEntry(ctx context.Context, entry Entry, dataDir string) (string, error) {
 	if nc.client == nil {
-		return errors.New("Notion client not initialized")
+		return "", errors.New("Notion client not initialized")
 	}
 	if strings.TrimSpace(string(nc.dbID)) == "" {
-		return errors.New("Notion database ID is empty")
+		return "", errors.New("Notion database ID is empty")
 	}
 	if strings.TrimSpace(entry.Message) == "" {
-		return errors.New("entry message is empty")
+		return "", errors.New("entry message is empty")
 	}
 	if utf8.RuneCountInString(entry.Message) > notionTextLimit {
-		return fmt.Errorf("entry message exceeds Notion's %d-character title limit", notionTextLimit)
+		return "", fmt.Errorf("entry message exceeds Notion's %d-character title limit", notionTextLimit)
 	}
 
-	properties := notionapi.Properties{
-		"TIL": notionapi.TitleProperty{
-			Title: []notionapi.RichText{{
-				Type: notionapi.ObjectTypeText,
-				Text: &notionapi.Text{Content: entry.Message},
-			}},
-		},
+	properties, err := nc.pageProperties(entry, dataDir)
+	if err != nil {
+		return "", err
 	}
 
-	if len(entry.Files) > 0 {
-		files, err := nc.attachmentFiles(entry, dataDir)
+	children := notionBodyBlocks(entry.MessageBody)
+
+	if entry.NotionPageID != "" {
+		updated, err := nc.updatePage(ctx, notionapi.PageID(entry.NotionPageID), properties, children)
 		if err != nil {
-			return err
+			return "", err
 		}
-		properties["Attachments"] = notionapi.FilesProperty{
-			Type:  notionapi.PropertyTypeFiles,
-			Files: files,
+		if updated {
+			return entry.NotionPageID, nil
 		}
 	}
 
-	children := notionBodyBlocks(entry.MessageBody)
-	if len(children) > notionChildrenLimit {
-		return fmt.Errorf(
-			"entry body requires %d Notion blocks; the API allows %d per page creation",
-			len(children),
-			notionChildrenLimit,
-		)
-	}
-
 	request := &notionapi.PageCreateRequest{
 		Parent: notionapi.Parent{
 			Type:       notionapi.ParentTypeDatabaseID,
 			DatabaseID: nc.dbID,
 		},
 		Properties: properties,
-		Children:   children,
+		Children:   children[:min(len(children), notionChildrenLimit)],
+	}
+	page, err := nc.client.Page.Create(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("create Notion page: %w", err)
+	}
+	if len(children) > notionChildrenLimit {
+		if err := nc.appendBlocks(ctx, notionapi.BlockID(page.ID), children[notionChildrenLimit:]); err != nil {
+			// Do not leave a page with a partial body behind. The rollback runs
+			// even when ctx was cancelled part way through.
+			if archiveErr := nc.ArchivePage(context.WithoutCancel(ctx), page.ID.String()); archiveErr != nil {
+				return "", errors.Join(err, archiveErr)
+			}
+			return "", err
+		}
+	}
+	return page.ID.String(), nil
+}
+
+// ArchivePage moves a page to the Notion trash. Pages that no longer exist are ignored.
+func (nc *NotionClient) ArchivePage(ctx context.Context, pageID string) error {
+	if nc.client == nil {
+		return errors.New("Notion client not initialized")
+	}
+	_, err := nc.client.Page.Update(ctx, notionapi.PageID(pageID), &notionapi.PageUpdateRequest{
+		Properties: notionapi.Properties{},
+		Archived:   true,
+	})
+	if err != nil && !isNotionNotFound(err) {
+		return fmt.Errorf("archive Notion page: %w", err)
+	}
+	return nil
+}
+
+// updatePage replaces the properties and body of an existing page. It reports
+// false when the page is gone and has to be recreated.
+func (nc *NotionClient) updatePage(
+	ctx context.Context,
+	pageID notionapi.PageID,
+	properties notionapi.Properties,
+	children []notionapi.Block,
+) (bool, error) {
+	page, err := nc.client.Page.Get(ctx, pageID)
+	if err != nil {
+		if isNotionNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("read Notion page: %w", err)
+	}
+	if page.Archived {
+		return false, nil
+	}
+
+	// Clear attachments that were removed locally.
+	attachments := nc.properties.withDefaults().Attachments
+	if _, ok := page.Properties[attachments]; ok {
+		if _, set := properties[attachments]; !set {
+			properties[attachments] = notionapi.FilesProperty{
+				Type:  notionapi.PropertyTypeFiles,
+				Files: []notionapi.File{},
+			}
+		}
+	}
+	if _, err := nc.client.Page.Update(ctx, pageID, &notionapi.PageUpdateRequest{
+		Properties: properties,
+	}); err != nil {
+		return false, fmt.Errorf("update Notion page: %w", err)
+	}
+	if err := nc.replacePageBlocks(ctx, notionapi.BlockID(pageID), children); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (nc *NotionClient) replacePageBlocks(
+	ctx context.Context,
+	pageID notionapi.BlockID,
+	children []notionapi.Block,
+) error {
+	// Collect every block before changing the page so pagination cursors
+	// stay valid.
+	existing := []notionapi.BlockID{}
+	pagination := &notionapi.Pagination{PageSize: 100}
+	for {
+		response, err := nc.client.Block.GetChildren(ctx, pageID, pagination)
+		if err != nil {
+			return fmt.Errorf("read Notion page content: %w", err)
+		}
+		for _, block := range response.Results {
+			existing = append(existing, block.GetID())
+		}
+		if !response.HasMore || response.NextCursor == "" {
+			break
+		}
+		pagination.StartCursor = notionapi.Cursor(response.NextCursor)
+	}
+
+	// The new content is written before the old is removed, so a failed
+	// push leaves the previous body on the page rather than an empty one.
+	if err := nc.appendBlocks(ctx, pageID, children); err != nil {
+		return err
 	}
-	if _, err := nc.client.Page.Create(ctx, request); err != nil {
-		return fmt.Errorf("create Notion page: %w", err)
+	for _, blockID := range existing {
+		if _, err := nc.client.Block.Delete(ctx, blockID); err != nil && !isNotionNotFound(err) {
+			return fmt.Errorf("remove Notion page content: %w", err)
+		}
 	}
 	return nil
 }
 
+// appendBlocks adds blocks to the end of a page in batches of
+// notionChildrenLimit, the most Notion accepts per request.
+func (nc *NotionClient) appendBlocks(
+	ctx context.Context,
+	pageID notionapi.BlockID,
+	children []notionapi.Block,
+) error {
+	for start := 0; start < len(children); start += notionChildrenLimit {
+		batch := children[start:min(start+notionChildrenLimit, len(children))]
+		if _, err := nc.client.Block.AppendChildren(ctx, pageID, &notionapi.AppendBlockChildrenRequest{
+			Children: batch,
+		}); err != nil {
+			return fmt.Errorf("write Notion page content: %w", err)
+		}
+	}
+	return nil
+}
+
+func isNotionNotFound(err error) bool {
+	var apiError *notionapi.Error
+	return errors.As(err, &apiError) &&
+		(apiError.Status == http.StatusNotFound || apiError.Code == "object_not_found")
+}
+
 func (nc *NotionClient) GetEntries(ctx context.Context, limit int) ([]Entry, error) {
 	if nc.client == nil {
 		return nil, errors.New("Notion client not initialized")
 	}
 
 	entries := []Entry{}
-	var cursor notionapi.Cursor
+	err := nc.queryPages(ctx, notionapi.SortOrderDESC, func(page notionapi.Page) bool {
+		entry, ok := entryFromNotionPage(page, nc.properties)
+		if ok {
+			entries = append(entries, entry)
+		}
+		return limit <= 0 || len(entries) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// QueryPages lists every page in the database, oldest first, without page bodies.
+func (nc *NotionClient) QueryPages(ctx context.Context) ([]NotionPage, error) {
+	if nc.client == nil {
+		return nil, errors.New("Notion client not initialized")
+	}
+
+	pages := []NotionPage{}
+	err := nc.queryPages(ctx, notionapi.SortOrderASC, func(page notionapi.Page) bool {
+		entry, ok := entryFromNotionPage(page, nc.properties)
+		if !ok {
+			return true
+		}
+		pages = append(pages, NotionPage{
+			ID:             entry.NotionPageID,
+			Entry:          entry,
+			Attachments:    notionAttachments(page, nc.properties),
+			LastEditedTime: page.LastEditedTime,
+		})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// PageMarkdown reads a page's blocks and converts them into an entry body.
+func (nc *NotionClient) PageMarkdown(ctx context.Context, pageID string) (string, error) {
+	if nc.client == nil {
+		return "", errors.New("Notion client not initialized")
+	}
+
+	blocks := []notionapi.Block{}
+	pagination := &notionapi.Pagination{PageSize: 100}
 	for {
-		pageSize := 100
-		if limit > 0 && limit-len(entries) < pageSize {
-			pageSize = limit - len(entries)
+		response, err := nc.client.Block.GetChildren(ctx, notionapi.BlockID(pageID), pagination)
+		if err != nil {
+			return "", fmt.Errorf("read Notion page content: %w", err)
 		}
-		if pageSize <= 0 {
+		blocks = append(blocks, response.Results...)
+		if !response.HasMore || response.NextCursor == "" {
 			break
 		}
+		pagination.StartCursor = notionapi.Cursor(response.NextCursor)
+	}
+	return notionBlocksMarkdown(blocks), nil
+}
 
+// queryPages visits database pages in creation order until visit returns false.
+func (nc *NotionClient) queryPages(
+	ctx context.Context,
+	direction notionapi.SortOrder,
+	visit func(notionapi.Page) bool,
+) error {
+	var cursor notionapi.Cursor
+	for {
 		query := notionapi.DatabaseQueryRequest{
 			Sorts: []notionapi.SortObject{{
 				Timestamp: notionapi.TimestampCreated,
-				Direction: notionapi.SortOrderDESC,
+				Direction: direction,
 			}},
 			StartCursor: cursor,
-			PageSize:    pageSize,
+			PageSize:    100,
 		}
 		response, err := nc.client.Database.Query(ctx, nc.dbID, &query)
 		if err != nil {
-			return nil, fmt.Errorf("query Notion database: %w", err)
+			return fmt.Errorf("query Notion database: %w", err)
 		}
 
 		for _, page := range response.Results {
-			entry, ok := entryFromNotionPage(page)
-			if ok {
-				entries = append(entries, entry)
-			}
-			if limit > 0 && len(entries) >= limit {
-				return entries, nil
+			if !visit(page) {
+				return nil
 			}
 		}
 		if !response.HasMore || response.NextCursor == "" {
-			break
+			return nil
 		}
 		cursor = response.NextCursor
 	}
-	return entries, nil
 }
 
 func (nc *NotionClient) IsEntrySynced(ctx context.Context, entry Entry) (bool, error) {
@@ -163,7 +389,7 @@ func (nc *NotionClient) IsEntrySynced(ctx context.Context, entry Entry) (bool, e
 		}
 
 		for _, page := range response.Results {
-			title, ok := page.Properties["TIL"].(notionapi.TitleProperty)
+			title, ok := titleProperty(page.Properties[nc.properties.withDefaults().Title])
 			if ok && notionTitle(title) == entry.Message {
 				return true, nil
 			}
@@ -175,6 +401,20 @@ func (nc *NotionClient) IsEntrySynced(ctx context.Context, entry Entry) (bool, e
 	}
 }
 
+// AttachmentURLs resolves the public URLs PushEntry would publish for an
+// entry's attachments.
+func (nc *NotionClient) AttachmentURLs(entry Entry, dataDir string) ([]NotionAttachment, error) {
+	files, err := nc.attachmentFiles(entry, dataDir)
+	if err != nil {
+		return nil, err
+	}
+	attachments := make([]NotionAttachment, 0, len(files))
+	for _, file := range files {
+		attachments = append(attachments, NotionAttachment{Name: file.Name, URL: file.External.URL})
+	}
+	return attachments, nil
+}
+
 func (nc *NotionClient) attachmentFiles(entry Entry, dataDir string) ([]notionapi.File, error) {
 	if strings.TrimSpace(nc.gitRemoteURL) == "" {
 		return nil, errors.New("Notion attachments require a configured GitHub remote")
@@ -201,7 +441,7 @@ func (nc *NotionClient) attachmentFiles(entry Entry, dataDir string) ([]notionap
 			return nil, err
 		}
 		files = append(files, notionapi.File{
-			Name: filepath.Base(fileName),
+			Name: fileName,
 			Type: notionapi.FileTypeExternal,
 			External: &notionapi.FileObject{
 				URL: externalURL,
@@ -211,32 +451,6 @@ func (nc *NotionClient) attachmentFiles(entry Entry, dataDir string) ([]notionap
 	return files, nil
 }
 
-func notionBodyBlocks(body string) []notionapi.Block {
-	paragraphs := strings.Split(strings.TrimSpace(body), "\n\n")
-	blocks := []notionapi.Block{}
-	for _, paragraph := range paragraphs {
-		paragraph = strings.TrimSpace(paragraph)
-		if paragraph == "" {
-			continue
-		}
-		for _, chunk := range splitRunes(paragraph, notionTextLimit) {
-			blocks = append(blocks, &notionapi.ParagraphBlock{
-				BasicBlock: notionapi.BasicBlock{
-					Object: notionapi.ObjectTypeBlock,
-					Type:   notionapi.BlockTypeParagraph,
-				},
-				Paragraph: notionapi.Paragraph{
-					RichText: []notionapi.RichText{{
-						Type: notionapi.ObjectTypeText,
-						Text: &notionapi.Text{Content: chunk},
-					}},
-				},
-			})
-		}
-	}
-	return blocks
-}
-
 func splitRunes(value string, limit int) []string {
 	if value == "" {
 		return nil
@@ -251,39 +465,50 @@ func splitRunes(value string, limit int) []string {
 	return chunks
 }
 
-func entryFromNotionPage(page notionapi.Page) (Entry, bool) {
-	title, ok := page.Properties["TIL"].(notionapi.TitleProperty)
+func notionAttachments(page notionapi.Page, mapping NotionProperties) []NotionAttachment {
+	property, ok := filesProperty(page.Properties[mapping.withDefaults().Attachments])
 	if !ok {
-		return Entry{}, false
+		return nil
 	}
-	message := notionTitle(title)
-	if message == "" {
-		return Entry{}, false
+	attachments := []NotionAttachment{}
+	for _, file := range property.Files {
+		attachment := NotionAttachment{Name: file.Name}
+		if file.External != nil {
+			attachment.URL = file.External.URL
+		} else if file.File != nil {
+			attachment.URL = file.File.URL
+		}
+		attachments = append(attachments, attachment)
 	}
+	return attachments
+}
 
-	files := []string{}
-	if attachment, ok := page.Properties["Attachments"].(notionapi.FilesProperty); ok {
-		for _, file := range attachment.Files {
-			files = append(files, file.Name)
+// Properties decoded from API responses are pointers, while ones built locally
+// are values; the property helpers accept both.
+func titleProperty(property notionapi.Property) (notionapi.TitleProperty, bool) {
+	switch property := property.(type) {
+	case notionapi.TitleProperty:
+		return property, true
+	case *notionapi.TitleProperty:
+		if property != nil {
+			return *property, true
 		}
 	}
-	return Entry{
-		Date:         page.CreatedTime,
-		Message:      message,
-		Files:        files,
-		IsCommitted:  true,
-		NotionSynced: true,
-	}, true
+	return notionapi.TitleProperty{}, false
 }
 
-func notionTitle(title notionapi.TitleProperty) string {
-	var value strings.Builder
-	for _, text := range title.Title {
-		if text.PlainText != "" {
-			value.WriteString(text.PlainText)
-		} else if text.Text != nil {
-			value.WriteString(text.Text.Content)
+func filesProperty(property notionapi.Property) (notionapi.FilesProperty, bool) {
+	switch property := property.(type) {
+	case notionapi.FilesProperty:
+		return property, true
+	case *notionapi.FilesProperty:
+		if property != nil {
+			return *property, true
 		}
 	}
-	return value.String()
+	return notionapi.FilesProperty{}, false
+}
+
+func notionTitle(title notionapi.TitleProperty) string {
+	return notionPlainText(title.Title)
 }
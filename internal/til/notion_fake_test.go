@@ -0,0 +1,340 @@
+package til
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+// fakeNotionServer is an in-memory stand-in for the parts of the Notion API the client uses.
+type fakeNotionServer struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu        sync.Mutex
+	pages     map[string]*fakeNotionPage
+	order     []string
+	nextID    int
+	pageLimit int
+	requests  []string
+	// schema maps database property names to their types.
+	schema map[string]string
+	// failures are statuses returned, in order, before requests are handled.
+	failures   []int
+	retryAfter string
+	// failAppends makes block-children appends fail after this many succeed.
+	failAppends int
+	appends     int
+}
+
+type fakeNotionPage struct {
+	ID         string
+	Properties map[string]map[string]any
+	Children   []map[string]any
+	Archived   bool
+	LastEdited time.Time
+}
+
+func newFakeNotionServer(t *testing.T) *fakeNotionServer {
+	t.Helper()
+	fake := &fakeNotionServer{
+		t:           t,
+		pages:       map[string]*fakeNotionPage{},
+		pageLimit:   100,
+		failAppends: -1,
+		schema:      map[string]string{"TIL": "title", "Attachments": "files"},
+	}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.server.Close)
+	return fake
+}
+
+func (fake *fakeNotionServer) notionClient(options ...NotionClientOption) *NotionClient {
+	target, err := url.Parse(fake.server.URL)
+	if err != nil {
+		fake.t.Fatal(err)
+	}
+	httpClient := &http.Client{Transport: rewriteHostTransport{target: target}}
+	client := &NotionClient{
+		client: notionapi.NewClient("secret-token", notionapi.WithHTTPClient(httpClient)),
+		dbID:   "database-id",
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+// retryingClient returns a client that sends requests through notionTransport
+// without rate limiting; waits between attempts are recorded instead of slept.
+func (fake *fakeNotionServer) retryingClient() (*NotionClient, *notionTransport, *[]time.Duration) {
+	target, err := url.Parse(fake.server.URL)
+	if err != nil {
+		fake.t.Fatal(err)
+	}
+	waits := []time.Duration{}
+	transport := newNotionTransport(rewriteHostTransport{target: target})
+	transport.interval = 0
+	transport.wait = func(ctx context.Context, delay time.Duration) error {
+		waits = append(waits, delay)
+		return ctx.Err()
+	}
+	client := &NotionClient{
+		client: newNotionAPIClient("secret-token", transport),
+		dbID:   "database-id",
+	}
+	return client, transport, &waits
+}
+
+func (fake *fakeNotionServer) page(id string) *fakeNotionPage {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return fake.pages[id]
+}
+
+func (fake *fakeNotionServer) pageCount() int {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return len(fake.pages)
+}
+
+func (fake *fakeNotionServer) handle(writer http.ResponseWriter, request *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.requests = append(fake.requests, request.Method+" "+request.URL.Path)
+	if len(fake.failures) > 0 {
+		status := fake.failures[0]
+		fake.failures = fake.failures[1:]
+		if fake.retryAfter != "" {
+			writer.Header().Set("Retry-After", fake.retryAfter)
+		}
+		fake.writeError(writer, status, "rate_limited", http.StatusText(status))
+		return
+	}
+
+	var body map[string]any
+	if request.Body != nil && request.ContentLength != 0 {
+		if err := json.NewDecoder(request.Body).Decode(&body); err != nil {
+			fake.writeError(writer, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+	}
+
+	path := strings.Split(strings.Trim(strings.TrimPrefix(request.URL.Path, "/v1"), "/"), "/")
+	switch {
+	case request.Method == http.MethodPost && len(path) == 1 && path[0] == "pages":
+		page := &fakeNotionPage{
+			ID:         fake.newID("page"),
+			Properties: map[string]map[string]any{},
+			LastEdited: time.Now().UTC(),
+		}
+		fake.mergeProperties(page, body)
+		if len(objectList(body["children"])) > 100 {
+			fake.writeError(writer, http.StatusBadRequest, "validation_error", "too many children")
+			return
+		}
+		for _, child := range objectList(body["children"]) {
+			page.Children = append(page.Children, fake.withID(child))
+		}
+		fake.pages[page.ID] = page
+		fake.order = append(fake.order, page.ID)
+		fake.writeJSON(writer, fake.pageJSON(page))
+	case len(path) == 2 && path[0] == "pages":
+		page, ok := fake.pages[path[1]]
+		if !ok {
+			fake.writeError(writer, http.StatusNotFound, "object_not_found", "page not found")
+			return
+		}
+		if request.Method == http.MethodPatch {
+			fake.mergeProperties(page, body)
+			if archived, ok := body["archived"].(bool); ok {
+				page.Archived = archived
+			}
+			page.LastEdited = time.Now().UTC()
+		}
+		fake.writeJSON(writer, fake.pageJSON(page))
+	case request.Method == http.MethodGet && len(path) == 2 && path[0] == "databases":
+		properties := map[string]any{}
+		for name, propertyType := range fake.schema {
+			properties[name] = map[string]any{"id": name, "name": name, "type": propertyType, propertyType: map[string]any{}}
+		}
+		fake.writeJSON(writer, map[string]any{"object": "database", "id": path[1], "properties": properties})
+	case request.Method == http.MethodPost && len(path) == 3 && path[0] == "databases" && path[2] == "query":
+		fake.writeQuery(writer, body)
+	case len(path) == 3 && path[0] == "blocks" && path[2] == "children":
+		page, ok := fake.pages[path[1]]
+		if !ok {
+			fake.writeError(writer, http.StatusNotFound, "object_not_found", "block not found")
+			return
+		}
+		if request.Method == http.MethodPatch {
+			if fake.failAppends >= 0 && fake.appends >= fake.failAppends {
+				fake.writeError(writer, http.StatusInternalServerError, "internal_server_error", "append failed")
+				return
+			}
+			fake.appends++
+			children := objectList(body["children"])
+			if len(children) > 100 {
+				fake.writeError(writer, http.StatusBadRequest, "validation_error", "too many children")
+				return
+			}
+			added := []map[string]any{}
+			for _, child := range children {
+				added = append(added, fake.withID(child))
+			}
+			page.Children = append(page.Children, added...)
+			fake.writeJSON(writer, map[string]any{"object": "list", "results": added})
+			return
+		}
+		fake.writeChildren(writer, request, page)
+	case request.Method == http.MethodDelete && len(path) == 2 && path[0] == "blocks":
+		for _, page := range fake.pages {
+			for i, child := range page.Children {
+				if child["id"] == path[1] {
+					page.Children = append(page.Children[:i], page.Children[i+1:]...)
+					fake.writeJSON(writer, child)
+					return
+				}
+			}
+		}
+		fake.writeError(writer, http.StatusNotFound, "object_not_found", "block not found")
+	default:
+		fake.writeError(writer, http.StatusNotFound, "invalid_request_url", "unsupported request")
+	}
+}
+
+func (fake *fakeNotionServer) writeChildren(writer http.ResponseWriter, request *http.Request, page *fakeNotionPage) {
+	start := 0
+	if cursor := request.URL.Query().Get("start_cursor"); cursor != "" {
+		start, _ = strconv.Atoi(cursor)
+	}
+	size := fake.pageLimit
+	if requested, err := strconv.Atoi(request.URL.Query().Get("page_size")); err == nil && requested < size {
+		size = requested
+	}
+	end := min(start+size, len(page.Children))
+	response := map[string]any{
+		"object":   "list",
+		"results":  page.Children[start:end],
+		"has_more": end < len(page.Children),
+	}
+	if end < len(page.Children) {
+		response["next_cursor"] = strconv.Itoa(end)
+	}
+	fake.writeJSON(writer, response)
+}
+
+func (fake *fakeNotionServer) writeQuery(writer http.ResponseWriter, body map[string]any) {
+	pages := []map[string]any{}
+	for _, id := range fake.order {
+		if page := fake.pages[id]; !page.Archived {
+			pages = append(pages, fake.pageJSON(page))
+		}
+	}
+	start := 0
+	if cursor, ok := body["start_cursor"].(string); ok && cursor != "" {
+		start, _ = strconv.Atoi(cursor)
+	}
+	end := min(start+fake.pageLimit, len(pages))
+	response := map[string]any{
+		"object":   "list",
+		"results":  pages[start:end],
+		"has_more": end < len(pages),
+	}
+	if end < len(pages) {
+		response["next_cursor"] = strconv.Itoa(end)
+	}
+	fake.writeJSON(writer, response)
+}
+
+func (fake *fakeNotionServer) mergeProperties(page *fakeNotionPage, body map[string]any) {
+	properties, _ := body["properties"].(map[string]any)
+	for name, value := range properties {
+		property, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		if _, ok := property["type"]; !ok {
+			for _, propertyType := range []string{"title", "rich_text", "files", "date", "multi_select", "url"} {
+				if _, ok := property[propertyType]; ok {
+					property["type"] = propertyType
+				}
+			}
+		}
+		page.Properties[name] = property
+	}
+}
+
+func (fake *fakeNotionServer) pageJSON(page *fakeNotionPage) map[string]any {
+	return map[string]any{
+		"object":           "page",
+		"id":               page.ID,
+		"created_time":     "2025-03-04T05:06:00.000Z",
+		"last_edited_time": page.LastEdited.Format(time.RFC3339Nano),
+		"archived":         page.Archived,
+		"properties":       page.Properties,
+	}
+}
+
+func (fake *fakeNotionServer) withID(block map[string]any) map[string]any {
+	block["id"] = fake.newID("block")
+	block["object"] = "block"
+	return block
+}
+
+func (fake *fakeNotionServer) newID(prefix string) string {
+	fake.nextID++
+	return fmt.Sprintf("%s-%d", prefix, fake.nextID)
+}
+
+func (fake *fakeNotionServer) writeJSON(writer http.ResponseWriter, value any) {
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(value); err != nil {
+		fake.t.Error(err)
+	}
+}
+
+func (fake *fakeNotionServer) writeError(writer http.ResponseWriter, status int, code, message string) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(map[string]any{
+		"object":  "error",
+		"status":  status,
+		"code":    code,
+		"message": message,
+	})
+}
+
+func objectList(value any) []map[string]any {
+	items, _ := value.([]any)
+	objects := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		if object, ok := item.(map[string]any); ok {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// rewriteHostTransport sends requests for the Notion API to the fake server.
+type rewriteHostTransport struct {
+	target *url.URL
+}
+
+func (transport rewriteHostTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request = request.Clone(request.Context())
+	request.URL.Scheme = transport.target.Scheme
+	request.URL.Host = transport.target.Host
+	request.Host = transport.target.Host
+	return http.DefaultTransport.RoundTrip(request)
+}
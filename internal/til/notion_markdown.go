@@ -0,0 +1,324 @@
+package til
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jomei/notionapi"
+)
+
+// notionRichTextLimit is the number of rich text objects Notion accepts per block.
+const notionRichTextLimit = 100
+
+// notionBodyBlocks converts an entry body to Notion blocks. Blocks whose text
+// exceeds Notion's limits are split into consecutive blocks of the same kind.
+func notionBodyBlocks(body string) []notionapi.Block {
+	blocks := []notionapi.Block{}
+	for _, block := range parseMarkdown(strings.TrimSpace(body)) {
+		if block.kind == markdownDivider {
+			blocks = append(blocks, &notionapi.DividerBlock{
+				BasicBlock: notionBasicBlock(notionapi.BlockTypeDivider),
+			})
+			continue
+		}
+
+		spans := []markdownSpan{{text: block.text}}
+		if block.kind != markdownCode {
+			spans = parseInline(block.text)
+		}
+		for _, richText := range notionRichTextChunks(spans) {
+			blocks = append(blocks, notionBlock(block, richText))
+		}
+	}
+	return blocks
+}
+
+func notionBlock(block markdownBlock, richText []notionapi.RichText) notionapi.Block {
+	switch block.kind {
+	case markdownHeading:
+		heading := notionapi.Heading{RichText: richText}
+		switch block.level {
+		case 1:
+			return &notionapi.Heading1Block{BasicBlock: notionBasicBlock(notionapi.BlockTypeHeading1), Heading1: heading}
+		case 2:
+			return &notionapi.Heading2Block{BasicBlock: notionBasicBlock(notionapi.BlockTypeHeading2), Heading2: heading}
+		default:
+			// Notion has three heading levels; deeper headings share the last one.
+			return &notionapi.Heading3Block{BasicBlock: notionBasicBlock(notionapi.BlockTypeHeading3), Heading3: heading}
+		}
+	case markdownBulletedItem:
+		return &notionapi.BulletedListItemBlock{
+			BasicBlock:       notionBasicBlock(notionapi.BlockTypeBulletedListItem),
+			BulletedListItem: notionapi.ListItem{RichText: richText},
+		}
+	case markdownNumberedItem:
+		return &notionapi.NumberedListItemBlock{
+			BasicBlock:       notionBasicBlock(notionapi.BlockTypeNumberedListItem),
+			NumberedListItem: notionapi.ListItem{RichText: richText},
+		}
+	case markdownTaskItem:
+		return &notionapi.ToDoBlock{
+			BasicBlock: notionBasicBlock(notionapi.BlockTypeToDo),
+			ToDo:       notionapi.ToDo{RichText: richText, Checked: block.checked},
+		}
+	case markdownQuote:
+		return &notionapi.QuoteBlock{
+			BasicBlock: notionBasicBlock(notionapi.BlockQuote),
+			Quote:      notionapi.Quote{RichText: richText},
+		}
+	case markdownCode:
+		return &notionapi.CodeBlock{
+			BasicBlock: notionBasicBlock(notionapi.BlockTypeCode),
+			Code:       notionapi.Code{RichText: richText, Language: notionCodeLanguage(block.language)},
+		}
+	}
+	return &notionapi.ParagraphBlock{
+		BasicBlock: notionBasicBlock(notionapi.BlockTypeParagraph),
+		Paragraph:  notionapi.Paragraph{RichText: richText},
+	}
+}
+
+func notionBasicBlock(blockType notionapi.BlockType) notionapi.BasicBlock {
+	return notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: blockType}
+}
+
+// notionRichTextChunks converts spans to rich text, starting a new chunk
+// whenever a block would exceed Notion's text or rich-text-object limits.
+func notionRichTextChunks(spans []markdownSpan) [][]notionapi.RichText {
+	chunks := [][]notionapi.RichText{}
+	current := []notionapi.RichText{}
+	length := 0
+	for _, span := range spans {
+		for _, text := range splitRunes(span.text, notionTextLimit) {
+			size := utf8.RuneCountInString(text)
+			if len(current) > 0 && (length+size > notionTextLimit || len(current) == notionRichTextLimit) {
+				chunks = append(chunks, current)
+				current, length = []notionapi.RichText{}, 0
+			}
+			current = append(current, notionRichText(span, text))
+			length += size
+		}
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+func notionRichText(span markdownSpan, text string) notionapi.RichText {
+	richText := notionapi.RichText{
+		Type: notionapi.ObjectTypeText,
+		Text: &notionapi.Text{Content: text},
+	}
+	// Notion rejects links that are not absolute URLs, so relative links
+	// and anchors are kept as their text.
+	if span.link != "" && isAutolinkURL(span.link) {
+		richText.Text.Link = &notionapi.Link{Url: span.link}
+	}
+	if span.bold || span.italic || span.strikethrough || span.code {
+		richText.Annotations = &notionapi.Annotations{
+			Bold:          span.bold,
+			Italic:        span.italic,
+			Strikethrough: span.strikethrough,
+			Code:          span.code,
+			Color:         notionapi.ColorDefault,
+		}
+	}
+	return richText
+}
+
+// notionBlocksMarkdown converts page blocks back into an entry body. Blocks
+// without a Markdown equivalent, such as embeds and databases, are skipped.
+func notionBlocksMarkdown(blocks []notionapi.Block) string {
+	var body strings.Builder
+	var previous notionapi.BlockType
+	number := 0
+	for _, block := range blocks {
+		text := notionBlockMarkdown(block, number+1)
+		if text == "" {
+			continue
+		}
+		if block.GetType() == notionapi.BlockTypeNumberedListItem {
+			number++
+		} else {
+			number = 0
+		}
+		if body.Len() > 0 {
+			if isNotionListItem(previous) && previous == block.GetType() {
+				body.WriteString("\n")
+			} else {
+				body.WriteString("\n\n")
+			}
+		}
+		body.WriteString(text)
+		previous = block.GetType()
+	}
+	return body.String()
+}
+
+func notionBlockMarkdown(block notionapi.Block, number int) string {
+	switch block := block.(type) {
+	case *notionapi.ParagraphBlock:
+		return prefixLines(notionMarkdownText(block.Paragraph.RichText), "", "", true)
+	case *notionapi.Heading1Block:
+		return "# " + strings.ReplaceAll(notionMarkdownText(block.Heading1.RichText), "\n", " ")
+	case *notionapi.Heading2Block:
+		return "## " + strings.ReplaceAll(notionMarkdownText(block.Heading2.RichText), "\n", " ")
+	case *notionapi.Heading3Block:
+		return "### " + strings.ReplaceAll(notionMarkdownText(block.Heading3.RichText), "\n", " ")
+	case *notionapi.BulletedListItemBlock:
+		return prefixLines(notionMarkdownText(block.BulletedListItem.RichText), "- ", "  ", true)
+	case *notionapi.NumberedListItemBlock:
+		marker := fmt.Sprintf("%d. ", number)
+		return prefixLines(notionMarkdownText(block.NumberedListItem.RichText), marker, strings.Repeat(" ", len(marker)), true)
+	case *notionapi.ToDoBlock:
+		marker := "- [ ] "
+		if block.ToDo.Checked {
+			marker = "- [x] "
+		}
+		return prefixLines(notionMarkdownText(block.ToDo.RichText), marker, "  ", true)
+	case *notionapi.QuoteBlock:
+		return prefixLines(notionMarkdownText(block.Quote.RichText), "> ", "> ", false)
+	case *notionapi.CalloutBlock:
+		return prefixLines(notionMarkdownText(block.Callout.RichText), "> ", "> ", false)
+	case *notionapi.ToggleBlock:
+		return prefixLines(notionMarkdownText(block.Toggle.RichText), "", "", true)
+	case *notionapi.CodeBlock:
+		code := notionPlainText(block.Code.RichText)
+		fence := strings.Repeat("`", max(3, longestRun(code, '`')+1))
+		language := block.Code.Language
+		if language == "plain text" {
+			language = ""
+		}
+		return fence + language + "\n" + code + "\n" + fence
+	case *notionapi.DividerBlock:
+		return "---"
+	case *notionapi.ImageBlock:
+		if url := block.Image.GetURL(); url != "" {
+			return "![" + escapeInlineMarkdown(notionPlainText(block.Image.Caption)) + "](" + linkDestination(url) + ")"
+		}
+	case *notionapi.BookmarkBlock:
+		if block.Bookmark.URL != "" {
+			return "<" + block.Bookmark.URL + ">"
+		}
+	}
+	return ""
+}
+
+// notionMarkdownText renders rich text as inline Markdown.
+func notionMarkdownText(texts []notionapi.RichText) string {
+	spans := make([]markdownSpan, 0, len(texts))
+	for _, text := range texts {
+		span := markdownSpan{text: notionPlainText([]notionapi.RichText{text}), link: text.Href}
+		if text.Text != nil && text.Text.Link != nil {
+			span.link = text.Text.Link.Url
+		}
+		if text.Annotations != nil {
+			span.bold = text.Annotations.Bold
+			span.italic = text.Annotations.Italic
+			span.strikethrough = text.Annotations.Strikethrough
+			span.code = text.Annotations.Code
+		}
+		spans = append(spans, span)
+	}
+	return renderInline(mergeSpans(spans))
+}
+
+// prefixLines puts marker before the first line and indent before the rest.
+// With escape, lines that would start a different block are escaped.
+func prefixLines(text, marker, indent string, escape bool) string {
+	if text == "" {
+		return strings.TrimRight(marker, " ")
+	}
+	lines := strings.Split(text, "\n")
+	for index, line := range lines {
+		if escape {
+			line = escapeBlockStart(line)
+		}
+		if index == 0 {
+			lines[index] = marker + line
+		} else {
+			lines[index] = indent + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isNotionListItem(blockType notionapi.BlockType) bool {
+	return blockType == notionapi.BlockTypeBulletedListItem ||
+		blockType == notionapi.BlockTypeNumberedListItem ||
+		blockType == notionapi.BlockTypeToDo
+}
+
+func notionPlainText(texts []notionapi.RichText) string {
+	var value strings.Builder
+	for _, text := range texts {
+		if text.PlainText != "" {
+			value.WriteString(text.PlainText)
+		} else if text.Text != nil {
+			value.WriteString(text.Text.Content)
+		}
+	}
+	return value.String()
+}
+
+// notionCodeLanguage maps a fence info string to a language Notion accepts.
+func notionCodeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if alias, ok := notionCodeLanguageAliases[language]; ok {
+		return alias
+	}
+	if notionCodeLanguages[language] {
+		return language
+	}
+	return "plain text"
+}
+
+var notionCodeLanguageAliases = map[string]string{
+	"cpp":        "c++",
+	"cs":         "c#",
+	"csharp":     "c#",
+	"dockerfile": "docker",
+	"fsharp":     "f#",
+	"golang":     "go",
+	"htm":        "html",
+	"js":         "javascript",
+	"jsx":        "javascript",
+	"kt":         "kotlin",
+	"make":       "makefile",
+	"md":         "markdown",
+	"objc":       "objective-c",
+	"proto":      "protobuf",
+	"ps1":        "powershell",
+	"pwsh":       "powershell",
+	"py":         "python",
+	"rb":         "ruby",
+	"rs":         "rust",
+	"sh":         "shell",
+	"tex":        "latex",
+	"text":       "plain text",
+	"ts":         "typescript",
+	"tsx":        "typescript",
+	"txt":        "plain text",
+	"yml":        "yaml",
+	"zsh":        "shell",
+}
+
+var notionCodeLanguages = map[string]bool{
+	"abap": true, "arduino": true, "bash": true, "basic": true, "c": true, "c#": true,
+	"c++": true, "clojure": true, "coffeescript": true, "css": true, "dart": true,
+	"diff": true, "docker": true, "elixir": true, "elm": true, "erlang": true, "f#": true,
+	"flow": true, "fortran": true, "gherkin": true, "glsl": true, "go": true,
+	"graphql": true, "groovy": true, "haskell": true, "html": true, "java": true,
+	"javascript": true, "json": true, "julia": true, "kotlin": true, "latex": true,
+	"less": true, "lisp": true, "livescript": true, "lua": true, "makefile": true,
+	"markdown": true, "markup": true, "matlab": true, "mermaid": true, "nix": true,
+	"objective-c": true, "ocaml": true, "pascal": true, "perl": true, "php": true,
+	"plain text": true, "powershell": true, "prolog": true, "protobuf": true,
+	"python": true, "r": true, "reason": true, "ruby": true, "rust": true, "sass": true,
+	"scala": true, "scheme": true, "scss": true, "shell": true, "solidity": true,
+	"sql": true, "swift": true, "toml": true, "typescript": true, "vb.net": true,
+	"verilog": true, "vhdl": true, "visual basic": true, "webassembly": true,
+	"xml": true, "yaml": true,
+}
@@ -0,0 +1,100 @@
+package til
+
+import (
+	"testing"
+
+	"github.com/jomei/notionapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const richMarkdownBody = "# Setup\n\n" +
+	"Use **bold**, *italic*, ~~struck~~ and `code` with a [link](https://example.com/docs).\n\n" +
+	"## Steps\n\n" +
+	"- first\n- second\n\n" +
+	"1. one\n2. two\n\n" +
+	"- [ ] open task\n- [x] done task\n\n" +
+	"> quoted *text*\n\n" +
+	"```go\nfmt.Println(\"hi\")\n```\n\n" +
+	"---\n\n" +
+	"\\# Not a heading"
+
+func TestNotionBodyBlocksConvertMarkdown(t *testing.T) {
+	blocks := notionBodyBlocks(richMarkdownBody)
+	types := make([]notionapi.BlockType, 0, len(blocks))
+	for _, block := range blocks {
+		types = append(types, block.GetType())
+	}
+	assert.Equal(t, []notionapi.BlockType{
+		notionapi.BlockTypeHeading1,
+		notionapi.BlockTypeParagraph,
+		notionapi.BlockTypeHeading2,
+		notionapi.BlockTypeBulletedListItem,
+		notionapi.BlockTypeBulletedListItem,
+		notionapi.BlockTypeNumberedListItem,
+		notionapi.BlockTypeNumberedListItem,
+		notionapi.BlockTypeToDo,
+		notionapi.BlockTypeToDo,
+		notionapi.BlockQuote,
+		notionapi.BlockTypeCode,
+		notionapi.BlockTypeDivider,
+		notionapi.BlockTypeParagraph,
+	}, types)
+
+	paragraph := blocks[1].(*notionapi.ParagraphBlock).Paragraph.RichText
+	annotated := map[string]notionapi.Annotations{}
+	for _, text := range paragraph {
+		if text.Annotations != nil {
+			annotated[text.Text.Content] = *text.Annotations
+		}
+	}
+	assert.True(t, annotated["bold"].Bold)
+	assert.True(t, annotated["italic"].Italic)
+	assert.True(t, annotated["struck"].Strikethrough)
+	assert.True(t, annotated["code"].Code)
+	link := paragraph[len(paragraph)-2]
+	assert.Equal(t, "link", link.Text.Content)
+	require.NotNil(t, link.Text.Link)
+	assert.Equal(t, "https://example.com/docs", link.Text.Link.Url)
+
+	assert.True(t, blocks[8].(*notionapi.ToDoBlock).ToDo.Checked)
+	code := blocks[10].(*notionapi.CodeBlock).Code
+	assert.Equal(t, "go", code.Language)
+	assert.Equal(t, "fmt.Println(\"hi\")", code.RichText[0].Text.Content)
+	assert.Equal(t, "# Not a heading", notionPlainText(blocks[12].(*notionapi.ParagraphBlock).Paragraph.RichText))
+}
+
+func TestNotionBodyBlocksKeepRelativeLinksAsText(t *testing.T) {
+	blocks := notionBodyBlocks("See [spec](./notes.md), [usage](#usage), [ftp](ftp://example.com), and [mail](mailto:ada@example.com).")
+	paragraph := blocks[0].(*notionapi.ParagraphBlock).Paragraph.RichText
+	links := map[string]string{}
+	for _, text := range paragraph {
+		if text.Text.Link != nil {
+			links[text.Text.Content] = text.Text.Link.Url
+		}
+	}
+	assert.Equal(t, map[string]string{"mail": "mailto:ada@example.com"}, links)
+	assert.Equal(t, "See spec, usage, ftp, and mail.", notionPlainText(paragraph))
+}
+
+func TestNotionMarkdownRoundTrip(t *testing.T) {
+	assert.Equal(t, richMarkdownBody, notionBlocksMarkdown(notionBodyBlocks(richMarkdownBody)))
+
+	for _, body := range []string{
+		"Line one\nline two",
+		"Snake_case_name and a_b",
+		"See <https://example.com>",
+		"```\nno language\n```",
+	} {
+		assert.Equal(t, body, notionBlocksMarkdown(notionBodyBlocks(body)), body)
+	}
+}
+
+func TestNotionCodeLanguage(t *testing.T) {
+	assert.Equal(t, "javascript", notionCodeLanguage("js"))
+	assert.Equal(t, "shell", notionCodeLanguage("SH"))
+	assert.Equal(t, "c++", notionCodeLanguage("cpp"))
+	assert.Equal(t, "rust", notionCodeLanguage("rust"))
+	assert.Equal(t, "plain text", notionCodeLanguage(""))
+	assert.Equal(t, "plain text", notionCodeLanguage("brainfuck"))
+}
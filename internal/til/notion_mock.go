@@ -2,30 +2,84 @@ package til
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
 )
 
 // MockNotionClient is a mock implementation of the Notion client for testing
 type MockNotionClient struct {
-	entries []Entry
+	mu       sync.Mutex
+	entries  []Entry
+	archived []string
+	edited   map[string]time.Time
+	nextPage int
 }
 
 // NewMockNotionClient creates a new mock Notion client
 func NewMockNotionClient() *MockNotionClient {
 	return &MockNotionClient{
 		entries: []Entry{},
+		edited:  map[string]time.Time{},
 	}
 }
 
-// PushEntry pushes a TIL entry to the mock client
-func (mnc *MockNotionClient) PushEntry(ctx context.Context, entry Entry, dataDir string) error {
+// PushEntry pushes a TIL entry to the mock client, replacing the entry stored
+// under its page ID when it has one
+func (mnc *MockNotionClient) PushEntry(ctx context.Context, entry Entry, dataDir string) (string, error) {
+	mnc.mu.Lock()
+	defer mnc.mu.Unlock()
+
 	// Mark the entry as synced and add it to our collection
 	entry.NotionSynced = true
+	if entry.NotionPageID != "" && !slices.Contains(mnc.archived, entry.NotionPageID) {
+		for i, existing := range mnc.entries {
+			if existing.NotionPageID == entry.NotionPageID {
+				mnc.entries[i] = entry
+				mnc.edited[entry.NotionPageID] = time.Now()
+				return entry.NotionPageID, nil
+			}
+		}
+	}
+	mnc.nextPage++
+	entry.NotionPageID = fmt.Sprintf("mock-page-%d", mnc.nextPage)
 	mnc.entries = append(mnc.entries, entry)
+	mnc.edited[entry.NotionPageID] = time.Now()
+	return entry.NotionPageID, nil
+}
+
+// EditPage simulates a change made directly in Notion
+func (mnc *MockNotionClient) EditPage(pageID, message, messageBody string, editedAt time.Time) {
+	mnc.mu.Lock()
+	defer mnc.mu.Unlock()
+
+	for i, entry := range mnc.entries {
+		if entry.NotionPageID == pageID {
+			mnc.entries[i].Message = message
+			mnc.entries[i].MessageBody = messageBody
+			mnc.edited[pageID] = editedAt
+		}
+	}
+}
+
+// ArchivePage removes the entry stored under a page ID
+func (mnc *MockNotionClient) ArchivePage(ctx context.Context, pageID string) error {
+	mnc.mu.Lock()
+	defer mnc.mu.Unlock()
+
+	mnc.entries = slices.DeleteFunc(mnc.entries, func(entry Entry) bool {
+		return entry.NotionPageID == pageID
+	})
+	mnc.archived = append(mnc.archived, pageID)
 	return nil
 }
 
 // GetEntries retrieves TIL entries from the mock client
 func (mnc *MockNotionClient) GetEntries(ctx context.Context, limit int) ([]Entry, error) {
+	mnc.mu.Lock()
+	defer mnc.mu.Unlock()
+
 	// Sort entries by date in descending order
 	sortedEntries := make([]Entry, len(mnc.entries))
 	copy(sortedEntries, mnc.entries)
@@ -47,8 +101,57 @@ func (mnc *MockNotionClient) GetEntries(ctx context.Context, limit int) ([]Entry
 	return sortedEntries, nil
 }
 
+// QueryPages lists the pushed entries as Notion pages, oldest first
+func (mnc *MockNotionClient) QueryPages(ctx context.Context) ([]NotionPage, error) {
+	mnc.mu.Lock()
+	defer mnc.mu.Unlock()
+
+	pages := []NotionPage{}
+	for _, entry := range mnc.entries {
+		page := NotionPage{
+			ID: entry.NotionPageID,
+			Entry: Entry{
+				Date:         entry.Date,
+				Message:      entry.Message,
+				IsCommitted:  true,
+				NotionSynced: true,
+				CommitID:     entry.CommitID,
+				NotionPageID: entry.NotionPageID,
+			},
+			LastEditedTime: mnc.edited[entry.NotionPageID],
+		}
+		for _, fileName := range entry.Files {
+			page.Attachments = append(page.Attachments, NotionAttachment{
+				Name: fileName,
+				URL:  "https://example.com/" + fileName,
+			})
+		}
+		pages = append(pages, page)
+	}
+	slices.SortFunc(pages, func(a, b NotionPage) int {
+		return a.Entry.Date.Compare(b.Entry.Date)
+	})
+	return pages, nil
+}
+
+// PageMarkdown returns the body of a pushed entry
+func (mnc *MockNotionClient) PageMarkdown(ctx context.Context, pageID string) (string, error) {
+	mnc.mu.Lock()
+	defer mnc.mu.Unlock()
+
+	for _, entry := range mnc.entries {
+		if entry.NotionPageID == pageID {
+			return entry.MessageBody, nil
+		}
+	}
+	return "", fmt.Errorf("page %s not found", pageID)
+}
+
 // IsEntrySynced checks if an entry has already been synced to Notion
 func (mnc *MockNotionClient) IsEntrySynced(ctx context.Context, entry Entry) (bool, error) {
+	mnc.mu.Lock()
+	defer mnc.mu.Unlock()
+
 	for _, e := range mnc.entries {
 		if e.CommitID != "" && entry.CommitID != "" {
 			if e.CommitID == entry.CommitID {
@@ -65,7 +168,10 @@ func (mnc *MockNotionClient) IsEntrySynced(ctx context.Context, entry Entry) (bo
 
 // NotionClientInterface is an interface for the Notion client
 type NotionClientInterface interface {
-	PushEntry(ctx context.Context, entry Entry, dataDir string) error
+	PushEntry(ctx context.Context, entry Entry, dataDir string) (string, error)
+	ArchivePage(ctx context.Context, pageID string) error
+	QueryPages(ctx context.Context) ([]NotionPage, error)
+	PageMarkdown(ctx context.Context, pageID string) (string, error)
 	GetEntries(ctx context.Context, limit int) ([]Entry, error)
 	IsEntrySynced(ctx context.Context, entry Entry) (bool, error)
 }
@@ -0,0 +1,315 @@
+package til
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jomei/notionapi"
+)
+
+// notionExcerptLimit is the length of the body excerpt written to Notion.
+const notionExcerptLimit = 200
+
+// NotionProperties names the database properties entry fields are written
+// to. Title and Attachments always have a property; the other fields are
+// only published when they are mapped.
+type NotionProperties struct {
+	Title       string
+	Attachments string
+	Date        string
+	CommitID    string
+	Tags        string
+	Excerpt     string
+	GitLink     string
+}
+
+func DefaultNotionProperties() NotionProperties {
+	return NotionProperties{Title: "TIL", Attachments: "Attachments"}
+}
+
+// IsDefault reports whether the mapping uses only the built-in properties.
+func (p NotionProperties) IsDefault() bool {
+	return p.withDefaults() == DefaultNotionProperties()
+}
+
+func (p NotionProperties) withDefaults() NotionProperties {
+	defaults := DefaultNotionProperties()
+	if strings.TrimSpace(p.Title) == "" {
+		p.Title = defaults.Title
+	}
+	if strings.TrimSpace(p.Attachments) == "" {
+		p.Attachments = defaults.Attachments
+	}
+	return p
+}
+
+// notionPropertyField describes one mapped entry field and the Notion
+// property type it needs.
+type notionPropertyField struct {
+	key          string
+	label        string
+	propertyType notionapi.PropertyConfigType
+	name         func(*NotionProperties) *string
+}
+
+var notionPropertyFields = []notionPropertyField{
+	{"TITLE", "title", notionapi.PropertyConfigTypeTitle, func(p *NotionProperties) *string { return &p.Title }},
+	{"ATTACHMENTS", "attachments", notionapi.PropertyConfigTypeFiles, func(p *NotionProperties) *string { return &p.Attachments }},
+	{"DATE", "created date", notionapi.PropertyConfigTypeDate, func(p *NotionProperties) *string { return &p.Date }},
+	{"COMMIT_ID", "commit ID", notionapi.PropertyConfigTypeRichText, func(p *NotionProperties) *string { return &p.CommitID }},
+	{"TAGS", "tags", notionapi.PropertyConfigTypeMultiSelect, func(p *NotionProperties) *string { return &p.Tags }},
+	{"EXCERPT", "body excerpt", notionapi.PropertyConfigTypeRichText, func(p *NotionProperties) *string { return &p.Excerpt }},
+	{"GIT_LINK", "Git link", notionapi.PropertyConfigTypeURL, func(p *NotionProperties) *string { return &p.GitLink }},
+}
+
+// NotionPropertyField is a mapped entry field as shown by configuration
+// prompts and summaries.
+type NotionPropertyField struct {
+	Label    string
+	Type     string
+	Property *string
+}
+
+// Fields lists the mappable entry fields in a stable order.
+func (p *NotionProperties) Fields() []NotionPropertyField {
+	fields := make([]NotionPropertyField, 0, len(notionPropertyFields))
+	for _, field := range notionPropertyFields {
+		fields = append(fields, NotionPropertyField{
+			Label:    field.label,
+			Type:     string(field.propertyType),
+			Property: field.name(p),
+		})
+	}
+	return fields
+}
+
+// ValidateProperties checks that every mapped property exists in the
+// database with the type its field needs.
+func (nc *NotionClient) ValidateProperties(ctx context.Context) error {
+	if nc.client == nil {
+		return errors.New("Notion client not initialized")
+	}
+	database, err := nc.client.Database.Get(ctx, nc.dbID)
+	if err != nil {
+		return fmt.Errorf("read Notion database: %w", err)
+	}
+
+	properties := nc.properties.withDefaults()
+	var problems []error
+	for _, field := range notionPropertyFields {
+		name := *field.name(&properties)
+		if name == "" {
+			continue
+		}
+		config, ok := database.Properties[name]
+		if !ok {
+			problems = append(problems, fmt.Errorf(
+				"Notion database has no %q property for the %s",
+				name,
+				field.label,
+			))
+			continue
+		}
+		if config.GetType() != field.propertyType {
+			problems = append(problems, fmt.Errorf(
+				"Notion property %q is a %s property; the %s needs %s",
+				name,
+				config.GetType(),
+				field.label,
+				field.propertyType,
+			))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// pageProperties builds the property values written for an entry.
+func (nc *NotionClient) pageProperties(entry Entry, dataDir string) (notionapi.Properties, error) {
+	mapping := nc.properties.withDefaults()
+	properties := notionapi.Properties{
+		mapping.Title: notionapi.TitleProperty{
+			Title: []notionapi.RichText{{
+				Type: notionapi.ObjectTypeText,
+				Text: &notionapi.Text{Content: entry.Message},
+			}},
+		},
+	}
+
+	if len(entry.Files) > 0 {
+		files, err := nc.attachmentFiles(entry, dataDir)
+		if err != nil {
+			return nil, err
+		}
+		properties[mapping.Attachments] = notionapi.FilesProperty{
+			Type:  notionapi.PropertyTypeFiles,
+			Files: files,
+		}
+	}
+	if mapping.Date != "" && !entry.Date.IsZero() {
+		date := notionapi.Date(entry.Date)
+		properties[mapping.Date] = notionapi.DateProperty{
+			Type: notionapi.PropertyTypeDate,
+			Date: &notionapi.DateObject{Start: &date},
+		}
+	}
+	if mapping.CommitID != "" {
+		properties[mapping.CommitID] = notionRichTextProperty(entry.CommitID)
+	}
+	if mapping.Tags != "" {
+		options := make([]notionapi.Option, 0, len(entry.Tags))
+		for _, tag := range entry.Tags {
+			options = append(options, notionapi.Option{Name: tag})
+		}
+		properties[mapping.Tags] = notionapi.MultiSelectProperty{
+			Type:        notionapi.PropertyTypeMultiSelect,
+			MultiSelect: options,
+		}
+	}
+	if mapping.Excerpt != "" {
+		properties[mapping.Excerpt] = notionRichTextProperty(bodyExcerpt(entry.MessageBody, notionExcerptLimit))
+	}
+	if mapping.GitLink != "" {
+		link, err := nc.gitLink(entry)
+		if err != nil {
+			return nil, err
+		}
+		properties[mapping.GitLink] = notionapi.URLProperty{
+			Type: notionapi.PropertyTypeURL,
+			URL:  link,
+		}
+	}
+	return properties, nil
+}
+
+// gitLink points at the entry's body in the Git repository, or at the README
+// for entries without a body.
+func (nc *NotionClient) gitLink(entry Entry) (string, error) {
+	if strings.TrimSpace(nc.gitRemoteURL) == "" {
+		return "", errors.New("the Notion Git link property requires a configured Git remote")
+	}
+	path := "README.md"
+	if strings.TrimSpace(entry.MessageBody) != "" {
+		path = filepath.Join(filesDirectoryName, bodyFileName(entry))
+	}
+	return GitWebFileURL(nc.gitRemoteURL, nc.gitBranch, path)
+}
+
+func notionRichTextProperty(value string) notionapi.RichTextProperty {
+	texts := []notionapi.RichText{}
+	for _, chunk := range splitRunes(value, notionTextLimit) {
+		texts = append(texts, notionapi.RichText{
+			Type: notionapi.ObjectTypeText,
+			Text: &notionapi.Text{Content: chunk},
+		})
+	}
+	return notionapi.RichTextProperty{Type: notionapi.PropertyTypeRichText, RichText: texts}
+}
+
+// bodyExcerpt returns the start of a body as plain text on a single line.
+func bodyExcerpt(body string, limit int) string {
+	parts := []string{}
+	for _, block := range parseMarkdown(strings.TrimSpace(body)) {
+		text := block.text
+		if block.kind != markdownCode {
+			var plain strings.Builder
+			for _, span := range parseInline(block.text) {
+				plain.WriteString(span.text)
+			}
+			text = plain.String()
+		}
+		if text = strings.Join(strings.Fields(text), " "); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	excerpt := strings.Join(parts, " ")
+	if utf8.RuneCountInString(excerpt) <= limit {
+		return excerpt
+	}
+	runes := []rune(excerpt)
+	return strings.TrimRight(string(runes[:limit-1]), " ") + "…"
+}
+
+func entryFromNotionPage(page notionapi.Page, mapping NotionProperties) (Entry, bool) {
+	mapping = mapping.withDefaults()
+	title, ok := titleProperty(page.Properties[mapping.Title])
+	if !ok {
+		return Entry{}, false
+	}
+	message := notionTitle(title)
+	if message == "" {
+		return Entry{}, false
+	}
+
+	files := []string{}
+	if attachment, ok := filesProperty(page.Properties[mapping.Attachments]); ok {
+		for _, file := range attachment.Files {
+			files = append(files, file.Name)
+		}
+	}
+	entry := Entry{
+		Date:         page.CreatedTime,
+		Message:      message,
+		Files:        files,
+		IsCommitted:  true,
+		NotionSynced: true,
+		NotionPageID: page.ID.String(),
+	}
+	if date, ok := dateProperty(page.Properties[mapping.Date]); ok {
+		entry.Date = date
+	}
+	if commitID, ok := richTextProperty(page.Properties[mapping.CommitID]); ok {
+		entry.CommitID = notionPlainText(commitID.RichText)
+	}
+	if tags, ok := multiSelectProperty(page.Properties[mapping.Tags]); ok {
+		entry.Tags = []string{}
+		for _, option := range tags.MultiSelect {
+			entry.Tags = append(entry.Tags, option.Name)
+		}
+	}
+	return entry, true
+}
+
+func dateProperty(property notionapi.Property) (time.Time, bool) {
+	var date *notionapi.DateObject
+	switch property := property.(type) {
+	case notionapi.DateProperty:
+		date = property.Date
+	case *notionapi.DateProperty:
+		if property != nil {
+			date = property.Date
+		}
+	}
+	if date == nil || date.Start == nil {
+		return time.Time{}, false
+	}
+	return time.Time(*date.Start), true
+}
+
+func richTextProperty(property notionapi.Property) (notionapi.RichTextProperty, bool) {
+	switch property := property.(type) {
+	case notionapi.RichTextProperty:
+		return property, true
+	case *notionapi.RichTextProperty:
+		if property != nil {
+			return *property, true
+		}
+	}
+	return notionapi.RichTextProperty{}, false
+}
+
+func multiSelectProperty(property notionapi.Property) (notionapi.MultiSelectProperty, bool) {
+	switch property := property.(type) {
+	case notionapi.MultiSelectProperty:
+		return property, true
+	case *notionapi.MultiSelectProperty:
+		if property != nil {
+			return *property, true
+		}
+	}
+	return notionapi.MultiSelectProperty{}, false
+}
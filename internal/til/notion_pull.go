@@ -0,0 +1,283 @@
+package til
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// NotionPageSource is the part of a Notion client used by PullNotion.
+type NotionPageSource interface {
+	QueryPages(ctx context.Context) ([]NotionPage, error)
+	PageMarkdown(ctx context.Context, pageID string) (string, error)
+}
+
+// NotionConflict is an entry that changed both locally and in Notion since
+// it was last synced.
+type NotionConflict struct {
+	Entry Entry
+	Page  NotionPage
+}
+
+type NotionPullReport struct {
+	Imported  []Entry
+	Updated   []Entry
+	Linked    []Entry
+	Conflicts []NotionConflict
+}
+
+// PullNotion imports pages that are not in the local database and applies
+// changes made in Notion to entries that were not also changed locally.
+// With overwriteLocal, conflicting entries take the Notion version.
+func (m *Manager) PullNotion(
+	ctx context.Context,
+	source NotionPageSource,
+	overwriteLocal bool,
+) (NotionPullReport, error) {
+	report := NotionPullReport{}
+	entries, err := m.GetLatestEntries(0)
+	if err != nil {
+		return report, err
+	}
+	tombstones, err := m.NotionPageTombstones()
+	if err != nil {
+		return report, err
+	}
+	pages, err := source.QueryPages(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	var pullErrors []error
+	byPageID := map[string]Entry{}
+	// Entries without a page ID are matched by the page's commit ID, such as
+	// one pushed from another device, and entries published before page IDs
+	// were recorded by title.
+	unlinkedByCommitID := map[string]Entry{}
+	unlinked := map[string]Entry{}
+	for _, entry := range entries {
+		if entry.NotionPageID != "" {
+			byPageID[entry.NotionPageID] = entry
+			continue
+		}
+		unlinkedByCommitID[entry.CommitID] = entry
+		if entry.NotionSynced {
+			if _, ok := unlinked[entry.Message]; !ok {
+				unlinked[entry.Message] = entry
+			}
+		}
+	}
+	link := func(local Entry, page NotionPage) {
+		delete(unlinkedByCommitID, local.CommitID)
+		if unlinked[local.Message].CommitID == local.CommitID {
+			delete(unlinked, local.Message)
+		}
+		local.NotionPageID = page.ID
+		if err := m.recordNotionSync(local, pulledAt(page)); err != nil {
+			pullErrors = append(pullErrors, fmt.Errorf("%q: %w", page.Entry.Message, err))
+			return
+		}
+		report.Linked = append(report.Linked, local)
+	}
+	removed := map[string]bool{}
+	for _, tombstone := range tombstones {
+		removed[tombstone.PageID] = true
+	}
+
+	for _, page := range pages {
+		if err := ctx.Err(); err != nil {
+			return report, errors.Join(append(pullErrors, err)...)
+		}
+		if removed[page.ID] {
+			continue
+		}
+
+		if local, ok := byPageID[page.ID]; ok {
+			if local.NotionSyncedAt.IsZero() || !page.LastEditedTime.After(local.NotionSyncedAt) {
+				continue
+			}
+			if local.NeedsNotionPush() && !overwriteLocal {
+				report.Conflicts = append(report.Conflicts, NotionConflict{Entry: local, Page: page})
+				continue
+			}
+			updated, err := m.applyNotionPage(ctx, source, local, page)
+			if err != nil {
+				pullErrors = append(pullErrors, fmt.Errorf("%q: %w", page.Entry.Message, err))
+				continue
+			}
+			report.Updated = append(report.Updated, updated)
+			continue
+		}
+
+		if local, ok := unlinkedByCommitID[page.Entry.CommitID]; ok && page.Entry.CommitID != "" {
+			link(local, page)
+			continue
+		}
+		if local, ok := unlinked[page.Entry.Message]; ok && page.Entry.CommitID == "" {
+			link(local, page)
+			continue
+		}
+
+		imported, err := m.importNotionPage(ctx, source, page)
+		if err != nil {
+			pullErrors = append(pullErrors, fmt.Errorf("%q: %w", page.Entry.Message, err))
+			continue
+		}
+		report.Imported = append(report.Imported, imported)
+	}
+
+	if m.Config.SyncToGit && len(report.Imported)+len(report.Updated) > 0 {
+		_ = m.RefreshReadme()
+	}
+	return report, errors.Join(pullErrors...)
+}
+
+func (m *Manager) importNotionPage(ctx context.Context, source NotionPageSource, page NotionPage) (Entry, error) {
+	body, err := notionPageBody(ctx, source, page, true)
+	if err != nil {
+		return Entry{}, err
+	}
+	message, body, err := normalizeCommitMessage(page.Entry.Message, body)
+	if err != nil {
+		return Entry{}, err
+	}
+	// Pages pushed from another device keep their commit ID, so the entry
+	// matches its copy when the devices also sync through Git.
+	date, commitID := page.Entry.Date, page.Entry.CommitID
+	exists := true
+	if isSafeCommitID(commitID) {
+		if exists, err = m.commitIDExists(commitID); err != nil {
+			return Entry{}, err
+		}
+	}
+	if exists {
+		date, commitID, err = m.availableCommitID(message, page.Entry.Date)
+		if err != nil {
+			return Entry{}, err
+		}
+	}
+	// Tags are only read when the database has a mapped tags property. Notion
+	// options that are not valid local tags, such as ones with spaces, are skipped.
+	tags := []string{}
+	for _, tag := range page.Entry.Tags {
+		if normalized, err := normalizeTags([]string{tag}); err == nil {
+			tags = append(tags, normalized...)
+		}
+	}
+	tags, err = normalizeTags(tags)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry := Entry{
+		Date:         date,
+		Message:      message,
+		MessageBody:  body,
+		Files:        []string{},
+		Tags:         tags,
+		IsCommitted:  true,
+		NotionSynced: true,
+		CommitID:     commitID,
+		NotionPageID: page.ID,
+	}
+	bodyPath := filepath.Join(m.filesDir(), bodyFileName(entry))
+	if body != "" {
+		if err := writeFileAtomic(bodyPath, []byte(body), 0644); err != nil {
+			return Entry{}, fmt.Errorf("save commit body: %w", err)
+		}
+	}
+	if err := m.insertEntry(entry); err != nil {
+		_ = os.Remove(bodyPath)
+		return Entry{}, err
+	}
+	if err := m.recordNotionSync(entry, pulledAt(page)); err != nil {
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+// applyNotionPage replaces an entry's title and body with the Notion version.
+// Local attachments and tags are kept.
+func (m *Manager) applyNotionPage(
+	ctx context.Context,
+	source NotionPageSource,
+	local Entry,
+	page NotionPage,
+) (Entry, error) {
+	body, err := notionPageBody(ctx, source, page, len(local.Files) == 0)
+	if err != nil {
+		return Entry{}, err
+	}
+	message, body, err := normalizeCommitMessage(page.Entry.Message, body)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	updated := local
+	updated.Message = message
+	updated.MessageBody = body
+	updated.NotionSynced = true
+	if entryContentChanged(local, updated) {
+		bodyPath := filepath.Join(m.filesDir(), bodyFileName(updated))
+		if body == "" {
+			if err := os.Remove(bodyPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return Entry{}, fmt.Errorf("remove commit body: %w", err)
+			}
+		} else if err := writeFileAtomic(bodyPath, []byte(body), 0644); err != nil {
+			return Entry{}, fmt.Errorf("save commit body: %w", err)
+		}
+		if err := m.updateEntry(updated); err != nil {
+			return Entry{}, err
+		}
+	}
+	if err := m.recordNotionSync(updated, pulledAt(page)); err != nil {
+		return Entry{}, err
+	}
+	return updated, nil
+}
+
+// pulledAt is the sync time recorded for a pulled page. It is never earlier
+// than the page's last edit, so a local clock running behind Notion's does not
+// make the page look changed again on the next pull.
+func pulledAt(page NotionPage) time.Time {
+	now := time.Now()
+	if page.LastEditedTime.After(now) {
+		return page.LastEditedTime
+	}
+	return now
+}
+
+// notionPageBody fetches a page body. Attachments that have no local copy are
+// appended as Markdown links.
+func notionPageBody(
+	ctx context.Context,
+	source NotionPageSource,
+	page NotionPage,
+	linkAttachments bool,
+) (string, error) {
+	body, err := source.PageMarkdown(ctx, page.ID)
+	if err != nil {
+		return "", err
+	}
+	if !linkAttachments || len(page.Attachments) == 0 {
+		return body, nil
+	}
+
+	var links strings.Builder
+	links.WriteString("Attachments:\n")
+	for _, attachment := range page.Attachments {
+		if attachment.URL == "" {
+			fmt.Fprintf(&links, "\n- %s", attachment.Name)
+			continue
+		}
+		fmt.Fprintf(&links, "\n- [%s](%s)", attachment.Name, attachment.URL)
+	}
+	if strings.TrimSpace(body) == "" {
+		return links.String(), nil
+	}
+	return strings.TrimRight(body, "\n") + "\n\n" + links.String(), nil
+}
@@ -0,0 +1,170 @@
+package til
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullNotionImportsNewPages(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	client := NewMockNotionClient()
+	created := time.Date(2025, 5, 6, 7, 8, 0, 0, time.UTC)
+	pageID, err := client.PushEntry(context.Background(), Entry{
+		Date:        created,
+		Message:     "Added in Notion",
+		MessageBody: "Written by a teammate.",
+		Files:       []string{"slides.pdf"},
+	}, "")
+	require.NoError(t, err)
+
+	report, err := manager.PullNotion(context.Background(), client, false)
+	require.NoError(t, err)
+	require.Len(t, report.Imported, 1)
+	imported, err := manager.GetEntry(report.Imported[0].CommitID)
+	require.NoError(t, err)
+	assert.Equal(t, "Added in Notion", imported.Message)
+	assert.Equal(t, created, imported.Date.UTC())
+	assert.Equal(t, "Written by a teammate.\n\nAttachments:\n\n- [slides.pdf](https://example.com/slides.pdf)", imported.MessageBody)
+	assert.Empty(t, imported.Files)
+	assert.Equal(t, pageID, imported.NotionPageID)
+	assert.False(t, imported.NeedsNotionPush())
+
+	body, err := os.ReadFile(filepath.Join(manager.filesDir(), bodyFileName(imported)))
+	require.NoError(t, err)
+	assert.Equal(t, imported.MessageBody, string(body))
+
+	report, err = manager.PullNotion(context.Background(), client, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Imported, "pulling twice does not duplicate entries")
+}
+
+func TestPullNotionAppliesRemoteEditsAndReportsConflicts(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	client := NewMockNotionClient()
+	require.NoError(t, manager.CommitEntryWithBody("Clean", "Original"))
+	require.NoError(t, manager.CommitEntryWithBody("Contested", "Original"))
+	entries, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+	for i := range entries {
+		entries[i].NotionPageID, err = client.PushEntry(context.Background(), entries[i], "")
+		require.NoError(t, err)
+		entries[i].NotionSynced = true
+		require.NoError(t, manager.UpdateEntryNotionSyncStatus(entries[i]))
+	}
+	contested, clean := entries[0], entries[1]
+
+	report, err := manager.PullNotion(context.Background(), client, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Updated, "pages pushed before the sync are unchanged")
+
+	later := time.Now().Add(time.Minute)
+	client.EditPage(clean.NotionPageID, "Clean (edited)", "Edited in Notion", later)
+	client.EditPage(contested.NotionPageID, "Contested (remote)", "Remote body", later)
+	require.NoError(t, manager.EditEntry(contested.CommitID, "Contested (local)", "Local body"))
+
+	report, err = manager.PullNotion(context.Background(), client, false)
+	require.NoError(t, err)
+	require.Len(t, report.Updated, 1)
+	assert.Equal(t, clean.CommitID, report.Updated[0].CommitID)
+	require.Len(t, report.Conflicts, 1)
+	assert.Equal(t, contested.CommitID, report.Conflicts[0].Entry.CommitID)
+
+	updated, err := manager.GetEntry(clean.CommitID)
+	require.NoError(t, err)
+	assert.Equal(t, "Clean (edited)", updated.Message)
+	assert.Equal(t, "Edited in Notion", updated.MessageBody)
+	assert.False(t, updated.NeedsNotionPush())
+	revisions, err := manager.EntryRevisions(clean.CommitID)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 1, "the replaced local version is kept as a revision")
+
+	kept, err := manager.GetEntry(contested.CommitID)
+	require.NoError(t, err)
+	assert.Equal(t, "Contested (local)", kept.Message)
+
+	report, err = manager.PullNotion(context.Background(), client, true)
+	require.NoError(t, err)
+	require.Len(t, report.Updated, 1)
+	assert.Empty(t, report.Conflicts)
+	taken, err := manager.GetEntry(contested.CommitID)
+	require.NoError(t, err)
+	assert.Equal(t, "Contested (remote)", taken.Message)
+	assert.Equal(t, "Remote body", taken.MessageBody)
+}
+
+func TestPullNotionSkipsRemovedAndLinksLegacyEntries(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	client := NewMockNotionClient()
+	require.NoError(t, manager.CommitEntry("Removed locally"))
+	require.NoError(t, manager.CommitEntry("Synced before page IDs"))
+	entries, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+	legacy, removed := entries[0], entries[1]
+
+	legacyPageID, err := client.PushEntry(context.Background(), legacy, "")
+	require.NoError(t, err)
+	legacy.NotionSynced = true
+	require.NoError(t, manager.UpdateEntryNotionSyncStatus(legacy))
+
+	removed.NotionPageID, err = client.PushEntry(context.Background(), removed, "")
+	require.NoError(t, err)
+	removed.NotionSynced = true
+	require.NoError(t, manager.UpdateEntryNotionSyncStatus(removed))
+	_, err = manager.RemoveEntry(removed.CommitID)
+	require.NoError(t, err)
+
+	report, err := manager.PullNotion(context.Background(), client, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Imported)
+	require.Len(t, report.Linked, 1)
+	linked, err := manager.GetEntry(legacy.CommitID)
+	require.NoError(t, err)
+	assert.Equal(t, legacyPageID, linked.NotionPageID)
+}
+
+func TestPullNotionKeepsCommitIDsOfPagesFromOtherDevices(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	client := NewMockNotionClient()
+	require.NoError(t, manager.CommitEntry("Pushed from this device"))
+	require.NoError(t, manager.CommitEntry("Renamed on this device"))
+	entries, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+	renamed, pushed := entries[0], entries[1]
+	pushed.NotionPageID, err = client.PushEntry(context.Background(), pushed, "")
+	require.NoError(t, err)
+	pushed.NotionSynced = true
+	require.NoError(t, manager.UpdateEntryNotionSyncStatus(pushed))
+
+	created := time.Date(2025, 5, 6, 7, 8, 0, 0, time.UTC)
+	remotePageID, err := client.PushEntry(context.Background(), Entry{Date: created, Message: "From the laptop", CommitID: "lap00001"}, "")
+	require.NoError(t, err)
+	renamedPageID, err := client.PushEntry(context.Background(), Entry{Date: created, Message: "Old title", CommitID: renamed.CommitID}, "")
+	require.NoError(t, err)
+	_, err = client.PushEntry(context.Background(), Entry{Date: created, Message: "Same ID, other page", CommitID: pushed.CommitID}, "")
+	require.NoError(t, err)
+
+	report, err := manager.PullNotion(context.Background(), client, false)
+	require.NoError(t, err)
+	require.Len(t, report.Linked, 1)
+	assert.Equal(t, renamed.CommitID, report.Linked[0].CommitID, "unlinked entries are matched by commit ID before title")
+	linked, err := manager.GetEntry(renamed.CommitID)
+	require.NoError(t, err)
+	assert.Equal(t, renamedPageID, linked.NotionPageID)
+	assert.Equal(t, "Renamed on this device", linked.Message)
+
+	require.Len(t, report.Imported, 2)
+	imported, err := manager.GetEntry("lap00001")
+	require.NoError(t, err, "a page from another device keeps its commit ID")
+	assert.Equal(t, remotePageID, imported.NotionPageID)
+	for _, entry := range report.Imported {
+		if entry.Message == "Same ID, other page" {
+			assert.NotEqual(t, pushed.CommitID, entry.CommitID, "a commit ID already in use is replaced")
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package til
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultNotionPushJobs is the number of entries pushed at the same time.
+// Requests are rate limited by the client, so more workers mostly overlap
+// network latency.
+const DefaultNotionPushJobs = 3
+
+// NotionPublisher is the part of a Notion client used by PushNotion.
+type NotionPublisher interface {
+	PushEntry(ctx context.Context, entry Entry, dataDir string) (string, error)
+	ArchivePage(ctx context.Context, pageID string) error
+}
+
+// PushNotion publishes entries that changed since their last push and
+// archives the pages of removed entries. Each result is saved as soon as its
+// entry finishes, so when ctx is cancelled the next push resumes with the
+// entries that were not published yet.
+func (m *Manager) PushNotion(
+	ctx context.Context,
+	client NotionPublisher,
+	options PushOptions,
+) (PushReport, error) {
+	report := PushReport{}
+	pending, err := m.pendingNotionEntries(options.Force)
+	if err != nil {
+		return report, err
+	}
+
+	jobs := max(options.Jobs, 1)
+	work := make(chan Entry)
+	results := make(chan PushProgress)
+	var workers sync.WaitGroup
+	for range min(jobs, max(len(pending), 1)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for entry := range work {
+				pageID, err := client.PushEntry(ctx, entry, m.Config.DataDir)
+				if err == nil {
+					entry.NotionSynced = true
+					entry.NotionPageID = pageID
+				}
+				results <- PushProgress{Entry: entry, Err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(work)
+		for _, entry := range pending {
+			select {
+			case work <- entry:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	// Results are saved here rather than in the workers so database writes
+	// stay on one goroutine.
+	var pushErrors []error
+	for result := range results {
+		if result.Err == nil {
+			result.Err = m.UpdateEntryNotionSyncStatus(result.Entry)
+		}
+		if result.Err != nil {
+			report.Failed++
+			pushErrors = append(pushErrors, fmt.Errorf("%q: %w", result.Entry.Message, result.Err))
+		} else {
+			report.Pushed++
+		}
+		result.Done = report.Pushed + report.Failed
+		result.Total = len(pending)
+		if options.Progress != nil {
+			options.Progress(result)
+		}
+	}
+	report.Remaining = len(pending) - report.Pushed - report.Failed
+	if err := ctx.Err(); err != nil {
+		return report, errors.Join(append(pushErrors, err)...)
+	}
+
+	tombstones, err := m.NotionPageTombstones()
+	if err != nil {
+		return report, errors.Join(append(pushErrors, err)...)
+	}
+	for _, tombstone := range tombstones {
+		if err := ctx.Err(); err != nil {
+			return report, errors.Join(append(pushErrors, err)...)
+		}
+		if err := client.ArchivePage(ctx, tombstone.PageID); err != nil {
+			pushErrors = append(pushErrors, fmt.Errorf("removed entry %q: %w", tombstone.Message, err))
+			continue
+		}
+		if err := m.ClearNotionPageTombstone(tombstone.PageID); err != nil {
+			pushErrors = append(pushErrors, fmt.Errorf("removed entry %q: %w", tombstone.Message, err))
+			continue
+		}
+		report.Removed++
+	}
+	return report, errors.Join(pushErrors...)
+}
+
+// NotionPushPlan lists what PushNotion would do without contacting Notion.
+// Entries with a NotionPageID update their page; the others create one.
+type NotionPushPlan struct {
+	Entries []Entry
+	Archive []NotionPageTombstone
+}
+
+func (m *Manager) PlanNotionPush(force bool) (NotionPushPlan, error) {
+	pending, err := m.pendingNotionEntries(force)
+	if err != nil {
+		return NotionPushPlan{}, err
+	}
+	tombstones, err := m.NotionPageTombstones()
+	if err != nil {
+		return NotionPushPlan{}, err
+	}
+	return NotionPushPlan{Entries: pending, Archive: tombstones}, nil
+}
+
+func (m *Manager) pendingNotionEntries(force bool) ([]Entry, error) {
+	entries, err := m.GetLatestEntries(0)
+	if err != nil {
+		return nil, err
+	}
+	pending := []Entry{}
+	for _, entry := range entries {
+		if entry.NeedsNotionPush() || force {
+			pending = append(pending, entry)
+		}
+	}
+	return pending, nil
+}
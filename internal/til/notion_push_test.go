@@ -0,0 +1,117 @@
+package til
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingPublisher records concurrency and can cancel the push after a
+// number of entries.
+type countingPublisher struct {
+	*MockNotionClient
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	pushed      int
+	cancelAfter int
+	cancel      context.CancelFunc
+}
+
+func (publisher *countingPublisher) PushEntry(ctx context.Context, entry Entry, dataDir string) (string, error) {
+	publisher.mu.Lock()
+	publisher.inFlight++
+	publisher.maxInFlight = max(publisher.maxInFlight, publisher.inFlight)
+	publisher.mu.Unlock()
+	defer func() {
+		publisher.mu.Lock()
+		publisher.inFlight--
+		publisher.mu.Unlock()
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	pageID, err := publisher.MockNotionClient.PushEntry(ctx, entry, dataDir)
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	publisher.pushed++
+	if publisher.cancel != nil && publisher.pushed == publisher.cancelAfter {
+		publisher.cancel()
+	}
+	return pageID, err
+}
+
+func commitTestEntries(t *testing.T, manager *Manager, count int) {
+	t.Helper()
+	for i := range count {
+		require.NoError(t, manager.CommitEntry(fmt.Sprintf("Entry %d", i+1)))
+	}
+}
+
+func TestPushNotionUsesBoundedWorkersAndReportsProgress(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	commitTestEntries(t, manager, 12)
+	publisher := &countingPublisher{MockNotionClient: NewMockNotionClient()}
+
+	var progress []PushProgress
+	report, err := manager.PushNotion(context.Background(), publisher, PushOptions{
+		Jobs:     4,
+		Progress: func(update PushProgress) { progress = append(progress, update) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 12, report.Pushed)
+	assert.LessOrEqual(t, publisher.maxInFlight, 4)
+	assert.Greater(t, publisher.maxInFlight, 1, "entries are pushed concurrently")
+	require.Len(t, progress, 12)
+	for i, update := range progress {
+		assert.Equal(t, i+1, update.Done)
+		assert.Equal(t, 12, update.Total)
+		assert.NoError(t, update.Err)
+		assert.NotEmpty(t, update.Entry.NotionPageID)
+	}
+
+	report, err = manager.PushNotion(context.Background(), publisher, PushOptions{Jobs: 4})
+	require.NoError(t, err)
+	assert.Zero(t, report.Pushed, "synced entries are skipped")
+}
+
+func TestPushNotionResumesAfterCancellation(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	commitTestEntries(t, manager, 10)
+	client := NewMockNotionClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	publisher := &countingPublisher{MockNotionClient: client, cancelAfter: 4, cancel: cancel}
+
+	report, err := manager.PushNotion(ctx, publisher, PushOptions{Jobs: 2})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.GreaterOrEqual(t, report.Pushed, 4)
+	assert.Less(t, report.Pushed, 10)
+	assert.Equal(t, 10, report.Pushed+report.Failed+report.Remaining)
+
+	entries, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+	synced := 0
+	for _, entry := range entries {
+		if !entry.NeedsNotionPush() {
+			synced++
+		}
+	}
+	assert.Equal(t, report.Pushed, synced, "progress is saved after every entry")
+
+	resumed, err := manager.PushNotion(context.Background(), client, PushOptions{Jobs: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 10-report.Pushed, resumed.Pushed)
+	pages, err := client.QueryPages(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, pages, 10, "resuming does not publish entries twice")
+}
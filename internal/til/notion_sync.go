@@ -0,0 +1,183 @@
+package til
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// NotionPageTombstone is a published page whose entry was removed locally.
+type NotionPageTombstone struct {
+	PageID    string
+	CommitID  string
+	Message   string
+	DeletedAt time.Time
+}
+
+// EntryContentHash fingerprints the parts of an entry that destinations
+// publish, including the content of its attachments. Entries whose
+// attachments have no recorded digest hash as they did before digests were
+// added.
+func EntryContentHash(entry Entry) string {
+	digests := attachmentDigests(entry)
+	if !slices.ContainsFunc(digests, func(digest string) bool { return digest != "" }) {
+		digests = nil
+	}
+	return entryContentHash(entry, digests)
+}
+
+// legacyEntryContentHash is EntryContentHash without attachment digests, as
+// recorded by versions before schema version 9.
+func legacyEntryContentHash(entry Entry) string {
+	return entryContentHash(entry, nil)
+}
+
+func entryContentHash(entry Entry, digests []string) string {
+	content, _ := json.Marshal(struct {
+		Date        string   `json:"date"`
+		Message     string   `json:"message"`
+		MessageBody string   `json:"message_body"`
+		Files       []string `json:"files"`
+		Digests     []string `json:"digests,omitempty"`
+		Tags        []string `json:"tags"`
+	}{
+		Date:        entry.Date.UTC().Format(time.RFC3339Nano),
+		Message:     entry.Message,
+		MessageBody: entry.MessageBody,
+		Files:       entry.Files,
+		Digests:     digests,
+		Tags:        entry.Tags,
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// upgradeContentHashes replaces sync state recorded with the legacy content
+// hash by the current one, so adding attachment digests to the hash does
+// not make every entry with attachments look changed to its destinations.
+func upgradeContentHashes(transaction *sql.Tx) error {
+	type hashedEntry struct {
+		id    int64
+		entry Entry
+	}
+	entries := []hashedEntry{}
+	rows, err := transaction.Query("SELECT id, created_at, message, message_body FROM entries")
+	if err != nil {
+		return fmt.Errorf("query entries for content hashes: %w", err)
+	}
+	for rows.Next() {
+		var (
+			item      hashedEntry
+			createdAt string
+		)
+		if err := rows.Scan(&item.id, &createdAt, &item.entry.Message, &item.entry.MessageBody); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan entry for content hashes: %w", err)
+		}
+		item.entry.Date, _ = time.Parse(time.RFC3339Nano, createdAt)
+		entries = append(entries, item)
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("close entry rows: %w", err)
+	}
+
+	for _, item := range entries {
+		entry := item.entry
+		entry.Attachments, err = loadAttachments(transaction, item.id)
+		if err != nil {
+			return err
+		}
+		entry.Files = attachmentNames(entry.Attachments)
+		entry.Tags, err = loadEntryTags(transaction, item.id)
+		if err != nil {
+			return err
+		}
+		legacy, current := legacyEntryContentHash(entry), EntryContentHash(entry)
+		if legacy == current {
+			continue
+		}
+		if _, err := transaction.Exec(
+			"UPDATE destination_sync SET content_hash = ? WHERE entry_id = ? AND content_hash = ?",
+			current,
+			item.id,
+			legacy,
+		); err != nil {
+			return fmt.Errorf("update content hashes: %w", err)
+		}
+	}
+	return nil
+}
+
+// NeedsNotionPush reports whether the entry is unpublished or changed since it
+// was last pushed. Entries synced before content hashes were recorded are
+// trusted to be current.
+func (entry Entry) NeedsNotionPush() bool {
+	if !entry.NotionSynced {
+		return true
+	}
+	return entry.NotionContentHash != "" && entry.NotionContentHash != EntryContentHash(entry)
+}
+
+func (m *Manager) NotionPageTombstones() ([]NotionPageTombstone, error) {
+	if !m.IsInitialized() {
+		return nil, ErrRepositoryNotInitialized
+	}
+
+	db, err := m.openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(
+		`SELECT page_id, commit_id, message, deleted_at
+         FROM notion_page_tombstones
+         ORDER BY deleted_at, page_id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query removed Notion pages: %w", err)
+	}
+	defer rows.Close()
+
+	tombstones := []NotionPageTombstone{}
+	for rows.Next() {
+		var (
+			tombstone NotionPageTombstone
+			deletedAt string
+		)
+		if err := rows.Scan(&tombstone.PageID, &tombstone.CommitID, &tombstone.Message, &deletedAt); err != nil {
+			return nil, fmt.Errorf("scan removed Notion page: %w", err)
+		}
+		tombstone.DeletedAt, err = time.Parse(time.RFC3339Nano, deletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("parse removal timestamp: %w", err)
+		}
+		tombstones = append(tombstones, tombstone)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate removed Notion pages: %w", err)
+	}
+	return tombstones, nil
+}
+
+// ClearNotionPageTombstone forgets a removed entry's page once it has been archived.
+func (m *Manager) ClearNotionPageTombstone(pageID string) error {
+	if !m.IsInitialized() {
+		return ErrRepositoryNotInitialized
+	}
+
+	db, err := m.openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("DELETE FROM notion_page_tombstones WHERE page_id = ?", pageID); err != nil {
+		return fmt.Errorf("clear removed Notion page: %w", err)
+	}
+	return nil
+}
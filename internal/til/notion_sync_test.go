@@ -0,0 +1,71 @@
+package til
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotionSyncStateTracksContentChanges(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntryWithBody("Published", "Body"))
+	entry, err := manager.GetLatestEntries(1)
+	require.NoError(t, err)
+	assert.True(t, entry[0].NeedsNotionPush())
+
+	entry[0].NotionSynced = true
+	entry[0].NotionPageID = "page-1"
+	require.NoError(t, manager.UpdateEntryNotionSyncStatus(entry[0]))
+	synced, err := manager.GetEntry(entry[0].CommitID)
+	require.NoError(t, err)
+	assert.Equal(t, "page-1", synced.NotionPageID)
+	assert.Equal(t, EntryContentHash(synced), synced.NotionContentHash)
+	assert.False(t, synced.NeedsNotionPush())
+
+	synced.NotionSynced = false
+	synced.NotionPageID = ""
+	require.NoError(t, manager.UpdateEntryNotionSyncStatus(synced))
+	unsynced, err := manager.GetEntry(synced.CommitID)
+	require.NoError(t, err)
+	assert.Equal(t, "page-1", unsynced.NotionPageID, "clearing the sync flag keeps the page")
+
+	// An edit that bypasses the sync flag is still detected through the hash.
+	changed := synced
+	changed.NotionSynced = true
+	changed.NotionContentHash = EntryContentHash(synced)
+	changed.MessageBody = "Edited body"
+	assert.True(t, changed.NeedsNotionPush())
+
+	legacy := Entry{Message: "Synced before hashes", NotionSynced: true}
+	assert.False(t, legacy.NeedsNotionPush())
+}
+
+func TestRemovingPublishedEntryRecordsTombstone(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntry("Local only"))
+	require.NoError(t, manager.CommitEntry("Published"))
+	entries, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+	published := entries[0]
+	published.NotionSynced = true
+	published.NotionPageID = "page-9"
+	require.NoError(t, manager.UpdateEntryNotionSyncStatus(published))
+
+	_, err = manager.RemoveEntry(entries[1].CommitID)
+	require.NoError(t, err)
+	_, err = manager.RemoveEntry(published.CommitID)
+	require.NoError(t, err)
+
+	tombstones, err := manager.NotionPageTombstones()
+	require.NoError(t, err)
+	require.Len(t, tombstones, 1)
+	assert.Equal(t, "page-9", tombstones[0].PageID)
+	assert.Equal(t, published.CommitID, tombstones[0].CommitID)
+	assert.Equal(t, "Published", tombstones[0].Message)
+
+	require.NoError(t, manager.ClearNotionPageTombstone("page-9"))
+	tombstones, err = manager.NotionPageTombstones()
+	require.NoError(t, err)
+	assert.Empty(t, tombstones)
+}
@@ -2,6 +2,8 @@ package til
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -26,14 +28,31 @@ func TestMockNotionClient(t *testing.T) {
 		CommitID: "newer",
 	}
 
-	require.NoError(t, client.PushEntry(context.Background(), older, t.TempDir()))
-	require.NoError(t, client.PushEntry(context.Background(), newer, t.TempDir()))
+	_, err := client.PushEntry(context.Background(), older, t.TempDir())
+	require.NoError(t, err)
+	newerPageID, err := client.PushEntry(context.Background(), newer, t.TempDir())
+	require.NoError(t, err)
 	entries, err := client.GetEntries(context.Background(), 1)
 	require.NoError(t, err)
 	require.Len(t, entries, 1)
 	assert.Equal(t, "newer", entries[0].CommitID)
 	assert.True(t, entries[0].NotionSynced)
 
+	newer.NotionPageID = newerPageID
+	newer.Message = "Amended"
+	pageID, err := client.PushEntry(context.Background(), newer, t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, newerPageID, pageID)
+	entries, err = client.GetEntries(context.Background(), 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Amended", entries[0].Message)
+
+	require.NoError(t, client.ArchivePage(context.Background(), newerPageID))
+	entries, err = client.GetEntries(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"older"}, commitIDs(entries))
+
 	synced, err := client.IsEntrySynced(context.Background(), Entry{Message: "Repeated", CommitID: "missing"})
 	require.NoError(t, err)
 	assert.False(t, synced)
@@ -92,8 +111,227 @@ func TestEntryFromNotionPageJoinsTitleSegments(t *testing.T) {
 		},
 	}
 
-	entry, ok := entryFromNotionPage(page)
+	entry, ok := entryFromNotionPage(page, DefaultNotionProperties())
 	require.True(t, ok)
 	assert.Equal(t, "Go interfaces", entry.Message)
 	assert.True(t, entry.NotionSynced)
 }
+
+func TestNotionPushUpdatesExistingPage(t *testing.T) {
+	server := newFakeNotionServer(t)
+	server.pageLimit = 1
+	client := server.notionClient()
+	ctx := context.Background()
+	entry := Entry{
+		Date:        time.Date(2025, 3, 4, 5, 6, 0, 0, time.UTC),
+		Message:     "Original",
+		MessageBody: "First paragraph\n\nSecond paragraph",
+		CommitID:    "abc12345",
+	}
+
+	pageID, err := client.PushEntry(ctx, entry, t.TempDir())
+	require.NoError(t, err)
+	require.NotEmpty(t, pageID)
+	require.Len(t, server.page(pageID).Children, 2)
+
+	entry.NotionPageID = pageID
+	entry.Message = "Amended"
+	entry.MessageBody = "Replacement"
+	updatedID, err := client.PushEntry(ctx, entry, t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, pageID, updatedID)
+	assert.Equal(t, 1, server.pageCount())
+
+	page := server.page(pageID)
+	title, err := json.Marshal(page.Properties["TIL"])
+	require.NoError(t, err)
+	assert.Contains(t, string(title), "Amended")
+	require.Len(t, page.Children, 1)
+	paragraph, err := json.Marshal(page.Children[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(paragraph), "Replacement")
+
+	require.NoError(t, client.ArchivePage(ctx, pageID))
+	assert.True(t, server.page(pageID).Archived)
+	require.NoError(t, client.ArchivePage(ctx, "missing-page"))
+
+	recreatedID, err := client.PushEntry(ctx, entry, t.TempDir())
+	require.NoError(t, err)
+	assert.NotEqual(t, pageID, recreatedID, "archived pages are replaced rather than edited")
+
+	entry.NotionPageID = "deleted-page"
+	recreatedID, err = client.PushEntry(ctx, entry, t.TempDir())
+	require.NoError(t, err)
+	assert.NotEqual(t, "deleted-page", recreatedID)
+	assert.Equal(t, 3, server.pageCount())
+}
+
+func TestNotionPushAppendsLongBodiesInBatches(t *testing.T) {
+	server := newFakeNotionServer(t)
+	client := server.notionClient()
+	ctx := context.Background()
+	paragraphs := make([]string, 250)
+	for i := range paragraphs {
+		paragraphs[i] = fmt.Sprintf("Paragraph %d", i+1)
+	}
+	entry := Entry{
+		Date:        time.Now(),
+		Message:     "Long write-up",
+		MessageBody: strings.Join(paragraphs, "\n\n"),
+		CommitID:    "abc12345",
+	}
+
+	pageID, err := client.PushEntry(ctx, entry, t.TempDir())
+	require.NoError(t, err)
+	children := server.page(pageID).Children
+	require.Len(t, children, 250)
+	last, err := json.Marshal(children[249])
+	require.NoError(t, err)
+	assert.Contains(t, string(last), "Paragraph 250")
+
+	entry.NotionPageID = pageID
+	entry.MessageBody += "\n\nParagraph 251"
+	_, err = client.PushEntry(ctx, entry, t.TempDir())
+	require.NoError(t, err)
+	assert.Len(t, server.page(pageID).Children, 251)
+	first, err := json.Marshal(server.page(pageID).Children[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(first), "Paragraph 1\"", "the old body is replaced, not kept")
+
+	server.failAppends = server.appends
+	_, err = client.PushEntry(ctx, entry, t.TempDir())
+	require.Error(t, err)
+	assert.Len(t, server.page(pageID).Children, 251, "a failed update keeps the previous body")
+
+	server.failAppends = server.appends + 1
+	entry.NotionPageID = ""
+	_, err = client.PushEntry(ctx, entry, t.TempDir())
+	require.Error(t, err)
+	assert.Equal(t, 2, server.pageCount())
+	for _, id := range server.order {
+		if id != pageID {
+			assert.True(t, server.page(id).Archived, "a partially written page is archived")
+		}
+	}
+}
+
+func TestNotionPropertyMapping(t *testing.T) {
+	server := newFakeNotionServer(t)
+	properties := NotionProperties{
+		Date:     "Created",
+		CommitID: "Commit",
+		Tags:     "Tags",
+		Excerpt:  "Preview",
+		GitLink:  "Source",
+	}
+	client := server.notionClient(
+		WithNotionProperties(properties),
+		WithGitAttachments("git@github.com:example/learning.git", "main"),
+	)
+	ctx := context.Background()
+
+	err := client.ValidateProperties(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `no "Created" property for the created date`)
+	server.schema["Created"] = "date"
+	server.schema["Commit"] = "rich_text"
+	server.schema["Tags"] = "multi_select"
+	server.schema["Preview"] = "title"
+	server.schema["Source"] = "url"
+	err = client.ValidateProperties(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"Preview" is a title property; the body excerpt needs rich_text`)
+	server.schema["Preview"] = "rich_text"
+	require.NoError(t, client.ValidateProperties(ctx))
+
+	created := time.Date(2024, 12, 24, 9, 30, 0, 0, time.UTC)
+	entry := Entry{
+		Date:        created,
+		Message:     "Mapped",
+		MessageBody: "Some **bold** text\n\n" + strings.Repeat("word ", 60),
+		Tags:        []string{"go", "notion"},
+		CommitID:    "abc12345",
+	}
+	pageID, err := client.PushEntry(ctx, entry, t.TempDir())
+	require.NoError(t, err)
+	page := server.page(pageID)
+	preview, err := json.Marshal(page.Properties["Preview"])
+	require.NoError(t, err)
+	assert.Contains(t, string(preview), "Some bold text word")
+	assert.Contains(t, string(preview), "…")
+	source, err := json.Marshal(page.Properties["Source"])
+	require.NoError(t, err)
+	assert.Contains(t, string(source), "https://github.com/example/learning/blob/main/files/")
+
+	pages, err := client.QueryPages(ctx)
+	require.NoError(t, err)
+	require.Len(t, pages, 1)
+	assert.Equal(t, created, pages[0].Entry.Date.UTC())
+	assert.Equal(t, "abc12345", pages[0].Entry.CommitID)
+	assert.Equal(t, []string{"go", "notion"}, pages[0].Entry.Tags)
+}
+
+func TestNotionPropertyMappingConfigRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".til"), 0755))
+	config := Config{
+		DataDir:          root,
+		SyncToNotion:     true,
+		NotionAPIKey:     "secret",
+		NotionDBID:       "database-id",
+		NotionProperties: NotionProperties{Title: "Name", Date: "Created", Tags: "Tags"},
+	}
+	require.NoError(t, SaveConfig(config))
+
+	content, err := os.ReadFile(filepath.Join(root, ".til", "config"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "NOTION_PROPERTY_TITLE=Name\n")
+	assert.Contains(t, string(content), "NOTION_PROPERTY_DATE=Created\n")
+	assert.NotContains(t, string(content), "NOTION_PROPERTY_EXCERPT")
+
+	loaded, err := LoadConfig(root)
+	require.NoError(t, err)
+	assert.Equal(t, config.NotionProperties, loaded.NotionProperties)
+	assert.False(t, loaded.NotionProperties.IsDefault())
+	assert.True(t, NotionProperties{Title: "TIL"}.IsDefault())
+
+	config.NotionProperties.Date = "Line\nbreak"
+	assert.ErrorContains(t, SaveConfig(config), "NOTION_PROPERTY_DATE cannot contain a newline")
+}
+
+func TestNotionQueryPagesReadsBodiesAndAttachments(t *testing.T) {
+	server := newFakeNotionServer(t)
+	server.pageLimit = 1
+	root := t.TempDir()
+	entry := Entry{
+		Date:        time.Now(),
+		Message:     "Teammate note",
+		MessageBody: "First\n\nSecond",
+		CommitID:    "abc12345",
+		Files:       []string{"diagram.png"},
+	}
+	storedPath := filepath.Join(root, "til", "files", storedAttachmentName(entry, entry.Files[0]))
+	require.NoError(t, os.MkdirAll(filepath.Dir(storedPath), 0755))
+	require.NoError(t, os.WriteFile(storedPath, []byte("png"), 0644))
+	client := server.notionClient(WithGitAttachments("https://github.com/example/learning.git", "main"))
+
+	pageID, err := client.PushEntry(context.Background(), entry, root)
+	require.NoError(t, err)
+	_, err = client.PushEntry(context.Background(), Entry{Message: "Second page", CommitID: "def67890"}, root)
+	require.NoError(t, err)
+
+	pages, err := client.QueryPages(context.Background())
+	require.NoError(t, err)
+	require.Len(t, pages, 2)
+	assert.Equal(t, pageID, pages[0].ID)
+	assert.Equal(t, "Teammate note", pages[0].Entry.Message)
+	assert.Equal(t, pageID, pages[0].Entry.NotionPageID)
+	assert.False(t, pages[0].LastEditedTime.IsZero())
+	require.Len(t, pages[0].Attachments, 1)
+	assert.Equal(t, "diagram.png", pages[0].Attachments[0].Name)
+	assert.Contains(t, pages[0].Attachments[0].URL, "raw.githubusercontent.com")
+
+	body, err := client.PageMarkdown(context.Background(), pageID)
+	require.NoError(t, err)
+	assert.Equal(t, "First\n\nSecond", body)
+}
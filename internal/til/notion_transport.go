@@ -0,0 +1,190 @@
+package til
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// notionRequestInterval keeps requests within Notion's documented average
+	// of three requests per second per integration.
+	notionRequestInterval = time.Second / 3
+	notionMaxAttempts     = 5
+	notionRetryBaseDelay  = 500 * time.Millisecond
+	notionRetryMaxDelay   = 30 * time.Second
+)
+
+// notionTransport rate-limits Notion API requests and retries ones that
+// failed for transient reasons: rate limiting, server errors, and network
+// errors. Retry-After is honored; otherwise retries back off exponentially
+// with full jitter.
+type notionTransport struct {
+	base        http.RoundTripper
+	interval    time.Duration
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	// wait pauses between attempts; tests replace it to record delays.
+	wait func(ctx context.Context, delay time.Duration) error
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newNotionTransport(base http.RoundTripper) *notionTransport {
+	return &notionTransport{
+		base:        base,
+		interval:    notionRequestInterval,
+		maxAttempts: notionMaxAttempts,
+		baseDelay:   notionRetryBaseDelay,
+		maxDelay:    notionRetryMaxDelay,
+		wait:        sleepContext,
+	}
+}
+
+func (transport *notionTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx := request.Context()
+	for attempt := 1; ; attempt++ {
+		if err := transport.throttle(ctx); err != nil {
+			return nil, err
+		}
+		attemptRequest, err := rewindRequest(request, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := transport.base.RoundTrip(attemptRequest)
+		if attempt >= transport.maxAttempts || !retryableNotionResponse(request, response, err) {
+			return response, err
+		}
+
+		delay := transport.backoff(attempt)
+		if response != nil {
+			if retryAfter, ok := parseRetryAfter(response.Header.Get("Retry-After"), time.Now()); ok {
+				delay = retryAfter
+			}
+			_, _ = io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+		if err := transport.wait(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// throttle waits for the next request slot.
+func (transport *notionTransport) throttle(ctx context.Context) error {
+	if transport.interval <= 0 {
+		return ctx.Err()
+	}
+	transport.mu.Lock()
+	now := time.Now()
+	slot := transport.next
+	if slot.Before(now) {
+		slot = now
+	}
+	transport.next = slot.Add(transport.interval)
+	transport.mu.Unlock()
+	return sleepContext(ctx, time.Until(slot))
+}
+
+func (transport *notionTransport) backoff(attempt int) time.Duration {
+	limit := transport.maxDelay
+	if shift := attempt - 1; shift < 32 {
+		limit = min(transport.baseDelay<<shift, transport.maxDelay)
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit) + 1
+}
+
+// rewindRequest returns the request to send for an attempt. Retries need a
+// fresh copy of the body because the previous attempt consumed it.
+func rewindRequest(request *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 || request.Body == nil || request.Body == http.NoBody {
+		return request, nil
+	}
+	if request.GetBody == nil {
+		return nil, errors.New("retry Notion request: request body cannot be replayed")
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("retry Notion request: %w", err)
+	}
+	retry := request.Clone(request.Context())
+	retry.Body = body
+	return retry, nil
+}
+
+func retryableNotionResponse(request *http.Request, response *http.Response, err error) bool {
+	if request.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		// A request that failed in transit may still have been applied, so
+		// only requests that are safe to repeat are retried.
+		return repeatableNotionRequest(request)
+	}
+	switch response.StatusCode {
+	case http.StatusTooManyRequests:
+		// Rate-limited requests were not processed.
+		return true
+	case http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		// The server may have applied the request before failing.
+		return repeatableNotionRequest(request)
+	}
+	return false
+}
+
+// repeatableNotionRequest reports whether sending request twice has the
+// same effect as sending it once. Database queries are sent with POST but
+// only read pages.
+func repeatableNotionRequest(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return strings.HasSuffix(request.URL.Path, "/query")
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,97 @@
+package til
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotionTransportRetriesTransientFailures(t *testing.T) {
+	server := newFakeNotionServer(t)
+	client, transport, waits := server.retryingClient()
+	server.failures = []int{http.StatusTooManyRequests}
+	server.retryAfter = "2"
+
+	pageID, err := client.PushEntry(context.Background(), Entry{
+		Message:     "Retried",
+		MessageBody: "Body survives the retry",
+		CommitID:    "abc12345",
+	}, t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, 1, server.pageCount(), "a retried create does not duplicate the page")
+	assert.Len(t, server.page(pageID).Children, 1, "the request body is replayed")
+	assert.Equal(t, []time.Duration{2 * time.Second}, *waits)
+
+	*waits = nil
+	server.retryAfter = ""
+	server.failures = []int{http.StatusServiceUnavailable, http.StatusBadGateway}
+	_, err = client.QueryPages(context.Background())
+	require.NoError(t, err)
+	require.Len(t, *waits, 2)
+	assert.LessOrEqual(t, (*waits)[0], transport.baseDelay)
+	assert.LessOrEqual(t, (*waits)[1], 2*transport.baseDelay)
+	for _, wait := range *waits {
+		assert.Positive(t, wait)
+	}
+}
+
+func TestNotionTransportGivesUpOnPersistentAndPermanentFailures(t *testing.T) {
+	server := newFakeNotionServer(t)
+	client, transport, waits := server.retryingClient()
+	for range transport.maxAttempts {
+		server.failures = append(server.failures, http.StatusInternalServerError)
+	}
+
+	_, err := client.QueryPages(context.Background())
+	require.Error(t, err)
+	assert.Len(t, *waits, transport.maxAttempts-1)
+	assert.Len(t, server.requests, transport.maxAttempts)
+
+	*waits = nil
+	err = client.ArchivePage(context.Background(), "missing-page")
+	require.NoError(t, err, "missing pages are ignored")
+	_, err = client.PushEntry(context.Background(), Entry{Message: "Stale", NotionPageID: "missing"}, t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, *waits, "client errors are not retried")
+
+	requests, pages := len(server.requests), server.pageCount()
+	server.failures = []int{http.StatusBadGateway}
+	_, err = client.PushEntry(context.Background(), Entry{Message: "Maybe created", CommitID: "def45678"}, t.TempDir())
+	require.Error(t, err)
+	assert.Empty(t, *waits, "a create that failed on the server may have been applied")
+	assert.Len(t, server.requests, requests+1)
+	assert.Equal(t, pages, server.pageCount())
+}
+
+func TestNotionTransportStopsAtContextDeadline(t *testing.T) {
+	server := newFakeNotionServer(t)
+	client, transport, _ := server.retryingClient()
+	transport.wait = sleepContext
+	server.failures = []int{http.StatusTooManyRequests}
+	server.retryAfter = "60"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	_, err := client.QueryPages(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(started), 5*time.Second)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	delay, ok := parseRetryAfter("3", now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, delay)
+
+	delay, ok = parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Second, delay)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
@@ -1,58 +1,340 @@
 package til
 
 import (
+	_ "embed"
+	"errors"
 	"fmt"
 	"net/url"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"text/template"
+	"time"
 )
 
+const (
+	readmeFileName           = "README.md"
+	readmeTemplateName       = "README.md.tmpl"
+	readmePagesDirectoryName = "pages"
+
+	// ReadmePagesYear and ReadmePagesMonth split the generated README into a
+	// table of contents and one page per year or month.
+	ReadmePagesYear  = "year"
+	ReadmePagesMonth = "month"
+)
+
+//go:embed templates/readme/README.md.tmpl
+var defaultReadmeTemplate string
+
+// ValidateReadmePages checks a README_PAGES setting. Empty keeps every
+// entry in README.md.
+func ValidateReadmePages(value string) error {
+	switch value {
+	case "", ReadmePagesYear, ReadmePagesMonth:
+		return nil
+	}
+	return fmt.Errorf("README pages must be %q or %q, got %q", ReadmePagesYear, ReadmePagesMonth, value)
+}
+
+// readmeData is what README templates receive. Paths are relative to the
+// repository root, so links are written as {{.Root}}{{.Path}}.
+type readmeData struct {
+	// Entries are the entries on the page being rendered, newest first.
+	// README.md has every entry, even when it only links to pages.
+	Entries []readmeEntry
+	// Tags and Stats describe the whole log.
+	Tags  []readmeTag
+	Stats readmeStats
+	// Pages lists every page when the README is split, and is empty otherwise.
+	Pages []readmePage
+	// Page is the page being rendered, or nil for README.md.
+	Page *readmePage
+	// Root is the relative path from the page to the repository root.
+	Root string
+}
+
+type readmeEntry struct {
+	Entry
+	// BodyPath is the body file's path, or empty when the entry has no body.
+	BodyPath    string
+	Attachments []readmeLink
+}
+
+type readmeLink struct {
+	Name string
+	Path string
+}
+
+type readmeTag struct {
+	Name  string
+	Count int
+}
+
+type readmeStats struct {
+	Entries     int
+	Attachments int
+	Tags        int
+	First       time.Time
+	Last        time.Time
+}
+
+type readmePage struct {
+	Title   string
+	Key     string
+	Path    string
+	Entries []readmeEntry
+}
+
+// readmeGroup is a set of entries returned by the grouping helpers.
+type readmeGroup struct {
+	Title   string
+	Key     string
+	Entries []readmeEntry
+}
+
+var readmeFunctions = template.FuncMap{
+	"escape":       escapeMarkdownText,
+	"join":         strings.Join,
+	"groupByYear":  func(entries []readmeEntry) []readmeGroup { return groupReadmeEntries(entries, ReadmePagesYear) },
+	"groupByMonth": func(entries []readmeEntry) []readmeGroup { return groupReadmeEntries(entries, ReadmePagesMonth) },
+	"groupByTag":   groupReadmeEntriesByTag,
+}
+
 func (m *Manager) RefreshReadme() error {
-	if !m.IsInitialized() {
-		return ErrRepositoryNotInitialized
+	files, err := m.renderReadmeFiles()
+	if err != nil {
+		return err
+	}
+	for name, content := range files {
+		if err := writeFileAtomic(filepath.Join(m.repositoryDir(), filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			return fmt.Errorf("refresh README: %w", err)
+		}
 	}
 
-	entries, err := m.GetLatestEntries(0)
+	stale, err := m.readmePageFiles()
 	if err != nil {
 		return err
 	}
+	for _, name := range stale {
+		if _, generated := files[name]; generated {
+			continue
+		}
+		if err := os.Remove(filepath.Join(m.repositoryDir(), filepath.FromSlash(name))); err != nil {
+			return fmt.Errorf("remove README page: %w", err)
+		}
+	}
+	if len(stale) > 0 {
+		// Only succeeds once the directory is empty.
+		_ = os.Remove(filepath.Join(m.repositoryDir(), readmePagesDirectoryName))
+	}
+	return nil
+}
+
+// ReadmeDiff returns the unified diff RefreshReadme would apply to README.md
+// and its pages, or an empty string when they are up to date.
+func (m *Manager) ReadmeDiff() (string, error) {
+	files, err := m.renderReadmeFiles()
+	if err != nil {
+		return "", err
+	}
+	existing, err := m.readmePageFiles()
+	if err != nil {
+		return "", err
+	}
+	names := []string{readmeFileName}
+	for _, name := range existing {
+		if _, generated := files[name]; !generated {
+			names = append(names, name)
+		}
+	}
+	for name := range files {
+		if name != readmeFileName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+
+	var diff strings.Builder
+	for _, name := range names {
+		current, err := os.ReadFile(filepath.Join(m.repositoryDir(), filepath.FromSlash(name)))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("read %s: %w", name, err)
+		}
+		diff.WriteString(UnifiedDiff("a/"+name, "b/"+name, string(current), files[name], 3))
+	}
+	return diff.String(), nil
+}
+
+// RenderReadme returns the README.md generated from the current entries.
+func (m *Manager) RenderReadme() (string, error) {
+	files, err := m.renderReadmeFiles()
+	if err != nil {
+		return "", err
+	}
+	return files[readmeFileName], nil
+}
 
-	var content strings.Builder
-	content.WriteString("# Today I Learned\n\n")
-	content.WriteString("A collection of things I've learned day to day.\n\n")
-	content.WriteString("## Entries\n\n")
-	content.WriteString("| Date | Entry | Files |\n")
-	content.WriteString("| ---- | ----- | ----- |\n")
+// renderReadmeFiles renders README.md and, when the README is split, its
+// pages, keyed by slash-separated path relative to the repository.
+func (m *Manager) renderReadmeFiles() (map[string]string, error) {
+	if !m.IsInitialized() {
+		return nil, ErrRepositoryNotInitialized
+	}
+	if err := ValidateReadmePages(m.Config.ReadmePages); err != nil {
+		return nil, err
+	}
+	readmeTemplate, err := m.readmeTemplate()
+	if err != nil {
+		return nil, err
+	}
 
+	entries, err := m.GetLatestEntries(0)
+	if err != nil {
+		return nil, err
+	}
+	data := readmeData{Entries: make([]readmeEntry, 0, len(entries))}
+	tagCounts := map[string]int{}
 	for _, entry := range entries {
-		message := escapeMarkdownText(entry.Message)
+		item := readmeEntry{Entry: entry}
 		if entry.MessageBody != "" {
-			message = fmt.Sprintf("[%s](files/%s)", message, url.PathEscape(bodyFileName(entry)))
+			item.BodyPath = filesDirectoryName + "/" + url.PathEscape(bodyFileName(entry))
 		}
-
-		fileLinks := make([]string, 0, len(entry.Files))
 		for _, fileName := range entry.Files {
-			fileLinks = append(fileLinks, fmt.Sprintf(
-				"[%s](files/%s)",
-				escapeMarkdownText(filepath.Base(fileName)),
-				url.PathEscape(storedAttachmentName(entry, fileName)),
-			))
+			item.Attachments = append(item.Attachments, readmeLink{
+				Name: fileName,
+				Path: filesDirectoryName + "/" + escapeURLPath(storedAttachmentName(entry, fileName)),
+			})
+		}
+		data.Entries = append(data.Entries, item)
+
+		data.Stats.Attachments += len(entry.Files)
+		if data.Stats.First.IsZero() || entry.Date.Before(data.Stats.First) {
+			data.Stats.First = entry.Date
+		}
+		if entry.Date.After(data.Stats.Last) {
+			data.Stats.Last = entry.Date
+		}
+		for _, tag := range entry.Tags {
+			tagCounts[tag]++
 		}
+	}
+	for tag, count := range tagCounts {
+		data.Tags = append(data.Tags, readmeTag{Name: tag, Count: count})
+	}
+	sort.Slice(data.Tags, func(i, j int) bool { return data.Tags[i].Name < data.Tags[j].Name })
+	data.Stats.Entries = len(entries)
+	data.Stats.Tags = len(data.Tags)
 
-		fmt.Fprintf(
-			&content,
-			"| %s | %s | %s |\n",
-			entry.Date.Format("2006-01-02"),
-			message,
-			strings.Join(fileLinks, ", "),
-		)
+	if m.Config.ReadmePages != "" {
+		for _, group := range groupReadmeEntries(data.Entries, m.Config.ReadmePages) {
+			data.Pages = append(data.Pages, readmePage{
+				Title:   group.Title,
+				Key:     group.Key,
+				Path:    readmePagesDirectoryName + "/" + group.Key + ".md",
+				Entries: group.Entries,
+			})
+		}
 	}
 
-	readmePath := filepath.Join(m.repositoryDir(), "README.md")
-	if err := writeFileAtomic(readmePath, []byte(content.String()), 0644); err != nil {
-		return fmt.Errorf("refresh README: %w", err)
+	files := map[string]string{}
+	render := func(name string, data readmeData) error {
+		var content strings.Builder
+		if err := readmeTemplate.Execute(&content, data); err != nil {
+			return fmt.Errorf("render %s: %w", name, err)
+		}
+		files[name] = content.String()
+		return nil
 	}
-	return nil
+	if err := render(readmeFileName, data); err != nil {
+		return nil, err
+	}
+	for index := range data.Pages {
+		page := data
+		page.Entries = data.Pages[index].Entries
+		page.Page = &data.Pages[index]
+		page.Root = "../"
+		if err := render(data.Pages[index].Path, page); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// readmeTemplate loads .til/templates/README.md.tmpl, or the built-in
+// template when there is none.
+func (m *Manager) readmeTemplate() (*template.Template, error) {
+	source := defaultReadmeTemplate
+	custom, err := os.ReadFile(filepath.Join(
+		m.Config.DataDir,
+		metadataDirectoryName,
+		siteTemplatesDirectoryName,
+		readmeTemplateName,
+	))
+	if err == nil {
+		source = string(custom)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read README template: %w", err)
+	}
+	readmeTemplate, err := template.New(readmeTemplateName).Funcs(readmeFunctions).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("parse README template: %w", err)
+	}
+	return readmeTemplate, nil
+}
+
+// readmePageFiles lists the generated pages currently in the repository.
+func (m *Manager) readmePageFiles() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(m.repositoryDir(), readmePagesDirectoryName, "*.md"))
+	if err != nil {
+		return nil, fmt.Errorf("list README pages: %w", err)
+	}
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, readmePagesDirectoryName+"/"+filepath.Base(match))
+	}
+	return names, nil
+}
+
+// groupReadmeEntries groups entries by year or month, keeping their order.
+func groupReadmeEntries(entries []readmeEntry, period string) []readmeGroup {
+	keyLayout, titleLayout := "2006", "2006"
+	if period == ReadmePagesMonth {
+		keyLayout, titleLayout = "2006-01", "January 2006"
+	}
+	groups := []readmeGroup{}
+	index := map[string]int{}
+	for _, entry := range entries {
+		key := entry.Date.Format(keyLayout)
+		position, ok := index[key]
+		if !ok {
+			position = len(groups)
+			index[key] = position
+			groups = append(groups, readmeGroup{Title: entry.Date.Format(titleLayout), Key: key})
+		}
+		groups[position].Entries = append(groups[position].Entries, entry)
+	}
+	return groups
+}
+
+// groupReadmeEntriesByTag groups entries by tag name. An entry with several
+// tags appears in each of their groups.
+func groupReadmeEntriesByTag(entries []readmeEntry) []readmeGroup {
+	groups := []readmeGroup{}
+	index := map[string]int{}
+	for _, entry := range entries {
+		for _, tag := range entry.Tags {
+			position, ok := index[tag]
+			if !ok {
+				position = len(groups)
+				index[tag] = position
+				groups = append(groups, readmeGroup{Title: "#" + tag, Key: tag})
+			}
+			groups[position].Entries = append(groups[position].Entries, entry)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups
 }
 
 func escapeMarkdownText(value string) string {
@@ -0,0 +1,133 @@
+package til
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func insertReadmeTestEntries(t *testing.T, manager *Manager) {
+	t.Helper()
+	for _, entry := range []Entry{
+		{
+			Date:        time.Date(2024, 12, 30, 9, 0, 0, 0, time.UTC),
+			Message:     "Pipes | [safely]",
+			MessageBody: "Body",
+			Files:       []string{"daily note.txt"},
+			Tags:        []string{"shell"},
+			IsCommitted: true,
+			CommitID:    "pipe0001",
+		},
+		{
+			Date:        time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC),
+			Message:     "Go generics",
+			Tags:        []string{"go", "shell"},
+			IsCommitted: true,
+			CommitID:    "gen00002",
+		},
+		{
+			Date:        time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC),
+			Message:     "SQLite WAL",
+			IsCommitted: true,
+			CommitID:    "wal00003",
+		},
+	} {
+		require.NoError(t, manager.insertEntry(entry))
+	}
+}
+
+func TestRenderReadmeDefaultTemplate(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	insertReadmeTestEntries(t, manager)
+
+	readme, err := manager.RenderReadme()
+	require.NoError(t, err)
+	assert.Equal(t, "# Today I Learned\n\n"+
+		"A collection of things I've learned day to day.\n\n"+
+		"## Entries\n\n"+
+		"| Date | Entry | Files |\n"+
+		"| ---- | ----- | ----- |\n"+
+		"| 2025-03-30 | SQLite WAL |  |\n"+
+		"| 2025-03-02 | Go generics |  |\n"+
+		"| 2024-12-30 | [Pipes \\| \\[safely\\]](files/body_pipe0001.md) | [daily note.txt](files/pipe0001_daily%20note.txt) |\n",
+		readme,
+	)
+}
+
+func TestRefreshReadmeSplitsPages(t *testing.T) {
+	manager, root := newTestManager(t, Config{ReadmePages: ReadmePagesMonth})
+	insertReadmeTestEntries(t, manager)
+	require.NoError(t, manager.RefreshReadme())
+
+	readme, err := os.ReadFile(filepath.Join(root, "til", "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Today I Learned\n\n"+
+		"A collection of things I've learned day to day.\n\n"+
+		"## Contents\n\n"+
+		"| Page | Entries |\n"+
+		"| ---- | ------- |\n"+
+		"| [March 2025](pages/2025-03.md) | 2 |\n"+
+		"| [December 2024](pages/2024-12.md) | 1 |\n",
+		string(readme),
+	)
+	page, err := os.ReadFile(filepath.Join(root, "til", "pages", "2024-12.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Today I Learned: December 2024\n\n"+
+		"[Back to contents](../README.md)\n\n"+
+		"| Date | Entry | Files |\n"+
+		"| ---- | ----- | ----- |\n"+
+		"| 2024-12-30 | [Pipes \\| \\[safely\\]](../files/body_pipe0001.md) | [daily note.txt](../files/pipe0001_daily%20note.txt) |\n",
+		string(page),
+	)
+	diff, err := manager.ReadmeDiff()
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	// Switching to yearly pages replaces the monthly ones.
+	manager.Config.ReadmePages = ReadmePagesYear
+	diff, err = manager.ReadmeDiff()
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- a/pages/2024-12.md\n")
+	assert.Contains(t, diff, "+++ b/pages/2025.md\n")
+	require.NoError(t, manager.RefreshReadme())
+	assert.FileExists(t, filepath.Join(root, "til", "pages", "2024.md"))
+	assert.FileExists(t, filepath.Join(root, "til", "pages", "2025.md"))
+	assert.NoFileExists(t, filepath.Join(root, "til", "pages", "2025-03.md"))
+
+	manager.Config.ReadmePages = ""
+	require.NoError(t, manager.RefreshReadme())
+	assert.NoDirExists(t, filepath.Join(root, "til", "pages"))
+}
+
+func TestRefreshReadmeUsesCustomTemplate(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	insertReadmeTestEntries(t, manager)
+	templates := filepath.Join(root, ".til", "templates")
+	require.NoError(t, os.MkdirAll(templates, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(templates, "README.md.tmpl"), []byte(
+		"# Notes ({{.Stats.Entries}} entries, {{.Stats.Attachments}} files, {{.Stats.Tags}} tags)\n"+
+			"{{range .Tags}}- {{.Name}}: {{.Count}}\n{{end}}"+
+			"{{range groupByYear .Entries}}## {{.Title}}\n{{range .Entries}}- {{escape .Message}}\n{{end}}{{end}}"+
+			"{{range groupByTag .Entries}}## {{.Title}}: {{len .Entries}}\n{{end}}",
+	), 0644))
+
+	require.NoError(t, manager.RefreshReadme())
+	readme, err := os.ReadFile(filepath.Join(root, "til", "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Notes (3 entries, 1 files, 2 tags)\n"+
+		"- go: 1\n"+
+		"- shell: 2\n"+
+		"## 2025\n- SQLite WAL\n- Go generics\n"+
+		"## 2024\n- Pipes \\| \\[safely\\]\n"+
+		"## #go: 1\n"+
+		"## #shell: 2\n",
+		string(readme),
+	)
+
+	require.NoError(t, os.WriteFile(filepath.Join(templates, "README.md.tmpl"), []byte("{{.Missing"), 0644))
+	assert.ErrorContains(t, manager.RefreshReadme(), "parse README template")
+}
@@ -0,0 +1,275 @@
+package til
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EntryRevision is an earlier state of an entry, captured when an update replaced it.
+type EntryRevision struct {
+	Revision    int
+	RecordedAt  time.Time
+	Message     string
+	MessageBody string
+	Files       []string
+	Attachments []Attachment
+	Tags        []string
+}
+
+func (m *Manager) EntryRevisions(commitID string) ([]EntryRevision, error) {
+	entry, err := m.GetEntry(commitID)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := m.openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return loadEntryRevisions(db, entry.CommitID)
+}
+
+// DiffEntry renders a unified diff from a revision to the current entry.
+// Revision 0 selects the most recent revision.
+func (m *Manager) DiffEntry(commitID string, revision int) (string, error) {
+	entry, revisionEntry, err := m.entryAtRevision(commitID, revision)
+	if err != nil {
+		return "", err
+	}
+	return UnifiedDiff(
+		fmt.Sprintf("%s@%d", entry.CommitID, revisionEntry.Revision),
+		entry.CommitID+" (current)",
+		revisionDocument(revisionEntry.Message, revisionEntry.MessageBody, revisionEntry.Files, revisionEntry.Tags),
+		revisionDocument(entry.Message, entry.MessageBody, entry.Files, entry.Tags),
+		3,
+	), nil
+}
+
+// RevertEntry restores the title, body, attachments, and tags of a revision.
+// The replaced state is itself recorded as a new revision.
+func (m *Manager) RevertEntry(commitID string, revision int) (Entry, error) {
+	if revision <= 0 {
+		return Entry{}, errors.New("revision must be greater than zero")
+	}
+	current, revisionEntry, err := m.entryAtRevision(commitID, revision)
+	if err != nil {
+		return Entry{}, err
+	}
+	restored := current
+	restored.Files = append([]string{}, revisionEntry.Files...)
+	restored.Attachments = append([]Attachment{}, revisionEntry.Attachments...)
+	for _, fileName := range restored.Files {
+		path := filepath.Join(m.filesDir(), storedAttachmentName(restored, fileName))
+		if _, err := os.Stat(path); err != nil {
+			return Entry{}, fmt.Errorf(
+				"attachment %s of revision %d is no longer stored: %w",
+				fileName,
+				revision,
+				err,
+			)
+		}
+	}
+
+	updated := restored
+	updated.Message = revisionEntry.Message
+	updated.MessageBody = revisionEntry.MessageBody
+	updated.Tags = append([]string{}, revisionEntry.Tags...)
+	if !entryContentChanged(current, updated) {
+		return current, nil
+	}
+	updated.NotionSynced = false
+
+	bodyPath := filepath.Join(m.filesDir(), bodyFileName(updated))
+	if updated.MessageBody == "" {
+		if err := os.Remove(bodyPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return Entry{}, fmt.Errorf("remove commit body: %w", err)
+		}
+	} else if err := writeFileAtomic(bodyPath, []byte(updated.MessageBody), 0644); err != nil {
+		return Entry{}, fmt.Errorf("save commit body: %w", err)
+	}
+	if err := m.updateEntry(updated); err != nil {
+		return Entry{}, err
+	}
+
+	if m.Config.SyncToGit {
+		_ = m.RefreshReadme()
+	}
+	return updated, nil
+}
+
+func (m *Manager) entryAtRevision(commitID string, revision int) (Entry, EntryRevision, error) {
+	entry, err := m.GetEntry(commitID)
+	if err != nil {
+		return Entry{}, EntryRevision{}, err
+	}
+	revisions, err := m.EntryRevisions(entry.CommitID)
+	if err != nil {
+		return Entry{}, EntryRevision{}, err
+	}
+	if len(revisions) == 0 {
+		return Entry{}, EntryRevision{}, fmt.Errorf("entry %s has no earlier revisions", entry.CommitID)
+	}
+	if revision == 0 {
+		return entry, revisions[len(revisions)-1], nil
+	}
+	for _, candidate := range revisions {
+		if candidate.Revision == revision {
+			return entry, candidate, nil
+		}
+	}
+	return Entry{}, EntryRevision{}, fmt.Errorf(
+		"entry %s has no revision %d (revisions 1-%d exist)",
+		entry.CommitID,
+		revision,
+		revisions[len(revisions)-1].Revision,
+	)
+}
+
+// recordEntryRevision saves the stored state of an entry before an update
+// replaces its content. Updates that change only sync state are not recorded.
+func recordEntryRevision(transaction *sql.Tx, entryID int64, updated Entry) error {
+	var previous Entry
+	if err := transaction.QueryRow(
+		"SELECT message, message_body FROM entries WHERE id = ?",
+		entryID,
+	).Scan(&previous.Message, &previous.MessageBody); err != nil {
+		return fmt.Errorf("read entry before update: %w", err)
+	}
+	var err error
+	previous.Attachments, err = loadAttachments(transaction, entryID)
+	if err != nil {
+		return err
+	}
+	previous.Files = attachmentNames(previous.Attachments)
+	previous.Tags, err = loadEntryTags(transaction, entryID)
+	if err != nil {
+		return err
+	}
+	if !entryContentChanged(previous, updated) {
+		return nil
+	}
+	return insertEntryRevision(transaction, entryID, previous)
+}
+
+// insertEntryRevision saves content as the entry's next revision.
+func insertEntryRevision(transaction *sql.Tx, entryID int64, previous Entry) error {
+	attachments, err := json.Marshal(previous.Files)
+	if err != nil {
+		return fmt.Errorf("encode revision attachments: %w", err)
+	}
+	objects, err := json.Marshal(previous.Attachments)
+	if err != nil {
+		return fmt.Errorf("encode revision attachment objects: %w", err)
+	}
+	tags, err := json.Marshal(previous.Tags)
+	if err != nil {
+		return fmt.Errorf("encode revision tags: %w", err)
+	}
+	if _, err := transaction.Exec(
+		`INSERT INTO entry_revisions (
+            entry_id, revision, recorded_at, message, message_body, attachments, attachment_objects, tags
+        )
+        SELECT ?, coalesce(max(revision), 0) + 1, ?, ?, ?, ?, ?, ?
+        FROM entry_revisions
+        WHERE entry_id = ?`,
+		entryID,
+		time.Now().Format(time.RFC3339Nano),
+		previous.Message,
+		previous.MessageBody,
+		string(attachments),
+		string(objects),
+		string(tags),
+		entryID,
+	); err != nil {
+		return fmt.Errorf("record entry revision: %w", err)
+	}
+	return nil
+}
+
+func loadEntryRevisions(db sqlQueryer, commitID string) ([]EntryRevision, error) {
+	rows, err := db.Query(
+		`SELECT r.revision, r.recorded_at, r.message, r.message_body, r.attachments, r.attachment_objects, r.tags
+         FROM entry_revisions r
+         JOIN entries e ON e.id = r.entry_id
+         WHERE e.commit_id = ?
+         ORDER BY r.revision`,
+		commitID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query entry revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []EntryRevision{}
+	for rows.Next() {
+		var (
+			revision    EntryRevision
+			recordedAt  string
+			attachments string
+			objects     string
+			tags        string
+		)
+		if err := rows.Scan(
+			&revision.Revision,
+			&recordedAt,
+			&revision.Message,
+			&revision.MessageBody,
+			&attachments,
+			&objects,
+			&tags,
+		); err != nil {
+			return nil, fmt.Errorf("scan entry revision: %w", err)
+		}
+		revision.RecordedAt, err = time.Parse(time.RFC3339Nano, recordedAt)
+		if err != nil {
+			return nil, fmt.Errorf("parse revision timestamp: %w", err)
+		}
+		if err := json.Unmarshal([]byte(attachments), &revision.Files); err != nil {
+			return nil, fmt.Errorf("decode revision attachments: %w", err)
+		}
+		if err := json.Unmarshal([]byte(objects), &revision.Attachments); err != nil {
+			return nil, fmt.Errorf("decode revision attachment objects: %w", err)
+		}
+		if err := json.Unmarshal([]byte(tags), &revision.Tags); err != nil {
+			return nil, fmt.Errorf("decode revision tags: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate entry revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+func entryContentChanged(previous, updated Entry) bool {
+	return previous.Message != updated.Message ||
+		previous.MessageBody != updated.MessageBody ||
+		!slices.Equal(previous.Files, updated.Files) ||
+		!slices.Equal(attachmentDigests(previous), attachmentDigests(updated)) ||
+		!slices.Equal(previous.Tags, updated.Tags)
+}
+
+func revisionDocument(message, body string, files, tags []string) string {
+	var document strings.Builder
+	fmt.Fprintf(&document, "Title: %s\n", message)
+	fmt.Fprintf(&document, "Tags: %s\n", strings.Join(tags, ", "))
+	document.WriteString("\n")
+	if body != "" {
+		document.WriteString(body)
+		document.WriteString("\n\n")
+	}
+	document.WriteString("Attachments:\n")
+	for _, fileName := range files {
+		fmt.Fprintf(&document, "- %s\n", fileName)
+	}
+	return document.String()
+}
@@ -0,0 +1,110 @@
+package til
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAmendRecordsRevisionsAndRevertRestoresThem(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntryWithTags("Original title", "Original body", []string{"go"}))
+	entries, err := manager.GetLatestEntries(1)
+	require.NoError(t, err)
+	commitID := entries[0].CommitID
+
+	attachment := filepath.Join(root, "notes.txt")
+	require.NoError(t, os.WriteFile(attachment, []byte("notes"), 0644))
+	require.NoError(t, manager.AddFile(attachment))
+	require.NoError(t, manager.AmendLastEntryWithTags("Amended title", "Amended body", []string{"sql"}))
+
+	require.NoError(t, manager.UpdateEntryNotionSyncStatus(Entry{CommitID: commitID, NotionSynced: true}))
+
+	revisions, err := manager.EntryRevisions(commitID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1, "sync-only updates are not revisions")
+	assert.Equal(t, 1, revisions[0].Revision)
+	assert.Equal(t, "Original title", revisions[0].Message)
+	assert.Equal(t, "Original body", revisions[0].MessageBody)
+	assert.Empty(t, revisions[0].Files)
+	assert.Equal(t, []string{"go"}, revisions[0].Tags)
+
+	diff, err := manager.DiffEntry(commitID, 0)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- "+commitID+"@1\n")
+	assert.Contains(t, diff, "+++ "+commitID+" (current)\n")
+	assert.Contains(t, diff, "-Title: Original title\n")
+	assert.Contains(t, diff, "+Title: Amended title\n")
+	assert.Contains(t, diff, "-Tags: go\n")
+	assert.Contains(t, diff, "+Tags: go, sql\n")
+	assert.Contains(t, diff, "+- notes.txt\n")
+
+	reverted, err := manager.RevertEntry(commitID[:6], 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Original title", reverted.Message)
+	assert.False(t, reverted.NotionSynced)
+
+	current, err := manager.GetEntry(commitID)
+	require.NoError(t, err)
+	assert.Equal(t, "Original body", current.MessageBody)
+	assert.Empty(t, current.Files)
+	assert.Equal(t, []string{"go"}, current.Tags)
+	body, err := os.ReadFile(filepath.Join(root, "til", "files", "body_"+commitID+".md"))
+	require.NoError(t, err)
+	assert.Equal(t, "Original body", string(body))
+
+	revisions, err = manager.EntryRevisions(commitID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "Amended title", revisions[1].Message)
+	assert.Equal(t, []string{"notes.txt"}, revisions[1].Files)
+
+	_, err = manager.RevertEntry(commitID, 2)
+	require.NoError(t, err)
+	current, err = manager.GetEntry(commitID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes.txt"}, current.Files)
+
+	_, err = manager.RevertEntry(commitID, 9)
+	assert.ErrorContains(t, err, "no revision 9")
+
+	_, err = manager.RemoveEntry(commitID)
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(root, "til", "files", commitID+"_notes.txt"))
+}
+
+func TestDiffWithoutRevisions(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntry("Untouched"))
+	entries, err := manager.GetLatestEntries(1)
+	require.NoError(t, err)
+
+	_, err = manager.DiffEntry(entries[0].CommitID, 0)
+	assert.ErrorContains(t, err, "no earlier revisions")
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	from := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	to := "one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n"
+	assert.Equal(t, `--- a
++++ b
+@@ -1,5 +1,5 @@
+ one
+-two
++2
+ three
+ four
+ five
+@@ -8,3 +8,4 @@
+ eight
+ nine
+ ten
++eleven
+`, UnifiedDiff("a", "b", from, to, 3))
+
+	assert.Empty(t, UnifiedDiff("a", "b", "same\n", "same", 3))
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1 @@\n+new\n", UnifiedDiff("a", "b", "", "new", 3))
+}
@@ -0,0 +1,387 @@
+package til
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const schemaVersion = 10
+
+// attachmentObjectsSchemaVersion is the migration that added attachment
+// digests and the object store under til/files/objects.
+const attachmentObjectsSchemaVersion = 8
+
+type SchemaMigration struct {
+	Version     int
+	Description string
+}
+
+type SchemaUpgradeReport struct {
+	FromVersion int
+	ToVersion   int
+	Applied     []SchemaMigration
+	BackupPath  string
+}
+
+func (report SchemaUpgradeReport) Upgraded() bool {
+	return len(report.Applied) > 0
+}
+
+type schemaMigration struct {
+	SchemaMigration
+	apply func(*sql.Tx) error
+}
+
+// schemaMigrations is the ordered schema history. Append new migrations with the
+// next version number and bump schemaVersion; never edit one that has shipped.
+var schemaMigrations = []schemaMigration{
+	{
+		SchemaMigration: SchemaMigration{Version: 1, Description: "create entries and attachments"},
+		apply:           execSchemaStatements(entriesSchema),
+	},
+	{
+		SchemaMigration: SchemaMigration{Version: 2, Description: "add entry tags"},
+		apply:           execSchemaStatements(tagsSchema),
+	},
+	{
+		SchemaMigration: SchemaMigration{Version: 3, Description: "add full-text search index"},
+		apply:           execSchemaStatements(fullTextSearchSchema),
+	},
+	{
+		SchemaMigration: SchemaMigration{Version: 4, Description: "add entry revision history"},
+		apply:           execSchemaStatements(entryRevisionsSchema),
+	},
+	{
+		SchemaMigration: SchemaMigration{Version: 5, Description: "track Notion pages"},
+		apply:           execSchemaStatements(notionPagesSchema),
+	},
+	{
+		SchemaMigration: SchemaMigration{Version: 6, Description: "record Notion sync times"},
+		apply:           execSchemaStatements(notionSyncTimesSchema),
+	},
+	{
+		SchemaMigration: SchemaMigration{Version: 7, Description: "track sync state per destination"},
+		apply:           execSchemaStatements(destinationSyncSchema),
+	},
+	{
+		SchemaMigration: SchemaMigration{Version: attachmentObjectsSchemaVersion, Description: "store attachments by content hash"},
+		apply:           execSchemaStatements(attachmentObjectsSchema),
+	},
+	{
+		SchemaMigration: SchemaMigration{Version: 9, Description: "include attachment content in content hashes"},
+		apply:           upgradeContentHashes,
+	},
+	{
+		SchemaMigration: SchemaMigration{Version: 10, Description: "identify the repository's feed"},
+		apply:           createRepositoryMetadata,
+	},
+}
+
+const entriesSchema = `
+CREATE TABLE IF NOT EXISTS entries (
+    id                   INTEGER PRIMARY KEY AUTOINCREMENT,
+    commit_id            TEXT NOT NULL UNIQUE,
+    created_at           TEXT NOT NULL,
+    created_at_unix_nano INTEGER NOT NULL,
+    created_date         TEXT NOT NULL,
+    message              TEXT NOT NULL CHECK (trim(message) <> ''),
+    message_body         TEXT NOT NULL DEFAULT '',
+    is_committed         INTEGER NOT NULL DEFAULT 1 CHECK (is_committed IN (0, 1)),
+    notion_synced        INTEGER NOT NULL DEFAULT 0 CHECK (notion_synced IN (0, 1))
+);
+
+CREATE INDEX IF NOT EXISTS entries_created_at_idx
+    ON entries (created_at_unix_nano DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS entries_created_date_idx
+    ON entries (created_date, created_at_unix_nano DESC);
+
+CREATE TABLE IF NOT EXISTS attachments (
+    entry_id  INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    position  INTEGER NOT NULL,
+    file_name TEXT NOT NULL,
+    PRIMARY KEY (entry_id, file_name),
+    UNIQUE (entry_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS attachments_file_name_idx
+    ON attachments (file_name);
+`
+
+const tagsSchema = `
+CREATE TABLE IF NOT EXISTS tags (
+    id   INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE CHECK (name <> '')
+);
+
+CREATE TABLE IF NOT EXISTS entry_tags (
+    entry_id INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    tag_id   INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (entry_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS entry_tags_tag_id_idx
+    ON entry_tags (tag_id);
+`
+
+const fullTextSearchSchema = `
+CREATE VIRTUAL TABLE entries_fts USING fts5 (
+    commit_id,
+    message,
+    message_body,
+    attachments,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER entries_fts_after_insert AFTER INSERT ON entries BEGIN
+    INSERT INTO entries_fts (rowid, commit_id, message, message_body, attachments)
+    VALUES (new.id, new.commit_id, new.message, new.message_body, '');
+END;
+
+CREATE TRIGGER entries_fts_after_update AFTER UPDATE OF commit_id, message, message_body ON entries BEGIN
+    UPDATE entries_fts
+    SET commit_id = new.commit_id, message = new.message, message_body = new.message_body
+    WHERE rowid = new.id;
+END;
+
+CREATE TRIGGER entries_fts_after_delete AFTER DELETE ON entries BEGIN
+    DELETE FROM entries_fts WHERE rowid = old.id;
+END;
+
+CREATE TRIGGER attachments_fts_after_insert AFTER INSERT ON attachments BEGIN
+    UPDATE entries_fts
+    SET attachments = (
+        SELECT coalesce(group_concat(file_name, ' '), '')
+        FROM (SELECT file_name FROM attachments WHERE entry_id = new.entry_id ORDER BY position)
+    )
+    WHERE rowid = new.entry_id;
+END;
+
+CREATE TRIGGER attachments_fts_after_delete AFTER DELETE ON attachments BEGIN
+    UPDATE entries_fts
+    SET attachments = (
+        SELECT coalesce(group_concat(file_name, ' '), '')
+        FROM (SELECT file_name FROM attachments WHERE entry_id = old.entry_id ORDER BY position)
+    )
+    WHERE rowid = old.entry_id;
+END;
+
+INSERT INTO entries_fts (rowid, commit_id, message, message_body, attachments)
+SELECT e.id, e.commit_id, e.message, e.message_body, (
+    SELECT coalesce(group_concat(file_name, ' '), '')
+    FROM (SELECT file_name FROM attachments WHERE entry_id = e.id ORDER BY position)
+)
+FROM entries e;
+`
+
+const entryRevisionsSchema = `
+CREATE TABLE entry_revisions (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    entry_id     INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    revision     INTEGER NOT NULL CHECK (revision > 0),
+    recorded_at  TEXT NOT NULL,
+    message      TEXT NOT NULL,
+    message_body TEXT NOT NULL DEFAULT '',
+    attachments  TEXT NOT NULL DEFAULT '[]',
+    tags         TEXT NOT NULL DEFAULT '[]',
+    UNIQUE (entry_id, revision)
+);
+`
+
+const notionPagesSchema = `
+ALTER TABLE entries ADD COLUMN notion_page_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE entries ADD COLUMN notion_content_hash TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE notion_page_tombstones (
+    page_id    TEXT PRIMARY KEY,
+    commit_id  TEXT NOT NULL,
+    message    TEXT NOT NULL,
+    deleted_at TEXT NOT NULL
+);
+`
+
+const notionSyncTimesSchema = `
+ALTER TABLE entries ADD COLUMN notion_synced_at TEXT NOT NULL DEFAULT '';
+`
+
+// destinationSyncSchema moves Notion's sync columns into a table keyed by
+// destination name so each destination tracks its own state.
+const destinationSyncSchema = `
+CREATE TABLE destination_sync (
+    entry_id     INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    destination  TEXT NOT NULL CHECK (destination <> ''),
+    synced       INTEGER NOT NULL DEFAULT 0 CHECK (synced IN (0, 1)),
+    remote_id    TEXT NOT NULL DEFAULT '',
+    content_hash TEXT NOT NULL DEFAULT '',
+    synced_at    TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (entry_id, destination)
+);
+
+INSERT INTO destination_sync (entry_id, destination, synced, remote_id, content_hash, synced_at)
+SELECT id, 'notion', notion_synced, notion_page_id, notion_content_hash, notion_synced_at
+FROM entries
+WHERE notion_synced <> 0 OR notion_page_id <> '';
+
+ALTER TABLE entries DROP COLUMN notion_synced_at;
+ALTER TABLE entries DROP COLUMN notion_content_hash;
+ALTER TABLE entries DROP COLUMN notion_page_id;
+ALTER TABLE entries DROP COLUMN notion_synced;
+`
+
+// attachmentObjectsSchema records the content of each attachment. Existing
+// rows keep an empty digest until upgradeDatabaseSchema moves their files
+// into the object store.
+const attachmentObjectsSchema = `
+ALTER TABLE attachments ADD COLUMN sha256 TEXT NOT NULL DEFAULT '';
+ALTER TABLE attachments ADD COLUMN size INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE attachments ADD COLUMN media_type TEXT NOT NULL DEFAULT '';
+ALTER TABLE entry_revisions ADD COLUMN attachment_objects TEXT NOT NULL DEFAULT '[]';
+
+CREATE INDEX attachments_sha256_idx ON attachments (sha256);
+`
+
+// repositoryMetadataSchema holds values that belong to the repository as a
+// whole and travel with the database, such as its feed ID.
+const repositoryMetadataSchema = `
+CREATE TABLE repository_metadata (
+    key   TEXT PRIMARY KEY CHECK (key <> ''),
+    value TEXT NOT NULL
+);
+`
+
+func createRepositoryMetadata(transaction *sql.Tx) error {
+	if _, err := transaction.Exec(repositoryMetadataSchema); err != nil {
+		return err
+	}
+	feedID, err := newFeedID()
+	if err != nil {
+		return err
+	}
+	_, err = transaction.Exec("INSERT INTO repository_metadata (key, value) VALUES (?, ?)", feedIDMetadataKey, feedID)
+	return err
+}
+
+// UpgradeSchema applies pending schema migrations after backing up the database.
+func (m *Manager) UpgradeSchema() (SchemaUpgradeReport, error) {
+	if !m.IsInitialized() {
+		return SchemaUpgradeReport{}, ErrRepositoryNotInitialized
+	}
+
+	db, version, err := m.openDatabaseConnection()
+	if err != nil {
+		return SchemaUpgradeReport{}, err
+	}
+	defer db.Close()
+
+	if version >= schemaVersion {
+		if err := m.finishAttachmentObjects(db, version); err != nil {
+			return SchemaUpgradeReport{}, err
+		}
+		return SchemaUpgradeReport{FromVersion: version, ToVersion: version}, nil
+	}
+	return m.upgradeDatabaseSchema(db, version)
+}
+
+func (m *Manager) upgradeDatabaseSchema(db *sql.DB, version int) (SchemaUpgradeReport, error) {
+	backupPath := ""
+	if version > 0 {
+		var err error
+		backupPath, err = m.BackupDatabase("")
+		if err != nil {
+			return SchemaUpgradeReport{}, fmt.Errorf("back up database before schema upgrade: %w", err)
+		}
+	}
+
+	report, err := migrateDatabaseSchema(db)
+	if err != nil {
+		return SchemaUpgradeReport{}, err
+	}
+	if err := m.finishAttachmentObjects(db, report.ToVersion); err != nil {
+		return SchemaUpgradeReport{}, err
+	}
+	report.BackupPath = backupPath
+	return report, nil
+}
+
+// finishAttachmentObjects moves attachments still stored under their
+// per-entry names into the object store. Whether there is work left is read
+// from the data on every open rather than from the schema version, so a move
+// interrupted after the schema upgrade committed is completed later.
+func (m *Manager) finishAttachmentObjects(db *sql.DB, version int) error {
+	if version < attachmentObjectsSchemaVersion {
+		return nil
+	}
+	var pending bool
+	if err := db.QueryRow(`
+SELECT EXISTS (SELECT 1 FROM attachments WHERE sha256 = '')
+    OR EXISTS (SELECT 1 FROM entry_revisions WHERE attachments <> '[]' AND attachment_objects = '[]')`,
+	).Scan(&pending); err != nil {
+		return fmt.Errorf("check for attachments to move into the object store: %w", err)
+	}
+	if !pending {
+		return nil
+	}
+	if err := m.migrateAttachmentObjects(db); err != nil {
+		return fmt.Errorf("move attachments into the object store: %w", err)
+	}
+	return nil
+}
+
+func migrateDatabaseSchema(db *sql.DB) (SchemaUpgradeReport, error) {
+	transaction, err := db.Begin()
+	if err != nil {
+		return SchemaUpgradeReport{}, fmt.Errorf("begin schema migration transaction: %w", err)
+	}
+	defer transaction.Rollback()
+
+	// Re-read the version inside the transaction in case another process upgraded first.
+	var version int
+	if err := transaction.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return SchemaUpgradeReport{}, fmt.Errorf("read SQL schema version: %w", err)
+	}
+	report := SchemaUpgradeReport{
+		FromVersion: version,
+		ToVersion:   version,
+		Applied:     []SchemaMigration{},
+	}
+	if version >= schemaVersion {
+		return report, nil
+	}
+
+	for _, migration := range schemaMigrations {
+		if migration.Version <= version {
+			continue
+		}
+		if err := migration.apply(transaction); err != nil {
+			return SchemaUpgradeReport{}, fmt.Errorf(
+				"apply schema migration %d (%s): %w",
+				migration.Version,
+				migration.Description,
+				err,
+			)
+		}
+		report.Applied = append(report.Applied, migration.SchemaMigration)
+		report.ToVersion = migration.Version
+	}
+	if report.ToVersion != schemaVersion {
+		return SchemaUpgradeReport{}, fmt.Errorf(
+			"schema migrations end at version %d, expected %d",
+			report.ToVersion,
+			schemaVersion,
+		)
+	}
+	if _, err := transaction.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion)); err != nil {
+		return SchemaUpgradeReport{}, fmt.Errorf("set SQL schema version: %w", err)
+	}
+	if err := transaction.Commit(); err != nil {
+		return SchemaUpgradeReport{}, fmt.Errorf("commit schema migration transaction: %w", err)
+	}
+	return report, nil
+}
+
+func execSchemaStatements(statements string) func(*sql.Tx) error {
+	return func(transaction *sql.Tx) error {
+		_, err := transaction.Exec(statements)
+		return err
+	}
+}
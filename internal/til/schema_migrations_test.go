@@ -0,0 +1,175 @@
+package til
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaMigrationsAreOrdered(t *testing.T) {
+	require.NotEmpty(t, schemaMigrations)
+	for i, migration := range schemaMigrations {
+		assert.Equal(t, i+1, migration.Version)
+		assert.NotEmpty(t, migration.Description)
+		assert.NotNil(t, migration.apply)
+	}
+	assert.Equal(t, schemaVersion, schemaMigrations[len(schemaMigrations)-1].Version)
+}
+
+func TestUpgradeSchemaBacksUpAndReportsMigrations(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntry("Before upgrade"))
+	downgradeToVersionOne(t, manager)
+
+	report, err := manager.UpgradeSchema()
+	require.NoError(t, err)
+	assert.True(t, report.Upgraded())
+	assert.Equal(t, 1, report.FromVersion)
+	assert.Equal(t, schemaVersion, report.ToVersion)
+	require.NotEmpty(t, report.Applied)
+	assert.Equal(t, 2, report.Applied[0].Version)
+	assert.Equal(t, filepath.Join(root, ".til", "backups"), filepath.Dir(report.BackupPath))
+	assert.Equal(t, 1, schemaVersionOf(t, report.BackupPath))
+	assert.Equal(t, schemaVersion, schemaVersionOf(t, manager.DatabasePath()))
+
+	report, err = manager.UpgradeSchema()
+	require.NoError(t, err)
+	assert.False(t, report.Upgraded())
+
+	entries, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Before upgrade", entries[0].Message)
+}
+
+func TestFreshDatabaseSkipsUpgradeBackup(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	report, err := manager.UpgradeSchema()
+	require.NoError(t, err)
+	assert.False(t, report.Upgraded())
+	assert.NoDirExists(t, filepath.Join(root, ".til", "backups"))
+}
+
+func TestFailedSchemaMigrationRollsBack(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	downgradeToVersionOne(t, manager)
+
+	original := schemaMigrations
+	t.Cleanup(func() { schemaMigrations = original })
+	schemaMigrations = append([]schemaMigration(nil), original...)
+	schemaMigrations[1].apply = func(transaction *sql.Tx) error {
+		if _, err := transaction.Exec("CREATE TABLE partial (id INTEGER)"); err != nil {
+			return err
+		}
+		return errors.New("simulated failure")
+	}
+
+	_, err := manager.UpgradeSchema()
+	assert.ErrorContains(t, err, "simulated failure")
+	assert.Equal(t, 1, schemaVersionOf(t, manager.DatabasePath()))
+
+	database, err := sql.Open("sqlite", manager.DatabasePath())
+	require.NoError(t, err)
+	defer database.Close()
+	var tables int
+	require.NoError(t, database.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE name IN ('partial', 'tags')",
+	).Scan(&tables))
+	assert.Zero(t, tables)
+}
+
+func TestDestinationSyncMigrationKeepsNotionState(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntry("Published"))
+	require.NoError(t, manager.CommitEntry("Unpublished"))
+	entries, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+
+	database, err := sql.Open("sqlite", manager.DatabasePath())
+	require.NoError(t, err)
+	_, err = database.Exec(`
+DROP INDEX attachments_sha256_idx;
+ALTER TABLE attachments DROP COLUMN media_type;
+ALTER TABLE attachments DROP COLUMN size;
+ALTER TABLE attachments DROP COLUMN sha256;
+ALTER TABLE entry_revisions DROP COLUMN attachment_objects;
+DROP TABLE repository_metadata;
+DROP TABLE destination_sync;
+ALTER TABLE entries ADD COLUMN notion_synced INTEGER NOT NULL DEFAULT 0 CHECK (notion_synced IN (0, 1));
+ALTER TABLE entries ADD COLUMN notion_page_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE entries ADD COLUMN notion_content_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE entries ADD COLUMN notion_synced_at TEXT NOT NULL DEFAULT '';
+PRAGMA user_version = 6;
+`)
+	require.NoError(t, err)
+	_, err = database.Exec(
+		`UPDATE entries
+         SET notion_synced = 1, notion_page_id = 'page-1', notion_content_hash = 'hash',
+             notion_synced_at = '2025-03-04T05:06:07Z'
+         WHERE commit_id = ?`,
+		entries[1].CommitID,
+	)
+	require.NoError(t, err)
+	require.NoError(t, database.Close())
+
+	report, err := manager.UpgradeSchema()
+	require.NoError(t, err)
+	require.Len(t, report.Applied, 4)
+	assert.Equal(t, 7, report.Applied[0].Version)
+
+	migrated, err := manager.GetLatestEntries(0)
+	require.NoError(t, err)
+	require.Len(t, migrated, 2)
+	assert.False(t, migrated[0].NotionSynced)
+	assert.Empty(t, migrated[0].NotionPageID)
+	assert.True(t, migrated[1].NotionSynced)
+	assert.Equal(t, "page-1", migrated[1].NotionPageID)
+	assert.Equal(t, "hash", migrated[1].NotionContentHash)
+	assert.Equal(t, time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC), migrated[1].NotionSyncedAt.UTC())
+}
+
+func downgradeToVersionOne(t *testing.T, manager *Manager) {
+	t.Helper()
+	database, err := sql.Open("sqlite", manager.DatabasePath())
+	require.NoError(t, err)
+	defer database.Close()
+	_, err = database.Exec(`
+DROP INDEX attachments_sha256_idx;
+ALTER TABLE attachments DROP COLUMN media_type;
+ALTER TABLE attachments DROP COLUMN size;
+ALTER TABLE attachments DROP COLUMN sha256;
+DROP TABLE repository_metadata;
+DROP TABLE destination_sync;
+ALTER TABLE entries ADD COLUMN notion_synced INTEGER NOT NULL DEFAULT 0 CHECK (notion_synced IN (0, 1));
+DROP TABLE notion_page_tombstones;
+DROP TABLE entry_revisions;
+DROP TRIGGER entries_fts_after_insert;
+DROP TRIGGER entries_fts_after_update;
+DROP TRIGGER entries_fts_after_delete;
+DROP TRIGGER attachments_fts_after_insert;
+DROP TRIGGER attachments_fts_after_delete;
+DROP TABLE entries_fts;
+DROP TABLE entry_tags;
+DROP TABLE tags;
+PRAGMA user_version = 1;
+`)
+	require.NoError(t, err)
+}
+
+func schemaVersionOf(t *testing.T, path string) int {
+	t.Helper()
+	_, err := os.Stat(path)
+	require.NoError(t, err)
+	database, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer database.Close()
+	var version int
+	require.NoError(t, database.QueryRow("PRAGMA user_version").Scan(&version))
+	return version
+}
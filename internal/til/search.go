@@ -0,0 +1,151 @@
+package til
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	SnippetHighlightStart = "**"
+	SnippetHighlightEnd   = "**"
+)
+
+type EntryMatch struct {
+	Entry
+	Rank    float64
+	Snippet string
+}
+
+// SearchEntries runs a full-text query and returns ranked matches with highlighted snippets.
+// Literal queries, and queries without search text, return matches without snippets.
+func (m *Manager) SearchEntries(query EntryQuery) ([]EntryMatch, error) {
+	return m.queryEntries(query)
+}
+
+// buildFullTextQuery translates til search syntax into an FTS5 MATCH expression.
+//
+// Bare words and "quoted phrases" are matched as phrases, a trailing * makes a
+// prefix term, AND, OR and NOT are operators, and parentheses group terms.
+// Adjacent terms are implicitly joined with AND.
+func buildFullTextQuery(search string) (string, error) {
+	tokens, err := tokenizeSearch(search)
+	if err != nil {
+		return "", err
+	}
+
+	parts := []string{}
+	depth := 0
+	expectOperand := true
+	for _, token := range tokens {
+		switch {
+		case token.operator != "":
+			if expectOperand {
+				return "", fmt.Errorf("invalid search query: %s must follow a search term", token.operator)
+			}
+			parts = append(parts, token.operator)
+			expectOperand = true
+		case token.text == "(":
+			if !expectOperand {
+				parts = append(parts, "AND")
+			}
+			parts = append(parts, "(")
+			depth++
+			expectOperand = true
+		case token.text == ")":
+			if depth == 0 || expectOperand {
+				return "", errors.New("invalid search query: unbalanced parentheses")
+			}
+			parts = append(parts, ")")
+			depth--
+		default:
+			if !expectOperand {
+				parts = append(parts, "AND")
+			}
+			parts = append(parts, token.text)
+			expectOperand = false
+		}
+	}
+	if depth != 0 {
+		return "", errors.New("invalid search query: unbalanced parentheses")
+	}
+	if len(parts) == 0 {
+		return "", errors.New("search query cannot be empty")
+	}
+	if expectOperand {
+		return "", fmt.Errorf("invalid search query: %s must precede a search term", parts[len(parts)-1])
+	}
+	return strings.Join(parts, " "), nil
+}
+
+type searchToken struct {
+	text     string
+	operator string
+}
+
+func tokenizeSearch(search string) ([]searchToken, error) {
+	runes := []rune(search)
+	tokens := []searchToken{}
+	for index := 0; index < len(runes); {
+		character := runes[index]
+		switch {
+		case unicode.IsSpace(character):
+			index++
+		case character == '(' || character == ')':
+			tokens = append(tokens, searchToken{text: string(character)})
+			index++
+		case character == '"':
+			end := index + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, errors.New("invalid search query: unterminated quoted phrase")
+			}
+			phrase := strings.TrimSpace(string(runes[index+1 : end]))
+			index = end + 1
+			prefix := index < len(runes) && runes[index] == '*'
+			if prefix {
+				index++
+			}
+			if phrase == "" {
+				continue
+			}
+			tokens = append(tokens, searchToken{text: fullTextPhrase(phrase, prefix)})
+		default:
+			end := index
+			for end < len(runes) &&
+				!unicode.IsSpace(runes[end]) &&
+				runes[end] != '(' &&
+				runes[end] != ')' &&
+				runes[end] != '"' {
+				end++
+			}
+			word := string(runes[index:end])
+			index = end
+			switch word {
+			case "AND", "OR", "NOT":
+				tokens = append(tokens, searchToken{operator: word})
+				continue
+			}
+			prefix := strings.HasSuffix(word, "*")
+			word = strings.TrimRight(word, "*")
+			if !strings.ContainsFunc(word, func(character rune) bool {
+				return unicode.IsLetter(character) || unicode.IsNumber(character)
+			}) {
+				continue
+			}
+			tokens = append(tokens, searchToken{text: fullTextPhrase(word, prefix)})
+		}
+	}
+	return tokens, nil
+}
+
+func fullTextPhrase(text string, prefix bool) string {
+	phrase := `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+	if prefix {
+		phrase += "*"
+	}
+	return phrase
+}
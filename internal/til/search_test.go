@@ -0,0 +1,147 @@
+package til
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildFullTextQuery(t *testing.T) {
+	for search, expected := range map[string]string{
+		"sqlite wal":                `"sqlite" AND "wal"`,
+		"sqlite AND wal":            `"sqlite" AND "wal"`,
+		"sqlite OR postgres":        `"sqlite" OR "postgres"`,
+		"sqlite NOT wal":            `"sqlite" NOT "wal"`,
+		`"write ahead" log`:         `"write ahead" AND "log"`,
+		"inter*":                    `"inter"*`,
+		"(go OR rust) generics":     `( "go" OR "rust" ) AND "generics"`,
+		"schema.sql":                `"schema.sql"`,
+		`say "hi"* there`:           `"say" AND "hi"* AND "there"`,
+		`column:value "quote""s"`:   `"column:value" AND "quote" AND "s"`,
+		"lowercase and is a term":   `"lowercase" AND "and" AND "is" AND "a" AND "term"`,
+		"100% *":                    `"100%"`,
+		"  padded   whitespace   ":  `"padded" AND "whitespace"`,
+		"NOT_AN_OPERATOR vs NOTION": `"NOT_AN_OPERATOR" AND "vs" AND "NOTION"`,
+	} {
+		actual, err := buildFullTextQuery(search)
+		require.NoError(t, err, search)
+		assert.Equal(t, expected, actual, search)
+	}
+
+	for _, invalid := range []string{"", "*", "AND sqlite", "sqlite OR", "(sqlite", "sqlite)", `"open phrase`, "a AND OR b"} {
+		_, err := buildFullTextQuery(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestFullTextSearch(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	date := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{
+			Message:     "SQLite WAL mode",
+			MessageBody: "Write-ahead logging lets readers continue while a writer appends.",
+			Files:       []string{"wal-diagram.png"},
+			CommitID:    "wal00001",
+		},
+		{
+			Message:     "Postgres vacuum",
+			MessageBody: "Autovacuum reclaims dead tuples. SQLite has VACUUM too.",
+			CommitID:    "vac00002",
+		},
+		{
+			Message:     "Go interfaces",
+			MessageBody: "Interface embedding composes method sets.",
+			CommitID:    "go000003",
+		},
+	}
+	for i, entry := range entries {
+		entry.Date = date.Add(time.Duration(i) * time.Hour)
+		entry.IsCommitted = true
+		require.NoError(t, manager.insertEntry(entry))
+	}
+
+	for search, expected := range map[string][]string{
+		"sqlite":                 {"wal00001", "vac00002"},
+		"sqlite AND wal":         {"wal00001"},
+		"sqlite NOT wal":         {"vac00002"},
+		"wal OR interfaces":      {"wal00001", "go000003"},
+		`"write ahead"`:          {"wal00001"},
+		`"ahead write"`:          {},
+		"interf*":                {"go000003"},
+		"diagram":                {"wal00001"},
+		"go000003":               {"go000003"},
+		"(postgres OR go) vacuu": {},
+	} {
+		matches, err := manager.SearchEntries(EntryQuery{Search: search})
+		require.NoError(t, err, search)
+		actual := []string{}
+		for _, match := range matches {
+			actual = append(actual, match.CommitID)
+		}
+		assert.ElementsMatch(t, expected, actual, search)
+	}
+
+	ranked, err := manager.SearchEntries(EntryQuery{Search: "sqlite"})
+	require.NoError(t, err)
+	require.Len(t, ranked, 2)
+	assert.Equal(t, "wal00001", ranked[0].CommitID, "title matches outrank body matches")
+	assert.LessOrEqual(t, ranked[0].Rank, ranked[1].Rank)
+	assert.Contains(t, ranked[0].Snippet, SnippetHighlightStart+"SQLite"+SnippetHighlightEnd)
+
+	chronological, err := manager.SearchEntries(EntryQuery{Search: "sqlite", OldestFirst: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"wal00001", "vac00002"}, []string{chronological[0].CommitID, chronological[1].CommitID})
+
+	literal, err := manager.SearchEntries(EntryQuery{Search: "write-ahead log", Literal: true})
+	require.NoError(t, err)
+	require.Len(t, literal, 1)
+	assert.Empty(t, literal[0].Snippet)
+
+	_, err = manager.QueryEntries(EntryQuery{Search: "sqlite OR"})
+	assert.ErrorContains(t, err, "invalid search query")
+}
+
+func TestFullTextIndexFollowsChanges(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntry("Original title"))
+	entries, err := manager.GetLatestEntries(1)
+	require.NoError(t, err)
+	entry := entries[0]
+
+	entry.Message = "Renamed heading"
+	entry.Files = []string{"attachment-notes.txt"}
+	require.NoError(t, manager.updateEntry(entry))
+
+	for search, expected := range map[string]int{"original": 0, "renamed": 1, "attachment": 1} {
+		matches, err := manager.QueryEntries(EntryQuery{Search: search})
+		require.NoError(t, err, search)
+		assert.Len(t, matches, expected, search)
+	}
+
+	entry.Files = []string{}
+	require.NoError(t, manager.updateEntry(entry))
+	matches, err := manager.QueryEntries(EntryQuery{Search: "attachment"})
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	_, err = manager.RemoveEntry(entry.CommitID)
+	require.NoError(t, err)
+	matches, err = manager.QueryEntries(EntryQuery{Search: "renamed"})
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestFullTextIndexBackfilledOnUpgrade(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntry("Indexed after upgrade"))
+	downgradeToVersionOne(t, manager)
+
+	_, err := manager.UpgradeSchema()
+	require.NoError(t, err)
+	matches, err := manager.QueryEntries(EntryQuery{Search: "upgrade"})
+	require.NoError(t, err)
+	assert.Len(t, matches, 1)
+}
@@ -0,0 +1,383 @@
+package til
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	siteDirectoryName          = "site"
+	siteTemplatesDirectoryName = "templates"
+	// siteMarkerFileName marks a directory written by BuildSite, which is the
+	// only kind of non-empty directory a build replaces.
+	siteMarkerFileName  = ".til-site"
+	siteSearchIndexName = "search-index.json"
+)
+
+//go:embed templates/site
+var siteTemplates embed.FS
+
+// SiteTemplateNames lists the templates and assets BuildSite uses. A file
+// with the same name in .til/templates replaces the built-in one.
+var SiteTemplateNames = []string{
+	"layout.html",
+	"index.html",
+	"entry.html",
+	"archive.html",
+	"style.css",
+	"search.js",
+}
+
+var siteImageExtensions = map[string]bool{
+	".apng": true,
+	".avif": true,
+	".gif":  true,
+	".jpeg": true,
+	".jpg":  true,
+	".png":  true,
+	".svg":  true,
+	".webp": true,
+}
+
+var siteSlugPattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+type SiteReport struct {
+	OutputDir string
+	Entries   int
+	Pages     int
+	// Overrides lists the templates read from .til/templates.
+	Overrides []string
+	// MissingFiles lists attachments that are not in the files directory.
+	// Their pages still link to them.
+	MissingFiles []string
+}
+
+// sitePage is the data every page template receives. Root is the relative
+// path from the page back to the site root, so the site works from any URL
+// prefix and from the file system.
+type sitePage struct {
+	Title   string
+	Root    string
+	Entries []siteEntry
+	Entry   siteEntry
+	Months  []siteArchive
+	Tags    []siteArchive
+}
+
+type siteEntry struct {
+	CommitID    string
+	Title       string
+	Date        time.Time
+	Path        string
+	Body        template.HTML
+	Tags        []siteLink
+	Attachments []siteAttachment
+}
+
+type siteLink struct {
+	Name string
+	Path string
+}
+
+type siteAttachment struct {
+	Name  string
+	Path  string
+	Image bool
+}
+
+type siteArchive struct {
+	Title   string
+	Path    string
+	Entries []siteEntry
+}
+
+type siteSearchEntry struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	Date  string   `json:"date"`
+	URL   string   `json:"url"`
+	Tags  []string `json:"tags"`
+	Text  string   `json:"text"`
+}
+
+// BuildSite renders the committed entries as a static HTML site: an index,
+// a page per entry, monthly and tag archives, and a search index. The site
+// is written to outputDir, or to a site directory next to the repository
+// when outputDir is empty, replacing the previous build.
+func (m *Manager) BuildSite(outputDir string) (SiteReport, error) {
+	if !m.IsInitialized() {
+		return SiteReport{}, ErrRepositoryNotInitialized
+	}
+	if outputDir == "" {
+		outputDir = filepath.Join(m.Config.DataDir, siteDirectoryName)
+	}
+	outputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return SiteReport{}, fmt.Errorf("resolve site directory: %w", err)
+	}
+	if err := checkSiteOutputDir(outputDir); err != nil {
+		return SiteReport{}, err
+	}
+
+	templates, overrides, err := m.siteTemplates()
+	if err != nil {
+		return SiteReport{}, err
+	}
+	entries, err := m.GetLatestEntries(0)
+	if err != nil {
+		return SiteReport{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputDir), 0755); err != nil {
+		return SiteReport{}, fmt.Errorf("create site directory: %w", err)
+	}
+	buildDir, err := os.MkdirTemp(filepath.Dir(outputDir), "."+filepath.Base(outputDir)+".tmp-*")
+	if err != nil {
+		return SiteReport{}, fmt.Errorf("create site directory: %w", err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	report, err := m.writeSite(buildDir, entries, templates)
+	if err != nil {
+		return SiteReport{}, err
+	}
+	if err := os.Chmod(buildDir, 0755); err != nil {
+		return SiteReport{}, fmt.Errorf("create site directory: %w", err)
+	}
+	if err := os.RemoveAll(outputDir); err != nil {
+		return SiteReport{}, fmt.Errorf("remove previous site: %w", err)
+	}
+	if err := os.Rename(buildDir, outputDir); err != nil {
+		return SiteReport{}, fmt.Errorf("move site into place: %w", err)
+	}
+
+	report.OutputDir = outputDir
+	report.Overrides = overrides
+	return report, nil
+}
+
+// checkSiteOutputDir refuses to replace a directory that BuildSite did not
+// create, so a mistyped --output cannot delete unrelated files.
+func checkSiteOutputDir(outputDir string) error {
+	names, err := os.ReadDir(outputDir)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(names) == 0) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read site directory: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, siteMarkerFileName)); err != nil {
+		return fmt.Errorf("%s is not empty and was not created by 'til site build'; choose another --output", outputDir)
+	}
+	return nil
+}
+
+// siteTemplates loads each template from .til/templates when it exists
+// there and from the built-in set otherwise.
+func (m *Manager) siteTemplates() (map[string][]byte, []string, error) {
+	overrideDir := filepath.Join(m.Config.DataDir, metadataDirectoryName, siteTemplatesDirectoryName)
+	templates := make(map[string][]byte, len(SiteTemplateNames))
+	overrides := []string{}
+	for _, name := range SiteTemplateNames {
+		content, err := os.ReadFile(filepath.Join(overrideDir, name))
+		if err == nil {
+			overrides = append(overrides, name)
+		} else if errors.Is(err, os.ErrNotExist) {
+			content, err = siteTemplates.ReadFile(path.Join("templates", "site", name))
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("read site template %s: %w", name, err)
+		}
+		templates[name] = content
+	}
+	return templates, overrides, nil
+}
+
+func (m *Manager) writeSite(buildDir string, entries []Entry, templates map[string][]byte) (SiteReport, error) {
+	report := SiteReport{Entries: len(entries)}
+	render := func(pageName string, target string, page sitePage) error {
+		pageTemplate, err := template.New(pageName).Parse(string(templates["layout.html"]))
+		if err == nil {
+			_, err = pageTemplate.Parse(string(templates[pageName]))
+		}
+		if err != nil {
+			return fmt.Errorf("parse site template %s: %w", pageName, err)
+		}
+		var content bytes.Buffer
+		if err := pageTemplate.ExecuteTemplate(&content, "layout", page); err != nil {
+			return fmt.Errorf("render %s: %w", target, err)
+		}
+		if err := writeSiteFile(buildDir, target, content.Bytes()); err != nil {
+			return err
+		}
+		report.Pages++
+		return nil
+	}
+
+	siteEntries := make([]siteEntry, 0, len(entries))
+	months := []siteArchive{}
+	tagEntries := map[string][]siteEntry{}
+	search := make([]siteSearchEntry, 0, len(entries))
+	for _, entry := range entries {
+		page := siteEntry{
+			CommitID: entry.CommitID,
+			Title:    entry.Message,
+			Date:     entry.Date,
+			Path:     "entries/" + siteFileName(entry.CommitID) + ".html",
+			Body:     markdownHTML(entry.MessageBody),
+		}
+		for _, tag := range entry.Tags {
+			page.Tags = append(page.Tags, siteLink{Name: tag, Path: siteTagPath(tag)})
+		}
+		for _, fileName := range entry.Files {
+			stored := storedAttachmentName(entry, fileName)
+			copied, err := copySiteAttachment(filepath.Join(m.filesDir(), stored), filepath.Join(buildDir, filesDirectoryName, stored))
+			if err != nil {
+				return SiteReport{}, err
+			}
+			if !copied {
+				report.MissingFiles = append(report.MissingFiles, stored)
+			}
+			page.Attachments = append(page.Attachments, siteAttachment{
+				Name:  fileName,
+				Path:  filesDirectoryName + "/" + stored,
+				Image: siteImageExtensions[strings.ToLower(filepath.Ext(fileName))],
+			})
+		}
+		siteEntries = append(siteEntries, page)
+
+		// Entries are newest first, so each month's entries are contiguous.
+		month := entry.Date.Format("2006-01")
+		if len(months) == 0 || months[len(months)-1].Path != "months/"+month+".html" {
+			months = append(months, siteArchive{Title: entry.Date.Format("January 2006"), Path: "months/" + month + ".html"})
+		}
+		months[len(months)-1].Entries = append(months[len(months)-1].Entries, page)
+		for _, tag := range entry.Tags {
+			tagEntries[tag] = append(tagEntries[tag], page)
+		}
+
+		search = append(search, siteSearchEntry{
+			ID:    entry.CommitID,
+			Title: entry.Message,
+			Date:  entry.Date.Format("2006-01-02"),
+			URL:   page.Path,
+			Tags:  append([]string{}, entry.Tags...),
+			Text:  markdownPlainText(entry.MessageBody),
+		})
+	}
+
+	tags := make([]siteArchive, 0, len(tagEntries))
+	for tag, tagged := range tagEntries {
+		tags = append(tags, siteArchive{Title: "#" + tag, Path: siteTagPath(tag), Entries: tagged})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Title < tags[j].Title })
+
+	if err := render("index.html", "index.html", sitePage{Entries: siteEntries, Months: months, Tags: tags}); err != nil {
+		return SiteReport{}, err
+	}
+	for _, entry := range siteEntries {
+		if err := render("entry.html", entry.Path, sitePage{Title: entry.Title, Root: "../", Entry: entry}); err != nil {
+			return SiteReport{}, err
+		}
+	}
+	for _, archive := range append(months, tags...) {
+		page := sitePage{Title: archive.Title, Root: "../", Entries: archive.Entries}
+		if err := render("archive.html", archive.Path, page); err != nil {
+			return SiteReport{}, err
+		}
+	}
+
+	searchIndex, err := json.Marshal(search)
+	if err != nil {
+		return SiteReport{}, fmt.Errorf("encode search index: %w", err)
+	}
+	staticFiles := map[string][]byte{
+		siteSearchIndexName: searchIndex,
+		"style.css":         templates["style.css"],
+		"search.js":         templates["search.js"],
+		siteMarkerFileName:  nil,
+	}
+	for name, content := range staticFiles {
+		if err := writeSiteFile(buildDir, name, content); err != nil {
+			return SiteReport{}, err
+		}
+	}
+	return report, nil
+}
+
+func writeSiteFile(buildDir, target string, content []byte) error {
+	path := filepath.Join(buildDir, filepath.FromSlash(target))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("write %s: %w", target, err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", target, err)
+	}
+	return nil
+}
+
+// copySiteAttachment copies an attachment into the site and reports whether
+// the source file existed.
+func copySiteAttachment(source, target string) (bool, error) {
+	input, err := os.Open(source)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("read attachment: %w", err)
+	}
+	defer input.Close()
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return false, fmt.Errorf("copy attachment: %w", err)
+	}
+	output, err := os.Create(target)
+	if err != nil {
+		return false, fmt.Errorf("copy attachment: %w", err)
+	}
+	if _, err := io.Copy(output, input); err != nil {
+		output.Close()
+		return false, fmt.Errorf("copy attachment: %w", err)
+	}
+	if err := output.Close(); err != nil {
+		return false, fmt.Errorf("copy attachment: %w", err)
+	}
+	return true, nil
+}
+
+func siteTagPath(tag string) string {
+	return "tags/" + siteFileName(tag) + ".html"
+}
+
+// siteFileName turns a commit ID or tag into a file name that needs no URL
+// escaping. Names with other characters keep their safe characters and gain
+// a hash so that distinct tags never share a page.
+func siteFileName(name string) string {
+	if siteSlugPattern.MatchString(name) {
+		return name
+	}
+	slug := strings.Map(func(character rune) rune {
+		if (character >= 'a' && character <= 'z') || (character >= '0' && character <= '9') ||
+			character == '_' || character == '-' {
+			return character
+		}
+		return '-'
+	}, strings.ToLower(name))
+	sum := sha256.Sum256([]byte(name))
+	return strings.Trim(slug, "-") + "-" + hex.EncodeToString(sum[:4])
+}
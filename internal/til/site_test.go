@@ -0,0 +1,152 @@
+package til
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSite(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	entries := []Entry{
+		{
+			Date:        time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC),
+			Message:     "Go <generics>",
+			MessageBody: "## Constraints\n\nUse **any** and [docs](https://go.dev/doc).",
+			Files:       []string{"diagram.png", "notes.txt"},
+			Tags:        []string{"go", "c++"},
+			IsCommitted: true,
+			CommitID:    "go000001",
+		},
+		{
+			Date:        time.Date(2025, 4, 2, 9, 0, 0, 0, time.UTC),
+			Message:     "SQLite WAL",
+			IsCommitted: true,
+			CommitID:    "wal00002",
+		},
+	}
+	for _, entry := range entries {
+		require.NoError(t, manager.insertEntry(entry))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(manager.filesDir(), "go000001_diagram.png"), []byte("png"), 0644))
+
+	report, err := manager.BuildSite("")
+	require.NoError(t, err)
+	site := filepath.Join(root, "site")
+	assert.Equal(t, site, report.OutputDir)
+	assert.Equal(t, 2, report.Entries)
+	// The index, two entries, two months, and two tags.
+	assert.Equal(t, 7, report.Pages)
+	assert.Equal(t, []string{"go000001_notes.txt"}, report.MissingFiles)
+
+	index, err := os.ReadFile(filepath.Join(site, "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(index), `<a href="entries/go000001.html">Go &lt;generics&gt;</a>`)
+	assert.Contains(t, string(index), `<a href="months/2025-04.html">April 2025</a> (1)`)
+	assert.Contains(t, string(index), `<a href="tags/go.html">#go</a> (1)`)
+
+	page, err := os.ReadFile(filepath.Join(site, "entries", "go000001.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "<h3>Constraints</h3>")
+	assert.Contains(t, string(page), `Use <strong>any</strong> and <a href="https://go.dev/doc">docs</a>.`)
+	assert.Contains(t, string(page), `<img src="../files/go000001_diagram.png" alt="diagram.png">`)
+	assert.Contains(t, string(page), `<a href="../files/go000001_notes.txt">notes.txt</a>`)
+	assert.FileExists(t, filepath.Join(site, "files", "go000001_diagram.png"))
+	assert.FileExists(t, filepath.Join(site, "months", "2025-03.html"))
+	assert.FileExists(t, filepath.Join(site, "tags", siteFileName("c++")+".html"))
+
+	var search []siteSearchEntry
+	data, err := os.ReadFile(filepath.Join(site, "search-index.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &search))
+	require.Len(t, search, 2)
+	assert.Equal(t, "entries/wal00002.html", search[0].URL)
+	assert.Equal(t, "Constraints\nUse any and docs.", search[1].Text)
+
+	// A rebuild replaces the previous site.
+	require.NoError(t, manager.insertEntry(Entry{
+		Date:        time.Date(2025, 4, 3, 9, 0, 0, 0, time.UTC),
+		Message:     "Third entry",
+		IsCommitted: true,
+		CommitID:    "thd00003",
+	}))
+	report, err = manager.BuildSite(site)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Entries)
+	assert.FileExists(t, filepath.Join(site, "entries", "thd00003.html"))
+}
+
+func TestBuildSiteUsesTemplateOverrides(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	require.NoError(t, manager.insertEntry(Entry{
+		Date:        time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC),
+		Message:     "Go generics",
+		IsCommitted: true,
+		CommitID:    "go000001",
+	}))
+	templates := filepath.Join(root, ".til", "templates")
+	require.NoError(t, os.MkdirAll(templates, 0755))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(templates, "entry.html"),
+		[]byte(`{{define "content"}}<p class="custom">{{.Entry.Title}}</p>{{end}}`),
+		0644,
+	))
+
+	report, err := manager.BuildSite(filepath.Join(root, "public"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"entry.html"}, report.Overrides)
+	page, err := os.ReadFile(filepath.Join(root, "public", "entries", "go000001.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), `<p class="custom">Go generics</p>`)
+	assert.Contains(t, string(page), `<link rel="stylesheet" href="../style.css">`)
+
+	require.NoError(t, os.WriteFile(filepath.Join(templates, "index.html"), []byte(`{{define "content"}`), 0644))
+	_, err = manager.BuildSite(filepath.Join(root, "public"))
+	assert.ErrorContains(t, err, "parse site template index.html")
+	assert.FileExists(t, filepath.Join(root, "public", "entries", "go000001.html"))
+}
+
+func TestBuildSiteRefusesUnrelatedDirectory(t *testing.T) {
+	manager, root := newTestManager(t, Config{})
+	output := filepath.Join(root, "documents")
+	require.NoError(t, os.MkdirAll(output, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(output, "keep.txt"), []byte("keep"), 0644))
+
+	_, err := manager.BuildSite(output)
+	assert.ErrorContains(t, err, "was not created by 'til site build'")
+	assert.FileExists(t, filepath.Join(output, "keep.txt"))
+}
+
+func TestMarkdownHTML(t *testing.T) {
+	body := "Intro with `<code>` & <b>tags</b>\n\n" +
+		"- one\n- two\n\n" +
+		"1. first\n\n" +
+		"- [x] done\n\n" +
+		"> quoted\n\n" +
+		"```go\nif a < b {}\n```\n\n" +
+		"---\n\n" +
+		"[bad](javascript:alert) and ~~old~~ *new*"
+	assert.Equal(
+		t,
+		"<p>Intro with <code>&lt;code&gt;</code> &amp; &lt;b&gt;tags&lt;/b&gt;</p>\n"+
+			"<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n"+
+			"<ol>\n<li>first</li>\n</ol>\n"+
+			"<ul class=\"tasks\">\n<li><input type=\"checkbox\" disabled checked> done</li>\n</ul>\n"+
+			"<blockquote><p>quoted</p></blockquote>\n"+
+			"<pre><code class=\"language-go\">if a &lt; b {}</code></pre>\n"+
+			"<hr>\n"+
+			"<p>bad and <del>old</del> <em>new</em></p>\n",
+		string(markdownHTML(body)),
+	)
+}
+
+func TestSiteFileName(t *testing.T) {
+	assert.Equal(t, "go", siteFileName("go"))
+	assert.Regexp(t, `^c-[0-9a-f]{8}$`, siteFileName("c++"))
+	assert.NotEqual(t, siteFileName("c++"), siteFileName("c#"))
+}
@@ -12,8 +12,6 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-const schemaVersion = 1
-
 type EntryQuery struct {
 	Limit       int
 	Since       *time.Time
@@ -22,40 +20,12 @@ type EntryQuery struct {
 	SinceDate   string
 	BeforeDate  string
 	Search      string
+	CommitID    string
+	Tags        []string
+	Literal     bool
 	OldestFirst bool
 }
 
-const databaseSchema = `
-CREATE TABLE IF NOT EXISTS entries (
-    id                   INTEGER PRIMARY KEY AUTOINCREMENT,
-    commit_id            TEXT NOT NULL UNIQUE,
-    created_at           TEXT NOT NULL,
-    created_at_unix_nano INTEGER NOT NULL,
-    created_date         TEXT NOT NULL,
-    message              TEXT NOT NULL CHECK (trim(message) <> ''),
-    message_body         TEXT NOT NULL DEFAULT '',
-    is_committed         INTEGER NOT NULL DEFAULT 1 CHECK (is_committed IN (0, 1)),
-    notion_synced        INTEGER NOT NULL DEFAULT 0 CHECK (notion_synced IN (0, 1))
-);
-
-CREATE INDEX IF NOT EXISTS entries_created_at_idx
-    ON entries (created_at_unix_nano DESC, id DESC);
-
-CREATE INDEX IF NOT EXISTS entries_created_date_idx
-    ON entries (created_date, created_at_unix_nano DESC);
-
-CREATE TABLE IF NOT EXISTS attachments (
-    entry_id  INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
-    position  INTEGER NOT NULL,
-    file_name TEXT NOT NULL,
-    PRIMARY KEY (entry_id, file_name),
-    UNIQUE (entry_id, position)
-);
-
-CREATE INDEX IF NOT EXISTS attachments_file_name_idx
-    ON attachments (file_name);
-`
-
 func (m *Manager) initializeDatabase() (retErr error) {
 	if m.IsInitialized() {
 		return errors.New("TIL repository already initialized")
@@ -76,17 +46,14 @@ func (m *Manager) initializeDatabase() (retErr error) {
 		}
 	}()
 
-	db, err := m.openDatabase()
+	db, _, err := m.openDatabaseConnection()
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	if _, err := db.Exec(databaseSchema); err != nil {
-		return fmt.Errorf("create SQL schema: %w", err)
-	}
-	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion)); err != nil {
-		return fmt.Errorf("set SQL schema version: %w", err)
+	if _, err := migrateDatabaseSchema(db); err != nil {
+		return err
 	}
 	if err := os.Chmod(databasePath, 0644); err != nil {
 		return fmt.Errorf("set database permissions: %w", err)
@@ -95,9 +62,25 @@ func (m *Manager) initializeDatabase() (retErr error) {
 }
 
 func (m *Manager) openDatabase() (*sql.DB, error) {
+	db, version, err := m.openDatabaseConnection()
+	if err != nil {
+		return nil, err
+	}
+	if version < schemaVersion {
+		if _, err := m.upgradeDatabaseSchema(db, version); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// openDatabaseConnection opens the database and reads its schema version without
+// applying pending migrations.
+func (m *Manager) openDatabaseConnection() (*sql.DB, int, error) {
 	db, err := sql.Open("sqlite", m.databasePath())
 	if err != nil {
-		return nil, fmt.Errorf("open TIL database: %w", err)
+		return nil, 0, fmt.Errorf("open TIL database: %w", err)
 	}
 	db.SetMaxOpenConns(1)
 	db.SetMaxIdleConns(1)
@@ -108,24 +91,24 @@ func (m *Manager) openDatabase() (*sql.DB, error) {
 	} {
 		if _, err := db.Exec(statement); err != nil {
 			db.Close()
-			return nil, fmt.Errorf("configure TIL database: %w", err)
+			return nil, 0, fmt.Errorf("configure TIL database: %w", err)
 		}
 	}
 
 	var version int
 	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("read SQL schema version: %w", err)
+		return nil, 0, fmt.Errorf("read SQL schema version: %w", err)
 	}
 	if version > schemaVersion {
 		db.Close()
-		return nil, fmt.Errorf(
+		return nil, 0, fmt.Errorf(
 			"TIL database schema version %d is newer than supported version %d",
 			version,
 			schemaVersion,
 		)
 	}
-	return db, nil
+	return db, version, nil
 }
 
 func (m *Manager) insertEntry(entry Entry) error {
@@ -163,14 +146,27 @@ func (m *Manager) updateEntry(entry Entry) error {
 	}
 	defer transaction.Rollback()
 
+	var entryID int64
+	if err := transaction.QueryRow(
+		"SELECT id FROM entries WHERE commit_id = ?",
+		entry.CommitID,
+	).Scan(&entryID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("entry %s not found", entry.CommitID)
+		}
+		return fmt.Errorf("find amended entry: %w", err)
+	}
+	if err := recordEntryRevision(transaction, entryID, entry); err != nil {
+		return err
+	}
+
 	result, err := transaction.Exec(
 		`UPDATE entries
-         SET message = ?, message_body = ?, is_committed = ?, notion_synced = ?
+         SET message = ?, message_body = ?, is_committed = ?
          WHERE commit_id = ?`,
 		entry.Message,
 		entry.MessageBody,
 		boolInt(entry.IsCommitted),
-		boolInt(entry.NotionSynced),
 		entry.CommitID,
 	)
 	if err != nil {
@@ -183,18 +179,20 @@ func (m *Manager) updateEntry(entry Entry) error {
 	if affected != 1 {
 		return fmt.Errorf("entry %s not found", entry.CommitID)
 	}
-
-	var entryID int64
-	if err := transaction.QueryRow(
-		"SELECT id FROM entries WHERE commit_id = ?",
-		entry.CommitID,
-	).Scan(&entryID); err != nil {
-		return fmt.Errorf("find amended entry: %w", err)
+	if err := markEntryChanged(transaction, entryID, entry.NotionSynced); err != nil {
+		return err
 	}
+
 	if _, err := transaction.Exec("DELETE FROM attachments WHERE entry_id = ?", entryID); err != nil {
 		return fmt.Errorf("replace entry attachments: %w", err)
 	}
-	if err := insertAttachments(transaction, entryID, entry.Files); err != nil {
+	if err := insertAttachments(transaction, entryID, entry); err != nil {
+		return err
+	}
+	if _, err := transaction.Exec("DELETE FROM entry_tags WHERE entry_id = ?", entryID); err != nil {
+		return fmt.Errorf("replace entry tags: %w", err)
+	}
+	if err := insertEntryTags(transaction, entryID, entry.Tags); err != nil {
 		return err
 	}
 
@@ -205,6 +203,18 @@ func (m *Manager) updateEntry(entry Entry) error {
 }
 
 func (m *Manager) QueryEntries(query EntryQuery) ([]Entry, error) {
+	matches, err := m.queryEntries(query)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, len(matches))
+	for i, match := range matches {
+		entries[i] = match.Entry
+	}
+	return entries, nil
+}
+
+func (m *Manager) queryEntries(query EntryQuery) ([]EntryMatch, error) {
 	if !m.IsInitialized() {
 		return nil, ErrRepositoryNotInitialized
 	}
@@ -223,6 +233,10 @@ func (m *Manager) QueryEntries(query EntryQuery) ([]Entry, error) {
 			return nil, fmt.Errorf("%s must use YYYY-MM-DD: %w", name, err)
 		}
 	}
+	tags, err := normalizeTags(query.Tags)
+	if err != nil {
+		return nil, err
+	}
 
 	db, err := m.openDatabase()
 	if err != nil {
@@ -230,8 +244,13 @@ func (m *Manager) QueryEntries(query EntryQuery) ([]Entry, error) {
 	}
 	defer db.Close()
 
+	direction := "DESC"
+	if query.OldestFirst {
+		direction = "ASC"
+	}
 	conditions := []string{"1 = 1"}
-	arguments := []any{}
+	// The first argument selects the Notion sync state joined onto each entry.
+	arguments := []any{notionDestinationName}
 	if query.Since != nil {
 		conditions = append(conditions, "e.created_at_unix_nano >= ?")
 		arguments = append(arguments, query.Since.UnixNano())
@@ -252,7 +271,25 @@ func (m *Manager) QueryEntries(query EntryQuery) ([]Entry, error) {
 		conditions = append(conditions, "e.created_date < ?")
 		arguments = append(arguments, query.BeforeDate)
 	}
-	if search := strings.TrimSpace(query.Search); search != "" {
+	if query.CommitID != "" {
+		conditions = append(conditions, "e.commit_id = ?")
+		arguments = append(arguments, query.CommitID)
+	}
+	for _, tag := range tags {
+		conditions = append(conditions, `EXISTS (
+            SELECT 1
+            FROM entry_tags et
+            JOIN tags t ON t.id = et.tag_id
+            WHERE et.entry_id = e.id
+              AND t.name = ?
+        )`)
+		arguments = append(arguments, tag)
+	}
+	search := strings.TrimSpace(query.Search)
+	source := "entries e"
+	rankColumns := "0.0, ''"
+	order := fmt.Sprintf("e.created_at_unix_nano %[1]s, e.id %[1]s", direction)
+	if search != "" && query.Literal {
 		pattern := "%" + escapeLike(strings.ToLower(search)) + "%"
 		conditions = append(conditions, `(
             lower(e.commit_id) LIKE ? ESCAPE '\' OR
@@ -266,21 +303,36 @@ func (m *Manager) QueryEntries(query EntryQuery) ([]Entry, error) {
             )
         )`)
 		arguments = append(arguments, pattern, pattern, pattern, pattern)
+	} else if search != "" {
+		expression, err := buildFullTextQuery(search)
+		if err != nil {
+			return nil, err
+		}
+		source = "entries_fts JOIN entries e ON e.id = entries_fts.rowid"
+		rankColumns = fmt.Sprintf(
+			"bm25(entries_fts, 2.0, 10.0, 4.0, 2.0), snippet(entries_fts, -1, '%s', '%s', '…', 12)",
+			SnippetHighlightStart,
+			SnippetHighlightEnd,
+		)
+		conditions = append(conditions, "entries_fts MATCH ?")
+		arguments = append(arguments, expression)
+		if !query.OldestFirst {
+			order = "bm25(entries_fts, 2.0, 10.0, 4.0, 2.0), " + order
+		}
 	}
 
-	direction := "DESC"
-	if query.OldestFirst {
-		direction = "ASC"
-	}
 	statement := fmt.Sprintf(
 		`SELECT e.id, e.commit_id, e.created_at, e.message, e.message_body,
-                e.is_committed, e.notion_synced
-         FROM entries e
+                e.is_committed, coalesce(ns.synced, 0), coalesce(ns.remote_id, ''),
+                coalesce(ns.content_hash, ''), coalesce(ns.synced_at, ''), %s
+         FROM %s
+         LEFT JOIN destination_sync ns ON ns.entry_id = e.id AND ns.destination = ?
          WHERE %s
-         ORDER BY e.created_at_unix_nano %s, e.id %s`,
+         ORDER BY %s`,
+		rankColumns,
+		source,
 		strings.Join(conditions, " AND "),
-		direction,
-		direction,
+		order,
 	)
 	if query.Limit > 0 {
 		statement += " LIMIT ?"
@@ -289,12 +341,15 @@ func (m *Manager) QueryEntries(query EntryQuery) ([]Entry, error) {
 
 	rows, err := db.Query(statement, arguments...)
 	if err != nil {
+		if search != "" && !query.Literal && strings.Contains(err.Error(), "fts5") {
+			return nil, fmt.Errorf("invalid search query %q: %w", search, err)
+		}
 		return nil, fmt.Errorf("query entries: %w", err)
 	}
 
 	type resultEntry struct {
 		id    int64
-		entry Entry
+		entry EntryMatch
 	}
 	results := []resultEntry{}
 	for rows.Next() {
@@ -303,6 +358,7 @@ func (m *Manager) QueryEntries(query EntryQuery) ([]Entry, error) {
 			createdAt      string
 			isCommitted    int
 			notionIsSynced int
+			notionSyncedAt string
 		)
 		if err := rows.Scan(
 			&result.id,
@@ -312,6 +368,11 @@ func (m *Manager) QueryEntries(query EntryQuery) ([]Entry, error) {
 			&result.entry.MessageBody,
 			&isCommitted,
 			&notionIsSynced,
+			&result.entry.NotionPageID,
+			&result.entry.NotionContentHash,
+			&notionSyncedAt,
+			&result.entry.Rank,
+			&result.entry.Snippet,
 		); err != nil {
 			rows.Close()
 			return nil, fmt.Errorf("scan entry: %w", err)
@@ -321,6 +382,13 @@ func (m *Manager) QueryEntries(query EntryQuery) ([]Entry, error) {
 			rows.Close()
 			return nil, fmt.Errorf("parse entry timestamp: %w", err)
 		}
+		if notionSyncedAt != "" {
+			result.entry.NotionSyncedAt, err = time.Parse(time.RFC3339Nano, notionSyncedAt)
+			if err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("parse Notion sync timestamp: %w", err)
+			}
+		}
 		result.entry.IsCommitted = isCommitted != 0
 		result.entry.NotionSynced = notionIsSynced != 0
 		results = append(results, result)
@@ -333,13 +401,18 @@ func (m *Manager) QueryEntries(query EntryQuery) ([]Entry, error) {
 		return nil, fmt.Errorf("close entry rows: %w", err)
 	}
 
-	entries := make([]Entry, len(results))
+	entries := make([]EntryMatch, len(results))
 	for i, result := range results {
 		attachments, err := loadAttachments(db, result.id)
 		if err != nil {
 			return nil, err
 		}
-		result.entry.Files = attachments
+		result.entry.Files = attachmentNames(attachments)
+		result.entry.Attachments = attachments
+		result.entry.Tags, err = loadEntryTags(db, result.id)
+		if err != nil {
+			return nil, err
+		}
 		entries[i] = result.entry
 	}
 	return entries, nil
@@ -362,28 +435,233 @@ func (m *Manager) commitIDExists(commitID string) (bool, error) {
 	return exists, nil
 }
 
-func (m *Manager) updateNotionSyncStatus(entry Entry) error {
+func (m *Manager) commitIDsWithPrefix(prefix string) ([]string, error) {
 	db, err := m.openDatabase()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer db.Close()
 
-	result, err := db.Exec(
-		"UPDATE entries SET notion_synced = ? WHERE commit_id = ?",
-		boolInt(entry.NotionSynced),
-		entry.CommitID,
+	rows, err := db.Query(
+		`SELECT commit_id
+         FROM entries
+         WHERE substr(commit_id, 1, length(?)) = ?
+         ORDER BY created_at_unix_nano DESC, id DESC`,
+		prefix,
+		prefix,
 	)
 	if err != nil {
-		return fmt.Errorf("update Notion sync status: %w", err)
+		return nil, fmt.Errorf("query commit IDs: %w", err)
+	}
+	defer rows.Close()
+
+	commitIDs := []string{}
+	for rows.Next() {
+		var commitID string
+		if err := rows.Scan(&commitID); err != nil {
+			return nil, fmt.Errorf("scan commit ID: %w", err)
+		}
+		commitIDs = append(commitIDs, commitID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate commit IDs: %w", err)
+	}
+	return commitIDs, nil
+}
+
+func (m *Manager) deleteEntry(commitID string) error {
+	db, err := m.openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	transaction, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin delete transaction: %w", err)
+	}
+	defer transaction.Rollback()
+
+	// Published pages are archived on the next Notion push.
+	if _, err := transaction.Exec(
+		`INSERT OR IGNORE INTO notion_page_tombstones (page_id, commit_id, message, deleted_at)
+         SELECT ds.remote_id, e.commit_id, e.message, ?
+         FROM entries e
+         JOIN destination_sync ds ON ds.entry_id = e.id AND ds.destination = ?
+         WHERE e.commit_id = ? AND ds.remote_id <> ''`,
+		time.Now().Format(time.RFC3339Nano),
+		notionDestinationName,
+		commitID,
+	); err != nil {
+		return fmt.Errorf("record Notion page for archiving: %w", err)
+	}
+	result, err := transaction.Exec("DELETE FROM entries WHERE commit_id = ?", commitID)
+	if err != nil {
+		return fmt.Errorf("delete entry: %w", err)
 	}
 	affected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("read Notion sync update count: %w", err)
+		return fmt.Errorf("inspect deleted entry: %w", err)
 	}
 	if affected != 1 {
+		return fmt.Errorf("entry %s not found", commitID)
+	}
+	if _, err := transaction.Exec(
+		"DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM entry_tags)",
+	); err != nil {
+		return fmt.Errorf("remove unused tags: %w", err)
+	}
+
+	if err := transaction.Commit(); err != nil {
+		return fmt.Errorf("commit delete transaction: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) updateNotionSyncStatus(entry Entry) error {
+	return m.recordNotionSync(entry, time.Now())
+}
+
+// recordNotionSync stores an entry's sync state. syncedAt is when Notion last
+// matched the local entry and is only recorded for synced entries.
+func (m *Manager) recordNotionSync(entry Entry, syncedAt time.Time) error {
+	state := DestinationSync{Synced: entry.NotionSynced, RemoteID: entry.NotionPageID}
+	if entry.NotionSynced {
+		state.ContentHash = EntryContentHash(entry)
+		state.SyncedAt = syncedAt
+	}
+	err := m.recordDestinationSync(notionDestinationName, map[string]DestinationSync{entry.CommitID: state})
+	if errors.Is(err, errEntryNotFound) {
 		return fmt.Errorf("entry %q not found", entry.Message)
 	}
+	return err
+}
+
+var errEntryNotFound = errors.New("entry not found")
+
+// recordDestinationSync stores sync state for entries keyed by commit ID. An
+// empty remote ID or zero sync time keeps the previously recorded value.
+func (m *Manager) recordDestinationSync(destination string, states map[string]DestinationSync) error {
+	db, err := m.openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	transaction, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin sync status transaction: %w", err)
+	}
+	defer transaction.Rollback()
+
+	for commitID, state := range states {
+		syncedAt := ""
+		if !state.SyncedAt.IsZero() {
+			syncedAt = state.SyncedAt.Format(time.RFC3339Nano)
+		}
+		result, err := transaction.Exec(
+			`INSERT INTO destination_sync (entry_id, destination, synced, remote_id, content_hash, synced_at)
+             SELECT id, ?, ?, ?, ?, ?
+             FROM entries
+             WHERE commit_id = ?
+             ON CONFLICT (entry_id, destination) DO UPDATE
+             SET synced = excluded.synced,
+                 remote_id = coalesce(nullif(excluded.remote_id, ''), remote_id),
+                 content_hash = excluded.content_hash,
+                 synced_at = coalesce(nullif(excluded.synced_at, ''), synced_at)`,
+			destination,
+			boolInt(state.Synced),
+			state.RemoteID,
+			state.ContentHash,
+			syncedAt,
+			commitID,
+		)
+		if err != nil {
+			return fmt.Errorf("update %s sync status: %w", destination, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("read %s sync update count: %w", destination, err)
+		}
+		if affected != 1 {
+			return fmt.Errorf("%w: %s", errEntryNotFound, commitID)
+		}
+	}
+
+	if err := transaction.Commit(); err != nil {
+		return fmt.Errorf("commit sync status transaction: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) destinationSyncStates(destination string) (map[string]DestinationSync, error) {
+	db, err := m.openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(
+		`SELECT e.commit_id, ds.synced, ds.remote_id, ds.content_hash, ds.synced_at
+         FROM destination_sync ds
+         JOIN entries e ON e.id = ds.entry_id
+         WHERE ds.destination = ?`,
+		destination,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query %s sync status: %w", destination, err)
+	}
+	defer rows.Close()
+
+	states := map[string]DestinationSync{}
+	for rows.Next() {
+		var (
+			commitID string
+			state    DestinationSync
+			synced   int
+			syncedAt string
+		)
+		if err := rows.Scan(&commitID, &synced, &state.RemoteID, &state.ContentHash, &syncedAt); err != nil {
+			return nil, fmt.Errorf("scan %s sync status: %w", destination, err)
+		}
+		state.Synced = synced != 0
+		if syncedAt != "" {
+			state.SyncedAt, err = time.Parse(time.RFC3339Nano, syncedAt)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s sync timestamp: %w", destination, err)
+			}
+		}
+		states[commitID] = state
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate %s sync status: %w", destination, err)
+	}
+	return states, nil
+}
+
+// markEntryChanged records that an entry's content changed, so destinations
+// need it pushed again. Notion instead follows entry.NotionSynced because a
+// Notion pull updates entries to match what is already published there.
+func markEntryChanged(transaction *sql.Tx, entryID int64, notionSynced bool) error {
+	if _, err := transaction.Exec(
+		"UPDATE destination_sync SET synced = 0 WHERE entry_id = ? AND (destination <> ? OR ?)",
+		entryID,
+		notionDestinationName,
+		boolInt(!notionSynced),
+	); err != nil {
+		return fmt.Errorf("reset entry sync status: %w", err)
+	}
+	if !notionSynced {
+		return nil
+	}
+	if _, err := transaction.Exec(
+		`INSERT INTO destination_sync (entry_id, destination, synced) VALUES (?, ?, 1)
+         ON CONFLICT (entry_id, destination) DO UPDATE SET synced = 1`,
+		entryID,
+		notionDestinationName,
+	); err != nil {
+		return fmt.Errorf("update Notion sync status: %w", err)
+	}
 	return nil
 }
 
@@ -418,8 +696,8 @@ func insertEntryTransaction(transaction *sql.Tx, entry Entry) (int64, error) {
 	result, err := transaction.Exec(
 		`INSERT INTO entries (
              commit_id, created_at, created_at_unix_nano, created_date,
-             message, message_body, is_committed, notion_synced
-         ) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+             message, message_body, is_committed
+         ) VALUES (?, ?, ?, ?, ?, ?, ?)`,
 		entry.CommitID,
 		entry.Date.Format(time.RFC3339Nano),
 		entry.Date.UnixNano(),
@@ -427,7 +705,6 @@ func insertEntryTransaction(transaction *sql.Tx, entry Entry) (int64, error) {
 		entry.Message,
 		entry.MessageBody,
 		boolInt(entry.IsCommitted),
-		boolInt(entry.NotionSynced),
 	)
 	if err != nil {
 		return 0, fmt.Errorf("insert entry %s: %w", entry.CommitID, err)
@@ -436,19 +713,45 @@ func insertEntryTransaction(transaction *sql.Tx, entry Entry) (int64, error) {
 	if err != nil {
 		return 0, fmt.Errorf("read inserted entry ID: %w", err)
 	}
-	if err := insertAttachments(transaction, entryID, entry.Files); err != nil {
+	if entry.NotionSynced || entry.NotionPageID != "" || entry.NotionContentHash != "" {
+		syncedAt := ""
+		if !entry.NotionSyncedAt.IsZero() {
+			syncedAt = entry.NotionSyncedAt.Format(time.RFC3339Nano)
+		}
+		if _, err := transaction.Exec(
+			`INSERT INTO destination_sync (entry_id, destination, synced, remote_id, content_hash, synced_at)
+             VALUES (?, ?, ?, ?, ?, ?)`,
+			entryID,
+			notionDestinationName,
+			boolInt(entry.NotionSynced),
+			entry.NotionPageID,
+			entry.NotionContentHash,
+			syncedAt,
+		); err != nil {
+			return 0, fmt.Errorf("insert Notion sync status for %s: %w", entry.CommitID, err)
+		}
+	}
+	if err := insertAttachments(transaction, entryID, entry); err != nil {
+		return 0, err
+	}
+	if err := insertEntryTags(transaction, entryID, entry.Tags); err != nil {
 		return 0, err
 	}
 	return entryID, nil
 }
 
-func insertAttachments(transaction *sql.Tx, entryID int64, files []string) error {
-	for position, fileName := range files {
+func insertAttachments(transaction *sql.Tx, entryID int64, entry Entry) error {
+	for position, fileName := range entry.Files {
+		attachment, _ := entry.attachment(fileName)
 		if _, err := transaction.Exec(
-			"INSERT INTO attachments (entry_id, position, file_name) VALUES (?, ?, ?)",
+			`INSERT INTO attachments (entry_id, position, file_name, sha256, size, media_type)
+             VALUES (?, ?, ?, ?, ?, ?)`,
 			entryID,
 			position,
 			fileName,
+			attachment.SHA256,
+			attachment.Size,
+			attachment.MediaType,
 		); err != nil {
 			return fmt.Errorf("insert attachment %s: %w", fileName, err)
 		}
@@ -456,9 +759,14 @@ func insertAttachments(transaction *sql.Tx, entryID int64, files []string) error
 	return nil
 }
 
-func loadAttachments(db *sql.DB, entryID int64) ([]string, error) {
+// sqlQueryer is satisfied by both *sql.DB and *sql.Tx.
+type sqlQueryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func loadAttachments(db sqlQueryer, entryID int64) ([]Attachment, error) {
 	rows, err := db.Query(
-		"SELECT file_name FROM attachments WHERE entry_id = ? ORDER BY position",
+		"SELECT file_name, sha256, size, media_type FROM attachments WHERE entry_id = ? ORDER BY position",
 		entryID,
 	)
 	if err != nil {
@@ -466,18 +774,23 @@ func loadAttachments(db *sql.DB, entryID int64) ([]string, error) {
 	}
 	defer rows.Close()
 
-	files := []string{}
+	attachments := []Attachment{}
 	for rows.Next() {
-		var fileName string
-		if err := rows.Scan(&fileName); err != nil {
+		var attachment Attachment
+		if err := rows.Scan(
+			&attachment.Name,
+			&attachment.SHA256,
+			&attachment.Size,
+			&attachment.MediaType,
+		); err != nil {
 			return nil, fmt.Errorf("scan attachment: %w", err)
 		}
-		files = append(files, fileName)
+		attachments = append(attachments, attachment)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterate attachments: %w", err)
 	}
-	return files, nil
+	return attachments, nil
 }
 
 func boolInt(value bool) int {
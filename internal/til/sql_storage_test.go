@@ -1,6 +1,7 @@
 package til
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -102,6 +103,11 @@ func TestSQLiteEntryUpdateIsTransactional(t *testing.T) {
 	entry.Message = "Updated"
 	entry.MessageBody = "A longer explanation"
 	entry.Files = []string{"after.txt", "diagram.png"}
+	entry.Attachments = []Attachment{
+		{Name: "after.txt", SHA256: strings.Repeat("a", 64), Size: 5, MediaType: "text/plain; charset=utf-8"},
+		{Name: "diagram.png"},
+	}
+	entry.Tags = []string{"go", "sql"}
 	entry.NotionSynced = true
 	require.NoError(t, manager.updateEntry(entry))
 
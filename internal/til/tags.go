@@ -0,0 +1,196 @@
+package til
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxTagLength = 64
+
+type TagCount struct {
+	Name    string
+	Entries int
+}
+
+// AddEntryTags attaches tags to an existing entry and returns its resulting tags.
+func (m *Manager) AddEntryTags(commitID string, tags []string) ([]string, error) {
+	return m.changeEntryTags(commitID, tags, func(current, changed []string) []string {
+		return mergeFileNames(current, changed)
+	})
+}
+
+// RemoveEntryTags detaches tags from an existing entry and returns its resulting tags.
+func (m *Manager) RemoveEntryTags(commitID string, tags []string) ([]string, error) {
+	return m.changeEntryTags(commitID, tags, func(current, changed []string) []string {
+		removed := make(map[string]struct{}, len(changed))
+		for _, tag := range changed {
+			removed[tag] = struct{}{}
+		}
+		remaining := []string{}
+		for _, tag := range current {
+			if _, ok := removed[tag]; !ok {
+				remaining = append(remaining, tag)
+			}
+		}
+		return remaining
+	})
+}
+
+// ListTags returns every tag in use with the number of entries carrying it.
+func (m *Manager) ListTags() ([]TagCount, error) {
+	if !m.IsInitialized() {
+		return nil, ErrRepositoryNotInitialized
+	}
+
+	db, err := m.openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(
+		`SELECT t.name, COUNT(et.entry_id)
+         FROM tags t
+         JOIN entry_tags et ON et.tag_id = t.id
+         GROUP BY t.id
+         ORDER BY t.name`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var tag TagCount
+		if err := rows.Scan(&tag.Name, &tag.Entries); err != nil {
+			return nil, fmt.Errorf("scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tags: %w", err)
+	}
+	return tags, nil
+}
+
+func (m *Manager) changeEntryTags(
+	commitID string,
+	tags []string,
+	change func(current, changed []string) []string,
+) ([]string, error) {
+	if !m.IsInitialized() {
+		return nil, ErrRepositoryNotInitialized
+	}
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if len(normalized) == 0 {
+		return nil, errors.New("at least one tag is required")
+	}
+
+	entry, err := m.GetEntry(commitID)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := normalizeTags(change(entry.Tags, normalized))
+	if err != nil {
+		return nil, err
+	}
+	if slices.Equal(changed, entry.Tags) {
+		return entry.Tags, nil
+	}
+	entry.Tags = changed
+	entry.NotionSynced = false
+	if err := m.updateEntry(entry); err != nil {
+		return nil, err
+	}
+	return entry.Tags, nil
+}
+
+// normalizeTags lowercases, validates, deduplicates, and sorts tag names.
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(tags))
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" {
+			return nil, errors.New("tag cannot be empty")
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, fmt.Errorf("tag %q exceeds %d characters", tag, maxTagLength)
+		}
+		if strings.ContainsFunc(tag, func(character rune) bool {
+			return unicode.IsSpace(character) || character == ',' || unicode.IsControl(character)
+		}) {
+			return nil, fmt.Errorf("tag %q cannot contain whitespace or commas", tag)
+		}
+		if _, duplicate := seen[tag]; duplicate {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+func insertEntryTags(transaction *sql.Tx, entryID int64, tags []string) error {
+	for _, tag := range tags {
+		if _, err := transaction.Exec(
+			"INSERT INTO tags (name) VALUES (?) ON CONFLICT (name) DO NOTHING",
+			tag,
+		); err != nil {
+			return fmt.Errorf("insert tag %s: %w", tag, err)
+		}
+		if _, err := transaction.Exec(
+			`INSERT INTO entry_tags (entry_id, tag_id)
+             SELECT ?, id FROM tags WHERE name = ?`,
+			entryID,
+			tag,
+		); err != nil {
+			return fmt.Errorf("tag entry with %s: %w", tag, err)
+		}
+	}
+	if _, err := transaction.Exec(
+		"DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM entry_tags)",
+	); err != nil {
+		return fmt.Errorf("remove unused tags: %w", err)
+	}
+	return nil
+}
+
+func loadEntryTags(db sqlQueryer, entryID int64) ([]string, error) {
+	rows, err := db.Query(
+		`SELECT t.name
+         FROM entry_tags et
+         JOIN tags t ON t.id = et.tag_id
+         WHERE et.entry_id = ?
+         ORDER BY t.name`,
+		entryID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query entry tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("scan entry tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate entry tags: %w", err)
+	}
+	return tags, nil
+}
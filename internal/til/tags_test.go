@@ -0,0 +1,81 @@
+package til
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := normalizeTags([]string{" Go ", "#sql", "go", "SQL"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "sql"}, tags)
+
+	for _, invalid := range []string{"", "  ", "two words", "a,b"} {
+		_, err := normalizeTags([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestEntryTagsCommitFilterAndEdit(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntryWithTags("Go generics", "", []string{"go", "types"}))
+	require.NoError(t, manager.CommitEntryWithTags("SQLite joins", "", []string{"sql"}))
+
+	entries, err := manager.QueryEntries(EntryQuery{OldestFirst: true})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, []string{"go", "types"}, entries[0].Tags)
+	assert.Equal(t, []string{"sql"}, entries[1].Tags)
+
+	filtered, err := manager.QueryEntries(EntryQuery{Tags: []string{"GO"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{entries[0].CommitID}, commitIDs(filtered))
+
+	filtered, err = manager.QueryEntries(EntryQuery{Tags: []string{"go", "sql"}})
+	require.NoError(t, err)
+	assert.Empty(t, filtered)
+
+	tags, err := manager.AddEntryTags(entries[1].CommitID, []string{"go"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "sql"}, tags)
+
+	tags, err = manager.RemoveEntryTags(entries[0].CommitID, []string{"types"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go"}, tags)
+
+	counts, err := manager.ListTags()
+	require.NoError(t, err)
+	assert.Equal(t, []TagCount{{Name: "go", Entries: 2}, {Name: "sql", Entries: 1}}, counts)
+
+	_, err = manager.AddEntryTags("missing", []string{"go"})
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestAmendMergesTags(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntryWithTags("Original", "", []string{"go"}))
+	require.NoError(t, manager.AmendLastEntryWithTags("Original", "", []string{"testing"}))
+
+	entries, err := manager.GetLatestEntries(1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, []string{"go", "testing"}, entries[0].Tags)
+	assert.False(t, entries[0].NotionSynced)
+}
+
+func TestOpenDatabaseUpgradesVersionOneSchema(t *testing.T) {
+	manager, _ := newTestManager(t, Config{})
+	require.NoError(t, manager.CommitEntry("Before tags"))
+
+	downgradeToVersionOne(t, manager)
+
+	entries, err := manager.GetLatestEntries(1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Empty(t, entries[0].Tags)
+
+	_, err = manager.AddEntryTags(entries[0].CommitID, []string{"upgraded"})
+	require.NoError(t, err)
+}
@@ -0,0 +1,53 @@
+// Filters entries on the index page using search-index.json.
+(function () {
+  const input = document.getElementById("search");
+  const results = document.getElementById("search-results");
+  if (!input || !results) {
+    return;
+  }
+
+  let index = null;
+  const load = () => {
+    if (!index) {
+      index = fetch("search-index.json")
+        .then((response) => response.json())
+        .catch(() => []);
+    }
+    return index;
+  };
+
+  const show = (entries) => {
+    results.replaceChildren(
+      ...entries.map((entry) => {
+        const item = document.createElement("li");
+        const date = document.createElement("time");
+        date.dateTime = entry.date;
+        date.textContent = entry.date;
+        const link = document.createElement("a");
+        link.href = entry.url;
+        link.textContent = entry.title;
+        item.append(date, " ", link);
+        return item;
+      }),
+    );
+  };
+
+  input.addEventListener("input", () => {
+    const terms = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    if (terms.length === 0) {
+      results.hidden = true;
+      return;
+    }
+    load().then((entries) => {
+      show(
+        entries.filter((entry) => {
+          const text = [entry.title, entry.text, ...entry.tags.map((tag) => "#" + tag)]
+            .join("\n")
+            .toLowerCase();
+          return terms.every((term) => text.includes(term));
+        }),
+      );
+      results.hidden = false;
+    });
+  });
+})();
@@ -28,9 +28,32 @@ type Config struct {
 	NotionAPIKeyAccount   string
 	NotionAPIKeyInKeyring bool
 	NotionAPIKeyLoadError error
+	NotionProperties      NotionProperties
 	SyncToNotion          bool
 	GitRemoteURL          string
 	SyncToGit             bool
+	// GitAuthor is the "Name <email>" Git pushes commit as. Empty uses Git's
+	// configured identity.
+	GitAuthor string
+	// GitCommitPerEntry makes Git pushes create one commit per changed entry,
+	// dated when the entry was written.
+	GitCommitPerEntry bool
+	// ReadmePages splits the generated README into per-year or per-month
+	// pages. Empty keeps every entry in README.md.
+	ReadmePages string
+
+	SyncToWebhook          bool
+	WebhookURL             string
+	WebhookHeaders         []WebhookHeader
+	WebhookSecret          string
+	WebhookSecretAccount   string
+	WebhookSecretLoadError error
+}
+
+// WebhookHeader is a custom header sent with every webhook delivery.
+type WebhookHeader struct {
+	Name  string
+	Value string
 }
 
 type Entry struct {
@@ -38,9 +61,20 @@ type Entry struct {
 	Message      string
 	MessageBody  string
 	Files        []string
+	Tags         []string
 	IsCommitted  bool
 	NotionSynced bool
 	CommitID     string
+
+	// Attachments records the stored content of Files, matched by name.
+	Attachments []Attachment
+
+	// NotionPageID and NotionContentHash record the page an entry was published
+	// to and the content it had at the time; NotionSyncedAt is when that happened.
+	// They and NotionSynced mirror the Notion destination's sync state.
+	NotionPageID      string
+	NotionContentHash string
+	NotionSyncedAt    time.Time
 }
 
 type Manager struct {
@@ -60,6 +94,8 @@ func (m *Manager) Init() error {
 	return m.initializeDatabase()
 }
 
+// UpdateEntryNotionSyncStatus records whether an entry is published. Marking it
+// synced also stores its page ID, when set, and the hash of its current content.
 func (m *Manager) UpdateEntryNotionSyncStatus(entry Entry) error {
 	if !m.IsInitialized() {
 		return ErrRepositoryNotInitialized
@@ -79,7 +115,14 @@ func (m *Manager) stagingDir() string {
 	return filepath.Join(m.Config.DataDir, metadataDirectoryName, "staging")
 }
 
+// storedAttachmentName is where one of an entry's files is stored, relative
+// to the files directory: its content object when the entry records a
+// digest, or the per-entry name used before attachments were
+// content-addressed.
 func storedAttachmentName(entry Entry, fileName string) string {
+	if attachment, ok := entry.attachment(fileName); ok && attachment.SHA256 != "" {
+		return attachmentObjectName(attachment.SHA256, fileName)
+	}
 	prefix := entry.CommitID
 	if prefix == "" {
 		prefix = entry.Date.Format("2006-01-02")
@@ -21,6 +21,11 @@ func newTestManager(t *testing.T, config Config) (*Manager, string) {
 	return manager, config.DataDir
 }
 
+// storedAttachmentPath is where one of an entry's files is stored.
+func storedAttachmentPath(manager *Manager, entry Entry, fileName string) string {
+	return filepath.Join(manager.filesDir(), storedAttachmentName(entry, fileName))
+}
+
 func TestManagerInit(t *testing.T) {
 	root := t.TempDir()
 	manager := NewManager(Config{DataDir: root})
@@ -47,12 +52,14 @@ func TestGenerateCommitID(t *testing.T) {
 func TestConfigRoundTripAndParentDiscovery(t *testing.T) {
 	root := t.TempDir()
 	config := Config{
-		DataDir:      root,
-		SyncToNotion: true,
-		NotionAPIKey: "secret-token",
-		NotionDBID:   "database-id",
-		SyncToGit:    true,
-		GitRemoteURL: "git@github.com:example/til.git",
+		DataDir:           root,
+		SyncToNotion:      true,
+		NotionAPIKey:      "secret-token",
+		NotionDBID:        "database-id",
+		SyncToGit:         true,
+		GitRemoteURL:      "git@github.com:example/til.git",
+		GitAuthor:         "Ada Lovelace <ada@example.test>",
+		GitCommitPerEntry: true,
 	}
 	require.NoError(t, SaveConfig(config))
 
@@ -70,6 +77,9 @@ func TestConfigRoundTripAndParentDiscovery(t *testing.T) {
 
 	config.NotionAPIKey = "unsafe\nSYNC_TO_GIT=false"
 	assert.ErrorContains(t, SaveConfig(config), "cannot contain a newline")
+	config.NotionAPIKey = "secret-token"
+	config.GitAuthor = "Ada Lovelace"
+	assert.ErrorContains(t, SaveConfig(config), `must look like "Name <email>"`)
 }
 
 func TestLoadConfigOutsideRepository(t *testing.T) {
@@ -16,9 +16,11 @@ type YAMLEntry struct {
 	Message      string    `yaml:"message"`
 	MessageBody  string    `yaml:"message_body,omitempty"`
 	Files        []string  `yaml:"files,omitempty"`
+	Tags         []string  `yaml:"tags,omitempty"`
 	IsCommitted  bool      `yaml:"is_committed"`
 	NotionSynced bool      `yaml:"notion_synced"`
 	CommitID     string    `yaml:"commit_id,omitempty"`
+	NotionPageID string    `yaml:"notion_page_id,omitempty"`
 }
 
 type YAMLStorage struct {
@@ -77,9 +79,11 @@ func ConvertEntriesToYAML(entries []Entry) []YAMLEntry {
 			Message:      entry.Message,
 			MessageBody:  entry.MessageBody,
 			Files:        append([]string(nil), entry.Files...),
+			Tags:         append([]string(nil), entry.Tags...),
 			IsCommitted:  entry.IsCommitted,
 			NotionSynced: entry.NotionSynced,
 			CommitID:     commitID,
+			NotionPageID: entry.NotionPageID,
 		}
 	}
 	return yamlEntries
@@ -93,9 +97,11 @@ func ConvertYAMLToEntries(yamlEntries []YAMLEntry) []Entry {
 			Message:      yamlEntry.Message,
 			MessageBody:  yamlEntry.MessageBody,
 			Files:        append([]string(nil), yamlEntry.Files...),
+			Tags:         append([]string(nil), yamlEntry.Tags...),
 			IsCommitted:  yamlEntry.IsCommitted,
 			NotionSynced: yamlEntry.NotionSynced,
 			CommitID:     yamlEntry.CommitID,
+			NotionPageID: yamlEntry.NotionPageID,
 		}
 	}
 	return entries
@@ -2,12 +2,17 @@ package test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -31,6 +36,30 @@ func TestLocalCLIWorkflow(t *testing.T) {
 	notePath := filepath.Join(repository, "daily note.txt")
 	require.NoError(t, os.WriteFile(notePath, []byte("first version"), 0644))
 	requireCLI(t, binary, repository, "", "add", "daily note.txt")
+	scratchPath := filepath.Join(t.TempDir(), "daily note.txt")
+	require.NoError(t, os.WriteFile(scratchPath, []byte("scratch"), 0644))
+	output, err := runCLI(binary, repository, "", "add", scratchPath)
+	require.Error(t, err, output)
+	assert.Contains(t, output, "already staged")
+	requireCLI(t, binary, repository, "", "add", "--force", scratchPath)
+	requireCLI(t, binary, repository, "", "add", "--force", "daily note.txt")
+	require.NoError(t, os.WriteFile(filepath.Join(repository, "scratch.txt"), []byte("scratch"), 0644))
+	requireCLI(t, binary, repository, "", "add", "scratch.txt")
+	output = requireCLI(t, binary, repository, "", "add", "--list")
+	assert.Equal(t, "- daily note.txt\n- scratch.txt\n", output)
+	output = requireCLI(t, binary, repository, "", "reset", "scratch.txt")
+	assert.Contains(t, output, "Unstaged file: scratch.txt")
+	require.NoError(t, os.MkdirAll(filepath.Join(repository, "snippets", "a"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repository, "snippets", "a", "main.go"), []byte("package a"), 0644))
+	output = requireCLI(t, binary, repository, "from stdin", "add", "-", "--name", "snippets/stdin.txt")
+	assert.Contains(t, output, "Added file: snippets/stdin.txt")
+	output = requireCLI(t, binary, repository, "", "add", "snippets/*")
+	assert.Contains(t, output, "Added file: snippets/a/main.go")
+	output = requireCLI(t, binary, repository, "", "add", "--list")
+	assert.Equal(t, "- daily note.txt\n- snippets/a/main.go\n- snippets/stdin.txt\n", output)
+	output = requireCLI(t, binary, repository, "", "reset", "snippets")
+	assert.Contains(t, output, "Unstaged file: snippets/a/main.go")
+	assert.Contains(t, output, "Unstaged file: snippets/stdin.txt")
 	requireCLI(t, binary, repository, "", "commit", "-m", "First learning")
 	requireCLI(t, binary, repository, "", "commit", "-m", "Second learning")
 
@@ -70,6 +99,41 @@ func TestLocalCLIWorkflow(t *testing.T) {
 	assert.Contains(t, output, "First learning")
 	assert.Contains(t, output, "Second learning, amended")
 
+	output = requireCLI(t, binary, nested, "", "site", "build")
+	assert.Contains(t, output, "Built 4 pages for 2 entries in "+filepath.Join(repository, "site"))
+	assert.FileExists(t, filepath.Join(repository, "site", "index.html"))
+	assert.FileExists(t, filepath.Join(repository, "site", attachmentObject("second version", ".txt")))
+
+	output = requireCLI(t, binary, repository, "", "gc")
+	assert.Contains(t, output, "No missing or orphaned files.")
+	require.NoError(t, os.WriteFile(filepath.Join(repository, "til", "files", "stray.txt"), []byte("stray"), 0644))
+	output = requireCLI(t, binary, repository, "", "gc", "--prune")
+	assert.Contains(t, output, "til/files/stray.txt (5 bytes)")
+	assert.Contains(t, output, "Deleted 1 orphaned file.")
+	assert.NoFileExists(t, filepath.Join(repository, "til", "files", "stray.txt"))
+
+	output = requireCLI(t, binary, repository, "", "doctor")
+	assert.Contains(t, output, "Stored files: ok")
+	assert.Contains(t, output, "No problems found.")
+	objectPath := filepath.Join(repository, "til", filepath.FromSlash(attachmentObject("second version", ".txt")))
+	require.NoError(t, os.WriteFile(objectPath, []byte("tampered"), 0644))
+	output, err = runCLI(binary, repository, "", "doctor")
+	require.Error(t, err, output)
+	assert.Contains(t, output, "Stored files: 1 problem")
+	assert.Contains(t, output, "has 8 bytes but 14 were recorded")
+	assert.Contains(t, output, "til doctor found 1 problem")
+	require.NoError(t, os.WriteFile(objectPath, []byte("second version"), 0644))
+
+	output = requireCLI(t, binary, repository, "", "config", "readme", "--pages", "month")
+	assert.Contains(t, output, "README pages: by month")
+	monthPage := filepath.Join(repository, "til", "pages", time.Now().Format("2006-01")+".md")
+	assert.FileExists(t, monthPage)
+	readme, err := os.ReadFile(filepath.Join(repository, "til", "README.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(readme), "(pages/"+time.Now().Format("2006-01")+".md) | 2 |")
+	requireCLI(t, binary, repository, "", "config", "readme", "--pages", "none")
+	assert.NoFileExists(t, monthPage)
+
 	exportPath := filepath.Join(repository, "entries.json")
 	output = requireCLI(
 		t,
@@ -145,8 +209,8 @@ func TestLocalCLIWorkflow(t *testing.T) {
 	restoredEntries, err := restoredManager.GetLatestEntries(0)
 	require.NoError(t, err)
 	require.Len(t, restoredEntries, 2)
-	restoredAttachment := restoredEntries[1].CommitID + "_daily note.txt"
-	assert.FileExists(t, filepath.Join(newDevice, "til", "files", restoredAttachment))
+	assert.Equal(t, []string{"daily note.txt"}, restoredEntries[1].Files)
+	assert.FileExists(t, filepath.Join(newDevice, "til", attachmentObject("second version", ".txt")))
 
 	output = requireCLI(t, binary, newDevice, "", "config")
 	assert.Contains(t, output, "Notion sync: disabled")
@@ -197,10 +261,31 @@ func TestGitCLIWorkflow(t *testing.T) {
 	showRef := exec.Command("git", "--git-dir", remote, "show-ref")
 	assert.Error(t, showRef.Run(), "commit should remain local until til push")
 
-	output := requireCLI(t, binary, repository, "", "push", "--git")
-	assert.Contains(t, output, "Successfully pushed changes to Git.")
+	output := requireCLI(t, binary, repository, "", "push", "--git", "--dry-run")
+	assert.Contains(t, output, "README.md is up to date.")
+	assert.Contains(t, output, "add 'README.md'")
+	assert.Contains(t, output, "add '"+attachmentObject("Git attachment", ".txt")+"'")
+	assert.Contains(t, output, `Would commit "Add Git-backed learning".`)
+	showRef = exec.Command("git", "--git-dir", remote, "show-ref")
+	assert.Error(t, showRef.Run(), "a dry run does not push")
+	status, err := exec.Command("git", "-C", filepath.Join(repository, "til"), "status", "--porcelain").Output()
+	require.NoError(t, err)
+	assert.NotContains(t, string(status), "A ", "a dry run does not stage files")
+
 	output = requireCLI(t, binary, repository, "", "push", "--git")
+	assert.Contains(t, output, "Successfully pushed changes to Git.")
+	output = requireCLI(t, binary, repository, "", "push", "--to", "git")
 	assert.Contains(t, output, "No new Git changes to commit.")
+	output = requireCLI(t, binary, repository, "", "push", "--git", "--feed", "--dry-run")
+	assert.Contains(t, output, "feed.xml would be updated.")
+	assert.Contains(t, output, "add 'feed.xml'")
+	output = requireCLI(t, binary, repository, "", "push", "--git", "--feed")
+	assert.Contains(t, output, "Committed TIL changes to Git.")
+	output = requireCLI(t, binary, repository, "", "status")
+	assert.Contains(t, output, "git:     1/1 entries synced")
+	output, err = runCLI(binary, repository, "", "push", "--to", "notion")
+	require.Error(t, err)
+	assert.Contains(t, output, "notion sync is not configured")
 
 	publishedRoot := t.TempDir()
 	clone := filepath.Join(publishedRoot, "til")
@@ -214,12 +299,112 @@ func TestGitCLIWorkflow(t *testing.T) {
 	entries, err := publishedManager.GetLatestEntries(0)
 	require.NoError(t, err)
 	require.Len(t, entries, 1)
-	attachment := entries[0].CommitID + "_example.txt"
-	assert.FileExists(t, filepath.Join(clone, "files", attachment))
+	attachment := attachmentObject("Git attachment", ".txt")
+	assert.FileExists(t, filepath.Join(clone, attachment))
 
 	readme, err := os.ReadFile(filepath.Join(clone, "README.md"))
 	require.NoError(t, err)
-	assert.Contains(t, string(readme), "(files/"+attachment+")")
+	assert.Contains(t, string(readme), "[example.txt]("+attachment+")")
+	feed, err := os.ReadFile(filepath.Join(clone, "feed.xml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(feed), "<id>urn:til:"+entries[0].CommitID+"</id>")
+
+	log, err := exec.Command("git", "--git-dir", remote, "log", "--format=%s", "main").Output()
+	require.NoError(t, err)
+	assert.Contains(t, string(log), "Add Git-backed learning\n")
+
+	output = requireCLI(t, binary, repository, "", "config", "git", "--author", "Ada Lovelace <ada@example.test>", "--commit-per-entry")
+	assert.Contains(t, output, "Git author: Ada Lovelace <ada@example.test>")
+	assert.Contains(t, output, "Git commits: one per entry")
+	_, err = runCLI(binary, repository, "", "config", "git", "--author", "Ada")
+	require.Error(t, err)
+
+	// A second device pushes first; the first device merges its entry before
+	// pushing its own.
+	secondDevice := t.TempDir()
+	requireCLI(t, binary, secondDevice, input, "init")
+	requireCLI(t, binary, secondDevice, "", "commit", "-m", "Second device learning")
+	requireCLI(t, binary, secondDevice, "", "push", "--git")
+	requireCLI(t, binary, repository, "", "commit", "-m", "First device learning")
+	output = requireCLI(t, binary, repository, "", "pull", "--git")
+	assert.Contains(t, output, "Imported 1 entry from Git.")
+	assert.Contains(t, output, "Second device learning")
+	output = requireCLI(t, binary, repository, "", "push", "--git")
+	assert.Contains(t, output, "Successfully pushed changes to Git.")
+	author, err := exec.Command("git", "--git-dir", remote, "log", "--format=%an|%s", "--author=Ada", "main").Output()
+	require.NoError(t, err)
+	assert.Contains(t, string(author), "Ada Lovelace|Add First device learning\n")
+	output = requireCLI(t, binary, secondDevice, "", "push", "--git")
+	assert.Contains(t, output, "Imported 1 entry from Git.")
+	assert.Contains(t, output, "First device learning")
+	output = requireCLI(t, binary, secondDevice, "", "pull")
+	assert.Contains(t, output, "Local entries are up to date with Git.")
+	output = requireCLI(t, binary, secondDevice, "", "log")
+	assert.Contains(t, output, "First device learning")
+	assert.Contains(t, output, "Second device learning")
+}
+
+func TestWebhookCLIWorkflow(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []til.EntryJSON
+		headers  []http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entry til.EntryJSON
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, entry)
+		headers = append(headers, r.Header.Clone())
+	}))
+	defer server.Close()
+
+	binary := buildCLI(t)
+	repository := t.TempDir()
+	requireCLI(t, binary, repository, "n\nn\n", "init")
+	requireCLI(t, binary, repository, "", "commit", "-m", "Webhook learning")
+
+	output := requireCLI(
+		t,
+		binary,
+		repository,
+		"",
+		"config",
+		"webhook",
+		"--url",
+		server.URL+"/hook?token=hidden",
+		"--header",
+		"X-Team: learning",
+	)
+	assert.Contains(t, output, "Webhook sync: enabled")
+	assert.Contains(t, output, "Webhook header: X-Team (value redacted)")
+	assert.NotContains(t, output, "hidden")
+
+	output = requireCLI(t, binary, repository, "", "push", "--dry-run")
+	assert.Contains(t, output, `create `)
+	assert.Contains(t, output, "Would deliver 1 entry to")
+	mu.Lock()
+	assert.Empty(t, received, "a dry run does not deliver")
+	mu.Unlock()
+
+	output = requireCLI(t, binary, repository, "", "push", "--to", "webhook")
+	assert.Contains(t, output, "Delivered 1 entry to")
+	output = requireCLI(t, binary, repository, "", "push")
+	assert.Contains(t, output, "Delivered 0 entries to")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 1)
+	assert.Equal(t, "Webhook learning", received[0].Message)
+	assert.Equal(t, "learning", headers[0].Get("X-Team"))
+	assert.Empty(t, headers[0].Get(til.WebhookSignatureHeader))
+
+	output = requireCLI(t, binary, repository, "", "config", "webhook", "--disable")
+	assert.Contains(t, output, "Webhook sync: disabled")
 }
 
 func buildCLI(t *testing.T) string {
@@ -268,6 +453,14 @@ func setGitEnvironment(t *testing.T) {
 	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
 }
 
+// attachmentObject is where til stores attachment content, relative to the
+// repository.
+func attachmentObject(content, extension string) string {
+	digest := sha256.Sum256([]byte(content))
+	name := hex.EncodeToString(digest[:])
+	return "files/objects/" + name[:2] + "/" + name + extension
+}
+
 func createBareRemote(t *testing.T) string {
 	t.Helper()
 	remote := filepath.Join(t.TempDir(), "remote.git")